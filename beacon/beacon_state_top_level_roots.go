@@ -142,33 +142,49 @@ type BeaconStateTopLevelRootsFulu struct {
 }
 
 func ProveBeaconTopLevelRootAgainstBeaconState(beaconTopLevelRoots *VersionedBeaconStateTopLevelRoots, index uint64) (common.Proof, error) {
-	var v reflect.Value
+	roots, treeHeight, err := beaconTopLevelRoots.Roots()
+	if err != nil {
+		return nil, err
+	}
+	return common.GetProof(roots, index, treeHeight)
+}
+
+// Tree returns the tree of the top-level roots, whose root is the beacon state root. The trees of
+// the state's lists can be grafted below their fields.
+func (v *VersionedBeaconStateTopLevelRoots) Tree() (common.GraftedTree, error) {
+	roots, treeHeight, err := v.Roots()
+	if err != nil {
+		return nil, err
+	}
+	tree, err := common.ComputeMerkleTreeFromLeaves(roots, treeHeight)
+	if err != nil {
+		return nil, err
+	}
+	return common.GraftedTree{1: common.LayeredTree(tree)}, nil
+}
+
+// Roots returns the top-level roots in field order, and the height of the tree they are the
+// leaves of.
+func (v *VersionedBeaconStateTopLevelRoots) Roots() ([]phase0.Root, uint64, error) {
+	var r reflect.Value
 	var treeHeight uint64
-	switch beaconTopLevelRoots.Version {
+	switch v.Version {
 	case spec.DataVersionDeneb:
-		v = reflect.ValueOf(*beaconTopLevelRoots.Deneb)
+		r = reflect.ValueOf(*v.Deneb)
 		treeHeight = BEACON_STATE_TREE_HEIGHT_DENEB
 	case spec.DataVersionElectra:
-		v = reflect.ValueOf(*beaconTopLevelRoots.Electra)
+		r = reflect.ValueOf(*v.Electra)
 		treeHeight = BEACON_STATE_TREE_HEIGHT_ELECTRA
 	case spec.DataVersionFulu:
-		v = reflect.ValueOf(*beaconTopLevelRoots.Fulu)
+		r = reflect.ValueOf(*v.Fulu)
 		treeHeight = BEACON_STATE_TREE_HEIGHT_FULU
 	default:
-		return nil, errors.New("unsupported beacon state version")
+		return nil, 0, errors.New("unsupported beacon state version")
 	}
 
-	beaconTopLevelRootsList := make([]interface{}, v.NumField())
-	for i := 0; i < v.NumField(); i++ {
-		r := v.Field(i).Interface()
-		typedR := r.(*phase0.Root)
-		beaconTopLevelRootsList[i] = *typedR
+	roots := make([]phase0.Root, r.NumField())
+	for i := 0; i < r.NumField(); i++ {
+		roots[i] = *r.Field(i).Interface().(*phase0.Root)
 	}
-
-	roots := make([]phase0.Root, len(beaconTopLevelRootsList))
-	for i, v := range beaconTopLevelRootsList {
-		roots[i] = v.(phase0.Root)
-	}
-
-	return common.GetProof(roots, index, treeHeight)
+	return roots, treeHeight, nil
 }
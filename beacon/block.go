@@ -16,6 +16,24 @@ func ProveStateRootAgainstBlockHeader(b *phase0.BeaconBlockHeader) (common.Proof
 	return common.GetProof(beaconBlockHeaderContainerRoots, STATE_ROOT_INDEX, BEACON_BLOCK_HEADER_TREE_HEIGHT)
 }
 
+// BlockHeaderTree returns the tree of a block header, with the tree of its state grafted below its
+// state root.
+func BlockHeaderTree(b *phase0.BeaconBlockHeader, beaconStateTree common.Tree) (common.GraftedTree, error) {
+	blockHeaderFieldRoots, err := GetBlockHeaderFieldRoots(b)
+	if err != nil {
+		return nil, err
+	}
+	blockHeaderTree, err := common.ComputeMerkleTreeFromLeaves(blockHeaderFieldRoots, BEACON_BLOCK_HEADER_TREE_HEIGHT)
+	if err != nil {
+		return nil, err
+	}
+
+	return common.GraftedTree{
+		1:                 common.LayeredTree(blockHeaderTree),
+		STATE_ROOT_GINDEX: beaconStateTree,
+	}, nil
+}
+
 func GetBlockHeaderFieldRoots(blockHeader *phase0.BeaconBlockHeader) ([]phase0.Root, error) {
 	blockHeaderContainerRoots := make([]phase0.Root, BEACON_BLOCK_HEADER_NUM_FIELDS)
 
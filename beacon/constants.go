@@ -9,6 +9,7 @@ const (
 	BEACON_STATE_TREE_HEIGHT_FULU    = uint64(6)
 	BALANCE_TREE_HEIGHT              = uint64(38)
 	VALIDATOR_TREE_HEIGHT            = uint64(40)
+	VALIDATOR_FIELDS_TREE_HEIGHT     = uint64(3)
 
 	STATE_ROOT_INDEX = uint64(3)
 
@@ -0,0 +1,94 @@
+package beacon
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/common"
+)
+
+// Generalized indices of the beacon state nodes that EigenPod proofs are made of, relative to the
+// beacon state root. Prefix them with STATE_ROOT_GINDEX (with common.ConcatGindices) to make them
+// relative to the block header root instead, which is what the EIP-4788 oracle provides.
+
+// STATE_ROOT_GINDEX is the gindex of the state root in a beacon block header.
+var STATE_ROOT_GINDEX = common.FieldGindex(BEACON_BLOCK_HEADER_TREE_HEIGHT, STATE_ROOT_INDEX)
+
+// BeaconStateTreeHeight returns the height of the tree of the beacon state's top-level fields.
+func BeaconStateTreeHeight(version spec.DataVersion) (uint64, error) {
+	switch version {
+	case spec.DataVersionDeneb:
+		return BEACON_STATE_TREE_HEIGHT_DENEB, nil
+	case spec.DataVersionElectra:
+		return BEACON_STATE_TREE_HEIGHT_ELECTRA, nil
+	case spec.DataVersionFulu:
+		return BEACON_STATE_TREE_HEIGHT_FULU, nil
+	default:
+		return 0, errors.New("unsupported beacon state version")
+	}
+}
+
+// BeaconStateFieldGindex returns the gindex of the top-level field `index` (e.g. VALIDATORS_INDEX).
+func BeaconStateFieldGindex(version spec.DataVersion, index uint64) (uint64, error) {
+	treeHeight, err := BeaconStateTreeHeight(version)
+	if err != nil {
+		return 0, err
+	}
+	return common.FieldGindex(treeHeight, index), nil
+}
+
+// ValidatorGindex returns the gindex of validator `validatorIndex`'s container root.
+func ValidatorGindex(version spec.DataVersion, validatorIndex uint64) (uint64, error) {
+	if validatorIndex >= 1<<VALIDATOR_TREE_HEIGHT {
+		return 0, fmt.Errorf("invalid validator index %d", validatorIndex)
+	}
+	validators, err := BeaconStateFieldGindex(version, VALIDATORS_INDEX)
+	if err != nil {
+		return 0, err
+	}
+	return common.ConcatGindices(validators, common.ListElementGindex(VALIDATOR_TREE_HEIGHT, validatorIndex))
+}
+
+// ValidatorFieldGindex returns the gindex of field `field` (e.g. VALIDATOR_PUBKEY_INDEX) of
+// validator `validatorIndex`.
+func ValidatorFieldGindex(version spec.DataVersion, validatorIndex uint64, field uint64) (uint64, error) {
+	validator, err := ValidatorGindex(version, validatorIndex)
+	if err != nil {
+		return 0, err
+	}
+	// the 8 fields of a validator are the leaves of a 3-level tree
+	return common.ConcatGindices(validator, common.FieldGindex(VALIDATOR_FIELDS_TREE_HEIGHT, field))
+}
+
+// ValidatorsLengthGindex returns the gindex of the length of the validators list.
+func ValidatorsLengthGindex(version spec.DataVersion) (uint64, error) {
+	validators, err := BeaconStateFieldGindex(version, VALIDATORS_INDEX)
+	if err != nil {
+		return 0, err
+	}
+	return common.ConcatGindices(validators, common.LIST_LENGTH_GINDEX)
+}
+
+// BalanceGindex returns the gindex of the leaf holding validator `validatorIndex`'s balance (4
+// balances are packed in each leaf).
+func BalanceGindex(version spec.DataVersion, validatorIndex uint64) (uint64, error) {
+	if validatorIndex >= 1<<VALIDATOR_TREE_HEIGHT {
+		return 0, fmt.Errorf("invalid validator index %d", validatorIndex)
+	}
+	balances, err := BeaconStateFieldGindex(version, BALANCES_INDEX)
+	if err != nil {
+		return 0, err
+	}
+	return common.ConcatGindices(balances, common.ListElementGindex(BALANCE_TREE_HEIGHT, validatorIndex/4))
+}
+
+// BalancesLengthGindex returns the gindex of the length of the balances list.
+func BalancesLengthGindex(version spec.DataVersion) (uint64, error) {
+	balances, err := BeaconStateFieldGindex(version, BALANCES_INDEX)
+	if err != nil {
+		return 0, err
+	}
+	return common.ConcatGindices(balances, common.LIST_LENGTH_GINDEX)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/deneb"
 	"github.com/attestantio/go-eth2-client/spec/electra"
 	"github.com/attestantio/go-eth2-client/spec/fulu"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 )
 
 func GetGenesisTime(state *spec.VersionedBeaconState) (uint64, error) {
@@ -22,6 +23,34 @@ func GetGenesisTime(state *spec.VersionedBeaconState) (uint64, error) {
 	}
 }
 
+// GetGenesisValidatorsRoot returns the genesis validators root of the chain `state` belongs to.
+func GetGenesisValidatorsRoot(state *spec.VersionedBeaconState) (phase0.Root, error) {
+	switch state.Version {
+	case spec.DataVersionFulu:
+		return state.Fulu.GenesisValidatorsRoot, nil
+	case spec.DataVersionElectra:
+		return state.Electra.GenesisValidatorsRoot, nil
+	case spec.DataVersionDeneb:
+		return state.Deneb.GenesisValidatorsRoot, nil
+	default:
+		return phase0.Root{}, errors.New("unsupported beacon state version")
+	}
+}
+
+// GetLatestExecutionBlockNumber returns the number of the latest execution block included in `state`.
+func GetLatestExecutionBlockNumber(state *spec.VersionedBeaconState) (uint64, error) {
+	switch state.Version {
+	case spec.DataVersionFulu:
+		return state.Fulu.LatestExecutionPayloadHeader.BlockNumber, nil
+	case spec.DataVersionElectra:
+		return state.Electra.LatestExecutionPayloadHeader.BlockNumber, nil
+	case spec.DataVersionDeneb:
+		return state.Deneb.LatestExecutionPayloadHeader.BlockNumber, nil
+	default:
+		return 0, errors.New("unsupported beacon state version")
+	}
+}
+
 func CreateVersionedSignedBlock(block interface{}) (spec.VersionedSignedBeaconBlock, error) {
 	var versionedBlock spec.VersionedSignedBeaconBlock
 
@@ -6,7 +6,9 @@ import (
 
 	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/fatih/color"
 )
@@ -39,7 +41,7 @@ func AssignSubmitterCommand(args TAssignSubmitterArgs) error {
 		return fmt.Errorf("failed to reach eth node for chain id: %w", err)
 	}
 
-	ownerAccount, err := utils.PrepareAccount(&args.Sender, chainId, false /* noSend */)
+	txMgr, err := utils.PrepareTxManager(eth, args.Sender, chainId, false /* noSend */, args.Verbose)
 	if err != nil {
 		return fmt.Errorf("failed to parse --sender: %w", err)
 	}
@@ -63,12 +65,17 @@ func AssignSubmitterCommand(args TAssignSubmitterArgs) error {
 		utils.PanicIfNoConsent(fmt.Sprintf("This will update your EigenPod to allow %s to submit proofs on its behalf. As the EigenPod's owner, you can always change this later.", newSubmitter))
 	}
 
-	txn, err := pod.SetProofSubmitter(ownerAccount.TransactionOptions, newSubmitter)
+	txn, err := txMgr.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return pod.SetProofSubmitter(opts, newSubmitter)
+	})
 	if err != nil {
 		return fmt.Errorf("error updating submitter role: %w", err)
 	}
 
 	color.Green("submitted txn: %s", txn.Hash())
+	if _, err := txMgr.WaitAll(ctx); err != nil {
+		return fmt.Errorf("error waiting for submitter update: %w", err)
+	}
 	color.Green("updated!")
 
 	return nil
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/fatih/color"
+)
+
+type TCancelNonceArgs struct {
+	EthNode  string
+	Sender   string
+	Nonce    uint64
+	NoPrompt bool
+	Verbose  bool
+}
+
+func CancelNonceCommand(args TCancelNonceArgs) error {
+	ctx := context.Background()
+
+	eth, err := ethclient.DialContext(ctx, args.EthNode)
+	utils.PanicOnError("failed to reach eth node", err)
+
+	chainId, err := eth.ChainID(ctx)
+	utils.PanicOnError("failed to load chainId", err)
+
+	txMgr, err := utils.PrepareTxManager(eth, args.Sender, chainId, false /* noSend */, args.Verbose)
+	utils.PanicOnError("failed to parse private key", err)
+	from := txMgr.Owner().FromAddress
+
+	confirmedNonce, err := eth.NonceAt(ctx, from, nil)
+	utils.PanicOnError("failed to load confirmed nonce", err)
+
+	pendingNonce, err := eth.PendingNonceAt(ctx, from)
+	utils.PanicOnError("failed to load pending nonce", err)
+
+	nonce := args.Nonce
+	if nonce == math.MaxUint64 {
+		if pendingNonce <= confirmedNonce {
+			fmt.Printf("%s has no pending transactions (nonce: %d).\n", from.Hex(), confirmedNonce)
+			return nil
+		}
+		nonce = confirmedNonce
+	} else if nonce < confirmedNonce {
+		return fmt.Errorf("nonce %d was already mined (%s's next nonce is %d)", nonce, from.Hex(), confirmedNonce)
+	}
+
+	if !args.NoPrompt {
+		utils.PanicIfNoConsent(fmt.Sprintf("This will replace any pending transaction from %s with nonce %d by an empty transfer to itself.", from.Hex(), nonce))
+	}
+
+	txn, err := txMgr.Cancel(ctx, nonce)
+	utils.PanicOnError("failed to cancel nonce", err)
+
+	color.Green("submitted cancellation %s (nonce %d). waiting for it to be mined...", txn.Hash().Hex(), nonce)
+
+	receipts, err := txMgr.WaitAll(ctx)
+	utils.PanicOnError("failed waiting for cancellation", err)
+
+	color.Green("nonce %d cancelled: %s", nonce, receipts[0].TxHash.Hex())
+	return nil
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 
 	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
+	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/fatih/color"
@@ -25,6 +25,7 @@ type TCheckpointCommandArgs struct {
 	BatchSize           uint64
 	ForceCheckpoint     bool
 	Verbose             bool
+	ProofsFromNode      bool
 }
 
 func CheckpointCommand(args TCheckpointCommandArgs) error {
@@ -46,18 +47,25 @@ func CheckpointCommand(args TCheckpointCommandArgs) error {
 	eigenpod, err := EigenPod.NewEigenPod(common.HexToAddress(args.EigenpodAddress), eth)
 	utils.PanicOnError("failed to connect to eigenpod", err)
 
+	var txMgr *utils.TxManager
+	if len(args.Sender) > 0 || args.SimulateTransaction {
+		txMgr, err = utils.PrepareTxManager(eth, args.Sender, chainId, args.SimulateTransaction, args.Verbose)
+		utils.PanicOnError("failed to parse private key", err)
+	}
+
 	if currentCheckpoint == 0 {
 		if len(args.Sender) > 0 || args.SimulateTransaction {
 			if !args.NoPrompt && !args.SimulateTransaction {
 				utils.PanicIfNoConsent(utils.StartCheckpointProofConsent())
 			}
 
-			txn, err := utils.StartCheckpoint(ctx, args.EigenpodAddress, args.Sender, chainId, eth, args.ForceCheckpoint, args.SimulateTransaction)
+			txn, err := utils.StartCheckpoint(ctx, txMgr, args.EigenpodAddress, eth, args.ForceCheckpoint)
 			utils.PanicOnError("failed to start checkpoint", err)
 
 			if !args.SimulateTransaction {
 				color.Green("starting checkpoint: %s.. (waiting for txn to be mined)", txn.Hash().Hex())
-				bind.WaitMined(ctx, eth, txn)
+				_, err = txMgr.WaitAll(ctx)
+				utils.PanicOnError("failed to wait for checkpoint to start", err)
 				color.Green("started checkpoint! txn: %s", txn.Hash().Hex())
 			} else {
 				gas := txn.Gas()
@@ -91,10 +99,19 @@ func CheckpointCommand(args TCheckpointCommandArgs) error {
 		color.Green("pod has active checkpoint! checkpoint timestamp: %d", currentCheckpoint)
 	}
 
-	proof, err := core.GenerateCheckpointProof(ctx, args.EigenpodAddress, eth, chainId, beaconClient, isVerbose)
+	var proof *eigenpodproofs.VerifyCheckpointProofsCallParams
+	if args.ProofsFromNode {
+		proof, err = core.GenerateCheckpointProofFromNode(ctx, args.EigenpodAddress, eth, chainId, beaconClient, isVerbose)
+	} else {
+		proof, err = core.GenerateCheckpointProof(ctx, args.EigenpodAddress, eth, chainId, beaconClient, isVerbose)
+	}
 	utils.PanicOnError("failed to generate checkpoint proof", err)
 
-	txns, err := core.SubmitCheckpointProof(ctx, args.Sender, args.EigenpodAddress, chainId, proof, eth, args.BatchSize, args.NoPrompt, args.SimulateTransaction, args.Verbose)
+	if txMgr == nil {
+		utils.PanicOnError("no private key provided to submit checkpoint proofs", errors.New("no --sender"))
+	}
+
+	txns, err := core.SubmitCheckpointProof(ctx, txMgr, args.EigenpodAddress, proof, eth, args.BatchSize, args.NoPrompt, args.Verbose)
 	if args.SimulateTransaction {
 		printableTxns := lo.Map(txns, func(txn *types.Transaction, _ int) Transaction {
 			return Transaction{
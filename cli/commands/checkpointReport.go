@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// How far back to look for checkpoints when --from-block isn't given (~30 days)
+const DEFAULT_REPORT_LOOKBACK_BLOCKS = uint64(216_000)
+
+type TCheckpointReportArgs struct {
+	EigenpodAddress string
+	Node            string
+	FromBlock       uint64
+	// 0 for the latest block
+	ToBlock uint64
+
+	// "json" (default) or "csv"
+	Format string
+}
+
+func CheckpointReportCommand(args TCheckpointReportArgs) error {
+	ctx := context.Background()
+	if args.Format != "" && args.Format != "json" && args.Format != "csv" {
+		return fmt.Errorf("unsupported --format %s (expected json or csv)", args.Format)
+	}
+
+	eth, _, err := utils.GetEthClient(ctx, args.Node)
+	utils.PanicOnError("failed to reach eth node", err)
+
+	toBlock := args.ToBlock
+	if toBlock == 0 {
+		toBlock, err = eth.BlockNumber(ctx)
+		utils.PanicOnError("failed to fetch latest block number", err)
+	}
+	fromBlock := args.FromBlock
+	if fromBlock == 0 && toBlock > DEFAULT_REPORT_LOOKBACK_BLOCKS {
+		fromBlock = toBlock - DEFAULT_REPORT_LOOKBACK_BLOCKS
+	}
+	if fromBlock > toBlock {
+		return fmt.Errorf("--from-block %d is after --to-block %d", fromBlock, toBlock)
+	}
+
+	ledger, err := core.GetCheckpointLedger(ctx, eth, args.EigenpodAddress, fromBlock, toBlock)
+	utils.PanicOnError("failed to reconstruct checkpoints", err)
+
+	if args.Format == "csv" {
+		return printCheckpointLedgerAsCSV(ledger)
+	}
+	PrintAsJSON(ledger)
+	return nil
+}
+
+// printCheckpointLedgerAsCSV writes one row per ledger line: each validator's balance change, the
+// pod's native ETH balance change, any slashing factor change, and the checkpoint's total.
+func printCheckpointLedgerAsCSV(ledger []core.CheckpointLedgerEntry) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write([]string{
+		"checkpointTimestamp", "finalizedBlock", "finalizedAt", "finalizedTxHash",
+		"item", "pubkeyHash", "validatorIndex", "previousGwei", "newGwei", "deltaGwei",
+	}); err != nil {
+		return err
+	}
+
+	for _, entry := range ledger {
+		row := func(item, pubkeyHash, validatorIndex, previous, next, delta string) []string {
+			return []string{
+				fmt.Sprintf("%d", entry.CheckpointTimestamp),
+				fmt.Sprintf("%d", entry.FinalizedBlock),
+				time.Unix(int64(entry.FinalizedAt), 0).UTC().Format(time.RFC3339),
+				entry.FinalizedTx,
+				item, pubkeyHash, validatorIndex, previous, next, delta,
+			}
+		}
+
+		rows := [][]string{}
+		for _, validator := range entry.Validators {
+			item := "validator"
+			if validator.Withdrawn {
+				item = "validator-withdrawn"
+			}
+			previous := ""
+			if validator.PreviousBalanceGwei != nil {
+				previous = fmt.Sprintf("%d", *validator.PreviousBalanceGwei)
+			}
+			validatorIndex := ""
+			if validator.ValidatorIndex != nil {
+				validatorIndex = fmt.Sprintf("%d", *validator.ValidatorIndex)
+			}
+			rows = append(rows, row(item, validator.PubkeyHash, validatorIndex, previous, fmt.Sprintf("%d", validator.NewBalanceGwei), formatOptionalInt(validator.DeltaGwei)))
+		}
+		rows = append(rows, row("pod-balance", "", "", "", "", formatOptionalInt(entry.PodBalanceDeltaGwei)))
+		if entry.SlashingFactor != nil {
+			rows = append(rows, row("slashing-factor", "", "", fmt.Sprintf("%d", entry.SlashingFactor.Previous), fmt.Sprintf("%d", entry.SlashingFactor.New), ""))
+		}
+		newTotalSharesGwei := ""
+		if entry.NewTotalSharesWei != nil {
+			newTotalSharesGwei = weiToGwei(entry.NewTotalSharesWei).String()
+		}
+		rows = append(rows, row("total-shares", "", "", "", newTotalSharesGwei, weiToGwei(entry.TotalShareDeltaWei).String()))
+
+		if err := w.WriteAll(rows); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+func weiToGwei(wei *big.Int) *big.Int {
+	return new(big.Int).Quo(wei, big.NewInt(params.GWei))
+}
+
+func formatOptionalInt(value *big.Int) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/fatih/color"
 )
@@ -23,6 +24,30 @@ type TCompleteWithdrawalArgs struct {
 	EigenPod    string
 	Sender      string
 	EstimateGas bool
+	NoPrompt    bool
+	UseJSON     bool
+
+	// only complete the withdrawals with these roots. By default, every completable withdrawal is considered.
+	WithdrawalRoots []string
+
+	// redeposit the withdrawn shares instead of receiving tokens
+	ReceiveAsShares bool
+}
+
+type CompletedWithdrawal struct {
+	Root            string
+	StartBlock      uint32
+	Strategies      []string
+	SharesWei       []*big.Int
+	BeaconSharesWei *big.Int
+}
+
+type CompleteWithdrawalsResult struct {
+	Withdrawals     []CompletedWithdrawal
+	ReceiveAsTokens bool
+	TotalBeaconWei  *big.Int
+	Transaction     *Transaction `json:",omitempty"`
+	TxHash          string       `json:",omitempty"`
 }
 
 func DelegationManager(chainId *big.Int) common.Address {
@@ -43,6 +68,7 @@ func CompleteAllWithdrawalsCommand(args TCompleteWithdrawalArgs) error {
 	ctx := context.Background()
 
 	isSimulation := args.EstimateGas
+	receiveAsTokens := !args.ReceiveAsShares
 
 	eth, err := ethclient.DialContext(ctx, args.EthNode)
 	utils.PanicOnError("failed to reach eth node", err)
@@ -50,7 +76,7 @@ func CompleteAllWithdrawalsCommand(args TCompleteWithdrawalArgs) error {
 	chainId, err := eth.ChainID(ctx)
 	utils.PanicOnError("failed to load chainId", err)
 
-	acc, err := utils.PrepareAccount(&args.Sender, chainId, isSimulation)
+	txMgr, err := utils.PrepareTxManager(eth, args.Sender, chainId, isSimulation, false /* verbose */)
 	utils.PanicOnError("failed to parse private key", err)
 
 	curBlockNumber, err := eth.BlockNumber(ctx)
@@ -61,7 +87,7 @@ func CompleteAllWithdrawalsCommand(args TCompleteWithdrawalArgs) error {
 
 	reg, err := pod.WithdrawableRestakedExecutionLayerGwei(nil)
 	utils.PanicOnError("failed to fetch REG", err)
-	rew := utils.GweiToWei(new(big.Float).SetUint64(reg))
+	rew := utils.IGweiToWei(new(big.Int).SetUint64(reg))
 
 	podOwner, err := pod.PodOwner(nil)
 	utils.PanicOnError("failed to read podOwner", err)
@@ -69,105 +95,152 @@ func CompleteAllWithdrawalsCommand(args TCompleteWithdrawalArgs) error {
 	delegationManager, err := IDelegationManager.NewIDelegationManager(DelegationManager(chainId), eth)
 	utils.PanicOnError("failed to reach delegation manager", err)
 
-	minDelay, err := delegationManager.MinWithdrawalDelayBlocks(nil)
-	utils.PanicOnError("failed to read MinWithdrawalDelayBlocks", err)
-
-	queuedWithdrawals, err := delegationManager.GetQueuedWithdrawals(nil, podOwner)
+	queuedWithdrawals, err := core.GetQueuedWithdrawals(delegationManager, podOwner)
 	utils.PanicOnError("failed to read queuedWithdrawals", err)
 
-	eligibleWithdrawals := lo.Map(queuedWithdrawals.Withdrawals, func(withdrawal IDelegationManager.IDelegationManagerTypesWithdrawal, index int) *IDelegationManager.IDelegationManagerTypesWithdrawal {
-		isBeaconWithdrawal := len(withdrawal.Strategies) == 1 && withdrawal.Strategies[0].Cmp(core.BeaconStrategy()) == 0
-		isCompletable := curBlockNumber > uint64(withdrawal.StartBlock+minDelay)
-		if isBeaconWithdrawal && isCompletable {
-			return &withdrawal
+	var candidates []core.QueuedWithdrawal
+	if len(args.WithdrawalRoots) != 0 {
+		byRoot := lo.KeyBy(queuedWithdrawals, func(w core.QueuedWithdrawal) common.Hash { return w.Root })
+		for _, root := range args.WithdrawalRoots {
+			withdrawal, ok := byRoot[common.HexToHash(root)]
+			if !ok {
+				return fmt.Errorf("withdrawal %s is not queued for podOwner(%s)", root, podOwner)
+			}
+			if !withdrawal.IsCompletable(curBlockNumber) {
+				return fmt.Errorf("withdrawal %s is not completable until block #%d (current block: %d)", root, withdrawal.CompletableAtBlock, curBlockNumber)
+			}
+			candidates = append(candidates, withdrawal)
 		}
-		return nil
-	})
-
-	readyWithdrawalCount := len(lo.Filter(eligibleWithdrawals, func(withdrawal *IDelegationManager.IDelegationManagerTypesWithdrawal, index int) bool {
-		return withdrawal != nil
-	}))
+	} else {
+		candidates = lo.Filter(queuedWithdrawals, func(w core.QueuedWithdrawal, _ int) bool {
+			return w.IsCompletable(curBlockNumber)
+		})
+	}
 
-	if readyWithdrawalCount == 0 {
+	if len(candidates) == 0 {
 		fmt.Printf("Your pod has no eligible withdrawals.\n")
 		return nil
 	}
 
-	var runningSumWei *big.Float = new(big.Float)
-
-	affordedWithdrawals := lo.Map(eligibleWithdrawals, func(withdrawal *IDelegationManager.IDelegationManagerTypesWithdrawal, index int) *IDelegationManager.IDelegationManagerTypesWithdrawal {
-		if withdrawal == nil {
-			return nil
+	// Native ETH paid out as tokens comes from the pod's withdrawable ETH, so only some withdrawals may be
+	// affordable. Withdrawals without native ETH, or completed as shares, are always completable.
+	selected := candidates
+	if receiveAsTokens {
+		needsFunding := lo.Filter(candidates, func(w core.QueuedWithdrawal, _ int) bool { return w.BeaconSharesWei.Sign() > 0 })
+		free := lo.Filter(candidates, func(w core.QueuedWithdrawal, _ int) bool { return w.BeaconSharesWei.Sign() == 0 })
+
+		var afforded []core.QueuedWithdrawal
+		if len(args.WithdrawalRoots) != 0 {
+			afforded = needsFunding
+			if total := sumBeaconShares(needsFunding); total.Cmp(rew) > 0 {
+				return fmt.Errorf("the selected withdrawals need %sETH of native ETH, but the pod only has %sETH withdrawable. Checkpoint first, or pass --receive-as-shares", utils.IweiToEther(total), utils.IweiToEther(rew))
+			}
+		} else {
+			amounts := lo.Map(needsFunding, func(w core.QueuedWithdrawal, _ int) *big.Int { return w.BeaconSharesWei })
+			for _, i := range core.PackWithdrawals(amounts, rew) {
+				afforded = append(afforded, needsFunding[i])
+			}
 		}
-		withdrawalShares := queuedWithdrawals.Shares[index][0]
-		// if REW > runningSumWei + withdrawalShares, we can complete with withdrawal.
-		if rew.Cmp(
-			new(big.Float).Add(
-				runningSumWei,
-				new(big.Float).SetInt(withdrawalShares),
-			),
-		) > 0 {
-			runningSumWei = new(big.Float).Add(runningSumWei, new(big.Float).SetInt(withdrawalShares))
-			return withdrawal
+
+		if len(afforded) == 0 && len(free) == 0 {
+			color.Yellow("WARN: Your pod has %d withdrawal(s) available, but your pod does not have enough funding to proceed.\n", len(needsFunding))
+			color.Yellow("Consider checkpointing to claim beacon rewards, or depositing ETH and checkpointing to complete these withdrawals.\n\n")
+			return errors.New("Insufficient funds")
 		}
-		return nil
-	})
 
-	affordedWithdrawals = lo.Filter(affordedWithdrawals, func(withdrawal *IDelegationManager.IDelegationManagerTypesWithdrawal, index int) bool {
-		return withdrawal != nil
-	})
+		if len(afforded) != len(needsFunding) {
+			color.Yellow("WARN: Your pod has %d native ETH withdrawal(s) available, but you only have enough balance to satisfy %d of them.\n", len(needsFunding), len(afforded))
+			color.Yellow("Consider checkpointing to claim beacon rewards, or depositing ETH and checkpointing to complete these withdrawals.\n\n")
+		}
 
-	if len(affordedWithdrawals) == 0 && readyWithdrawalCount > 0 {
-		color.Yellow("WARN: Your pod has %d withdrawal(s) available, but your pod does not have enough funding to proceed.\n", readyWithdrawalCount)
-		color.Yellow("Consider checkpointing to claim beacon rewards, or depositing ETH and checkpointing to complete these withdrawals.\n\n")
-		return errors.New("Insufficient funds")
+		selected = append(free, afforded...)
 	}
 
-	if len(affordedWithdrawals) != readyWithdrawalCount {
-		color.Yellow("WARN: Your pod has %d withdrawal(s) available, but you only have enough balance to satisfy %d of them.\n", readyWithdrawalCount, len(affordedWithdrawals))
-		color.Yellow("Consider checkpointing to claim beacon rewards, or depositing ETH and checkpointing to complete these withdrawals.\n\n")
+	result := CompleteWithdrawalsResult{
+		Withdrawals: lo.Map(selected, func(w core.QueuedWithdrawal, _ int) CompletedWithdrawal {
+			return CompletedWithdrawal{
+				Root:            w.Root.Hex(),
+				StartBlock:      w.Withdrawal.StartBlock,
+				Strategies:      lo.Map(w.Withdrawal.Strategies, func(s common.Address, _ int) string { return s.Hex() }),
+				SharesWei:       w.Shares,
+				BeaconSharesWei: w.BeaconSharesWei,
+			}
+		}),
+		ReceiveAsTokens: receiveAsTokens,
+		TotalBeaconWei:  sumBeaconShares(selected),
 	}
 
-	fmt.Printf("Your podOwner(%s) has %d withdrawal(s) that can be completed right now.\n", podOwner.Hex(), len(affordedWithdrawals))
-	runningSumWeiInt, _ := runningSumWei.Int(nil)
-	fmt.Printf("Total ETH on all withdrawals: %sETH\n", utils.GweiToEther(utils.WeiToGwei(runningSumWeiInt)).String())
+	if !args.UseJSON {
+		fmt.Printf("Your podOwner(%s) has %d withdrawal(s) that can be completed right now.\n", podOwner.Hex(), len(selected))
+		for _, w := range result.Withdrawals {
+			fmt.Printf("\t- %s (queued at block %d, %d strateg(ies), %sETH native)\n", w.Root, w.StartBlock, len(w.Strategies), utils.IweiToEther(w.BeaconSharesWei).String())
+		}
+		fmt.Printf("Total native ETH on all withdrawals: %sETH\n", utils.IweiToEther(result.TotalBeaconWei).String())
+		if receiveAsTokens {
+			fmt.Printf("These will be received as tokens.\n")
+		} else {
+			fmt.Printf("These will be redeposited as shares.\n")
+		}
+	}
 
 	if !isSimulation {
-		utils.PanicIfNoConsent("Would you like to continue?")
-	} else {
+		if !args.NoPrompt {
+			utils.PanicIfNoConsent("Would you like to continue?")
+		}
+	} else if !args.UseJSON {
 		color.Yellow("THIS IS A SIMULATION. No transaction will be recorded onchain.\n")
 	}
 
-	withdrawals := lo.Map(affordedWithdrawals, func(w *IDelegationManager.IDelegationManagerTypesWithdrawal, i int) IDelegationManager.IDelegationManagerTypesWithdrawal {
-		return *w
+	withdrawals := lo.Map(selected, func(w core.QueuedWithdrawal, _ int) IDelegationManager.IDelegationManagerTypesWithdrawal {
+		return w.Withdrawal
 	})
 
-	tokens := lo.Map(withdrawals, func(_ IDelegationManager.IDelegationManagerTypesWithdrawal, _ int) []common.Address {
-		return []common.Address{common.BigToAddress(big.NewInt(0))}
-	})
+	tokens := make([][]common.Address, len(withdrawals))
+	for i, withdrawal := range withdrawals {
+		tokens[i], err = core.WithdrawalTokens(eth, withdrawal)
+		utils.PanicOnError("failed to load withdrawal tokens", err)
+	}
 
-	receiveAsTokens := lo.Map(withdrawals, func(_ IDelegationManager.IDelegationManagerTypesWithdrawal, _ int) bool {
-		return true
+	receiveAsTokensList := lo.Map(withdrawals, func(_ IDelegationManager.IDelegationManagerTypesWithdrawal, _ int) bool {
+		return receiveAsTokens
 	})
 
-	txn, err := delegationManager.CompleteQueuedWithdrawals(acc.TransactionOptions, withdrawals, tokens, receiveAsTokens)
+	txn, err := txMgr.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return delegationManager.CompleteQueuedWithdrawals(opts, withdrawals, tokens, receiveAsTokensList)
+	})
 	utils.PanicOnError("CompleteQueuedWithdrawals failed.", err)
 
 	if !isSimulation {
-		_, err := bind.WaitMined(ctx, eth, txn)
+		receipts, err := txMgr.WaitAll(ctx)
 		utils.PanicOnError("waitMined failed", err)
 
-		color.Green("%s\n", txn.Hash().Hex())
+		result.TxHash = receipts[0].TxHash.Hex()
+		if !args.UseJSON {
+			color.Green("%s\n", result.TxHash)
+		}
 	} else {
-		PrintAsJSON(Transaction{
-			Type:     "complete-withdrawals",
-			To:       txn.To().Hex(),
-			CallData: common.Bytes2Hex(txn.Data()),
-			GasEstimateGwei: func() *uint64 {
-				gas := txn.Gas()
-				return &gas
-			}(),
-		})
+		gas := txn.Gas()
+		result.Transaction = &Transaction{
+			Type:            "complete-withdrawals",
+			To:              txn.To().Hex(),
+			CallData:        common.Bytes2Hex(txn.Data()),
+			GasEstimateGwei: &gas,
+		}
+		if !args.UseJSON {
+			PrintAsJSON(*result.Transaction)
+		}
+	}
+
+	if args.UseJSON {
+		PrintAsJSON(result)
 	}
 	return nil
 }
+
+func sumBeaconShares(withdrawals []core.QueuedWithdrawal) *big.Int {
+	sum := big.NewInt(0)
+	for _, w := range withdrawals {
+		sum.Add(sum, w.BeaconSharesWei)
+	}
+	return sum
+}
@@ -31,6 +31,9 @@ type ConsolidateBaseCommandArgs struct {
 	CheckFee              bool
 	NoWarn                bool
 	FeeOverestimateFactor float64
+
+	// when set, chunks are sent one block apart, each once the per-request fee is at or under this cap
+	MaxFeePerRequestGwei uint64
 }
 
 type TConsolidateSwitchCommandArgs struct {
@@ -46,6 +49,89 @@ type TConsolidateToTargetCommandArgs struct {
 	SourceValidators []uint64
 }
 
+type TConsolidatePlanCommandArgs struct {
+	EigenpodAddress string
+
+	DisableColor bool
+	UseJSON      bool
+	Node         string
+	BeaconNode   string
+	BatchSize    uint64
+	Verbose      bool
+	Output       string
+}
+
+func ConsolidatePlanCommand(args TConsolidatePlanCommandArgs) error {
+	ctx := context.Background()
+	if args.DisableColor {
+		color.NoColor = true
+	}
+
+	eth, beaconClient, _, err := utils.GetClients(ctx, args.Node, args.BeaconNode, !args.UseJSON)
+	utils.PanicOnError("failed to reach ethereum clients", err)
+
+	headState, err := utils.GetBeaconHeadState(ctx, beaconClient)
+	utils.PanicOnError("failed to fetch beacon chain head state", err)
+
+	plan, err := core.PlanConsolidations(ctx, eth, args.EigenpodAddress, headState, args.BatchSize)
+	utils.PanicOnError("failed to plan consolidations", err)
+
+	if args.UseJSON {
+		PrintAsJSON(plan)
+	} else {
+		printConsolidationPlan(plan, args.Verbose)
+	}
+
+	if len(plan.Requests) == 0 {
+		if !args.UseJSON {
+			color.Yellow("Nothing to consolidate.")
+		}
+		return nil
+	}
+
+	requestsJSON, err := core.MarshalConsolidationRequests(plan.Requests)
+	utils.PanicOnError("failed to serialize consolidation requests", err)
+
+	if args.Output != "" || !args.UseJSON {
+		return utils.WriteOutputToFileOrStdout(requestsJSON, &args.Output)
+	}
+	return nil
+}
+
+func printConsolidationPlan(plan *core.ConsolidationPlan, verbose bool) {
+	numSwitches := 0
+	for _, target := range plan.Targets {
+		switchNote := ""
+		if target.NeedsSwitch {
+			switchNote = " [switch 0x01 -> 0x02]"
+			numSwitches++
+		}
+		color.New(color.Bold).Printf("Target #%d (0x%s)%s\n", target.Index, target.PublicKey[:16], switchNote)
+		for _, source := range target.Sources {
+			fmt.Printf("\t<- #%d (%s ETH)\n", source.Index, utils.GweiToEther(new(big.Float).SetUint64(source.EffectiveBalance)).String())
+		}
+		fmt.Printf("\tresulting effective balance: %s ETH\n", utils.GweiToEther(new(big.Float).SetUint64(target.ResultingBalance)).String())
+	}
+
+	if len(plan.Skipped) > 0 {
+		fmt.Printf("\n%d validator(s) were skipped", len(plan.Skipped))
+		if verbose {
+			fmt.Printf(":\n")
+			for _, skipped := range plan.Skipped {
+				fmt.Printf("\t#%d: %s\n", skipped.Index, skipped.Reason)
+			}
+		} else {
+			fmt.Printf(" (re-run with --verbose to see why)\n")
+		}
+	}
+
+	fmt.Printf("\n%d request(s) (%d switch, %d consolidation) in %d transaction(s)\n", len(plan.Requests), numSwitches, len(plan.Requests)-numSwitches, len(plan.FeePerChunk))
+	for i, chunk := range plan.FeePerChunk {
+		fmt.Printf("\ttxn %d: %s per request, %s total (queue size: %s)\n", i+1, toPrintableUnits(chunk.FeePerRequest), toPrintableUnits(chunk.TotalFee), chunk.CurrentQueueSize)
+	}
+	fmt.Printf("Estimated total predeploy fee: %s\n\n", toPrintableUnits(plan.TotalFee))
+}
+
 func ConsolidateSwitchCommand(args TConsolidateSwitchCommandArgs) error {
 	ctx := context.Background()
 	if args.DisableColor {
@@ -93,11 +179,27 @@ func ConsolidateSwitchCommand(args TConsolidateSwitchCommandArgs) error {
 		}
 	}
 
+	report, err := core.ValidateConsolidationRequests(args.EigenpodAddress, headState, requests)
+	utils.PanicOnError("failed to check consolidation requests against beacon state", err)
+	if err := handlePreflightReport(report, args.NoWarn, args.NoPrompt, args.SimulateTransaction); err != nil {
+		return err
+	}
+
+	txMgr, err := utils.PrepareTxManager(eth, args.Sender, chainId, args.SimulateTransaction, isVerbose)
+	utils.PanicOnError("failed to parse private key", err)
+
+	scheduler := newPredeployScheduler(utils.NewConsolidationScheduler, eth, args.MaxFeePerRequestGwei, isVerbose)
+	if scheduler != nil {
+		args.BatchSize = scheduler.ChunkSize(args.BatchSize)
+	}
+
 	requestChunks := utils.Chunk(requests, args.BatchSize)
 	txns := make([]*types.Transaction, 0)
 
 	for i, chunk := range requestChunks {
-		feeInfo, err := utils.GetConsolidationFeeInfoForRequest(eth, chunk, args.FeeOverestimateFactor)
+		feeInfo, err := chunkFeeInfo(ctx, scheduler, args.SimulateTransaction, len(chunk), func() (*utils.PredeployFeeInfo, error) {
+			return utils.GetConsolidationFeeInfoForRequest(eth, chunk, args.FeeOverestimateFactor)
+		})
 		utils.PanicOnError("error getting consolidation fee info", err)
 
 		isSimulatedStr := ""
@@ -110,14 +212,16 @@ func ConsolidateSwitchCommand(args TConsolidateSwitchCommandArgs) error {
 		// Prompt the user for consent.
 		// We prompt for individual chunks because the predeploy includes exponential fee growth depending
 		// on the size of the queue, and we want to make sure the user is aware of the rising fee.
-		utils.PanicIfNoConsent(utils.SubmitSwitchRequestConsent(
-			len(chunk),
-			feeInfo.CurrentQueueSize,
-			toPrintableUnits(feeInfo.FeePerRequest),
-			toPrintableUnits(feeInfo.TotalFee),
-			toPrintableUnits(feeInfo.OverestimateFee),
-			isSimulatedStr,
-		))
+		if !args.NoPrompt {
+			utils.PanicIfNoConsent(utils.SubmitSwitchRequestConsent(
+				len(chunk),
+				feeInfo.CurrentQueueSize,
+				toPrintableUnits(feeInfo.FeePerRequest),
+				toPrintableUnits(feeInfo.TotalFee),
+				toPrintableUnits(feeInfo.OverestimateFee),
+				isSimulatedStr,
+			))
+		}
 
 		if isVerbose {
 			color.Green("Submitting chunk %d/%d (msg.value: %s)", i+1, len(requestChunks), toPrintableUnits(feeInfo.OverestimateFee))
@@ -125,13 +229,11 @@ func ConsolidateSwitchCommand(args TConsolidateSwitchCommandArgs) error {
 
 		txn, err := core.SubmitConsolidationRequests(
 			ctx,
-			args.Sender,
+			txMgr,
 			args.EigenpodAddress,
-			chainId,
 			eth,
 			chunk,
 			feeInfo.OverestimateFee,
-			args.SimulateTransaction,
 			isVerbose,
 		)
 
@@ -145,11 +247,25 @@ func ConsolidateSwitchCommand(args TConsolidateSwitchCommandArgs) error {
 			utils.PanicOnError("consolidation request submission failed", err)
 		} else {
 			if isVerbose {
-				color.Green("transaction %d/%d succeeded: %s", i+1, len(requestChunks), txn.Hash().Hex())
+				color.Green("transaction %d/%d submitted: %s", i+1, len(requestChunks), txn.Hash().Hex())
 			}
 
 			txns = append(txns, txn)
 		}
+
+		// Wait for each chunk to be included, so the next one lands in a later block
+		if scheduler != nil && !args.SimulateTransaction {
+			receipts, err := txMgr.WaitAll(ctx)
+			utils.PanicOnError("failed waiting for consolidation request transactions", err)
+			for _, receipt := range receipts {
+				scheduler.Included(receipt.BlockNumber.Uint64())
+			}
+		}
+	}
+
+	if !args.SimulateTransaction {
+		_, err = txMgr.WaitAll(ctx)
+		utils.PanicOnError("failed waiting for consolidation request transactions", err)
 	}
 
 	if isVerbose {
@@ -233,11 +349,27 @@ func ConsolidateToTargetCommand(args TConsolidateToTargetCommandArgs) error {
 		}
 	}
 
+	report, err := core.ValidateConsolidationRequests(args.EigenpodAddress, headState, requests)
+	utils.PanicOnError("failed to check consolidation requests against beacon state", err)
+	if err := handlePreflightReport(report, args.NoWarn, args.NoPrompt, args.SimulateTransaction); err != nil {
+		return err
+	}
+
+	txMgr, err := utils.PrepareTxManager(eth, args.Sender, chainId, args.SimulateTransaction, isVerbose)
+	utils.PanicOnError("failed to parse private key", err)
+
+	scheduler := newPredeployScheduler(utils.NewConsolidationScheduler, eth, args.MaxFeePerRequestGwei, isVerbose)
+	if scheduler != nil {
+		args.BatchSize = scheduler.ChunkSize(args.BatchSize)
+	}
+
 	requestChunks := utils.Chunk(requests, args.BatchSize)
 	txns := make([]*types.Transaction, 0)
 
 	for i, chunk := range requestChunks {
-		feeInfo, err := utils.GetConsolidationFeeInfoForRequest(eth, chunk, args.FeeOverestimateFactor)
+		feeInfo, err := chunkFeeInfo(ctx, scheduler, args.SimulateTransaction, len(chunk), func() (*utils.PredeployFeeInfo, error) {
+			return utils.GetConsolidationFeeInfoForRequest(eth, chunk, args.FeeOverestimateFactor)
+		})
 		utils.PanicOnError("error getting consolidation fee info", err)
 
 		isSimulatedStr := ""
@@ -250,16 +382,18 @@ func ConsolidateToTargetCommand(args TConsolidateToTargetCommandArgs) error {
 		// Prompt the user for consent.
 		// We prompt for individual chunks because the predeploy includes exponential fee growth depending
 		// on the size of the queue, and we want to make sure the user is aware of the rising fee.
-		utils.PanicIfNoConsent(utils.SubmitSourceToTargetRequestConsent(
-			len(chunk),
-			feeInfo.CurrentQueueSize,
-			toPrintableUnits(feeInfo.FeePerRequest),
-			toPrintableUnits(feeInfo.TotalFee),
-			toPrintableUnits(feeInfo.OverestimateFee),
-			args.TargetValidator,
-			len(args.SourceValidators),
-			isSimulatedStr,
-		))
+		if !args.NoPrompt {
+			utils.PanicIfNoConsent(utils.SubmitSourceToTargetRequestConsent(
+				len(chunk),
+				feeInfo.CurrentQueueSize,
+				toPrintableUnits(feeInfo.FeePerRequest),
+				toPrintableUnits(feeInfo.TotalFee),
+				toPrintableUnits(feeInfo.OverestimateFee),
+				args.TargetValidator,
+				len(args.SourceValidators),
+				isSimulatedStr,
+			))
+		}
 
 		if isVerbose {
 			color.Green("Submitting chunk %d/%d (msg.value: %s)", i+1, len(requestChunks), toPrintableUnits(feeInfo.OverestimateFee))
@@ -267,13 +401,11 @@ func ConsolidateToTargetCommand(args TConsolidateToTargetCommandArgs) error {
 
 		txn, err := core.SubmitConsolidationRequests(
 			ctx,
-			args.Sender,
+			txMgr,
 			args.EigenpodAddress,
-			chainId,
 			eth,
 			chunk,
 			feeInfo.OverestimateFee,
-			args.SimulateTransaction,
 			isVerbose,
 		)
 
@@ -287,11 +419,25 @@ func ConsolidateToTargetCommand(args TConsolidateToTargetCommandArgs) error {
 			utils.PanicOnError("consolidation request submission failed", err)
 		} else {
 			if isVerbose {
-				color.Green("transaction %d/%d succeeded: %s", i+1, len(requestChunks), txn.Hash().Hex())
+				color.Green("transaction %d/%d submitted: %s", i+1, len(requestChunks), txn.Hash().Hex())
 			}
 
 			txns = append(txns, txn)
 		}
+
+		// Wait for each chunk to be included, so the next one lands in a later block
+		if scheduler != nil && !args.SimulateTransaction {
+			receipts, err := txMgr.WaitAll(ctx)
+			utils.PanicOnError("failed waiting for consolidation request transactions", err)
+			for _, receipt := range receipts {
+				scheduler.Included(receipt.BlockNumber.Uint64())
+			}
+		}
+	}
+
+	if !args.SimulateTransaction {
+		_, err = txMgr.WaitAll(ctx)
+		utils.PanicOnError("failed waiting for consolidation request transactions", err)
 	}
 
 	if isVerbose {
@@ -6,6 +6,7 @@ import (
 	"math"
 	"math/big"
 
+	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
 	"github.com/ethereum/go-ethereum/common"
@@ -27,6 +28,7 @@ type TCredentialCommandArgs struct {
 	BatchSize           uint64
 	NoPrompt            bool
 	Verbose             bool
+	ProofsFromNode      bool
 }
 
 func CredentialsCommand(args TCredentialCommandArgs) error {
@@ -49,7 +51,16 @@ func CredentialsCommand(args TCredentialCommandArgs) error {
 		}
 	}
 
-	validatorProofs, oracleBeaconTimestamp, err := core.GenerateValidatorProof(ctx, args.EigenpodAddress, eth, chainId, beaconClient, specificValidatorIndex, isVerbose)
+	var validatorProofs *eigenpodproofs.VerifyValidatorFieldsCallParams
+	var oracleBeaconTimestamp uint64
+	if args.ProofsFromNode {
+		if specificValidatorIndex == nil {
+			utils.Panic("--proofs-from-node requires --validatorIndex: validators awaiting a credential proof can't be found without the beacon state")
+		}
+		validatorProofs, oracleBeaconTimestamp, err = core.GenerateValidatorProofFromNode(ctx, args.EigenpodAddress, eth, chainId, beaconClient, specificValidatorIndex.Uint64(), isVerbose)
+	} else {
+		validatorProofs, oracleBeaconTimestamp, err = core.GenerateValidatorProof(ctx, args.EigenpodAddress, eth, chainId, beaconClient, specificValidatorIndex, isVerbose)
+	}
 
 	if err != nil || validatorProofs == nil {
 		utils.PanicOnError("Failed to generate validator proof", err)
@@ -57,7 +68,10 @@ func CredentialsCommand(args TCredentialCommandArgs) error {
 	}
 
 	if len(args.Sender) != 0 || args.SimulateTransaction {
-		txns, indices, err := core.SubmitValidatorProof(ctx, args.Sender, args.EigenpodAddress, chainId, eth, args.BatchSize, validatorProofs, oracleBeaconTimestamp, args.NoPrompt, args.SimulateTransaction, isVerbose)
+		txMgr, err := utils.PrepareTxManager(eth, args.Sender, chainId, args.SimulateTransaction, args.Verbose)
+		utils.PanicOnError("failed to parse private key", err)
+
+		txns, indices, err := core.SubmitValidatorProof(ctx, txMgr, args.EigenpodAddress, eth, args.BatchSize, validatorProofs, oracleBeaconTimestamp, args.NoPrompt, isVerbose)
 		utils.PanicOnError(fmt.Sprintf("failed to %s validator proof", func() string {
 			if args.SimulateTransaction {
 				return "simulate"
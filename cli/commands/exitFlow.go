@@ -0,0 +1,210 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	cliutils "github.com/Layr-Labs/eigenpod-proofs-generation/cli/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fatih/color"
+)
+
+type TExitFlowCommandArgs struct {
+	EigenpodAddress string
+	Node            string
+	BeaconNode      string
+	Sender          string
+	Validators      []uint64
+	StateFile       string
+	BatchSize       uint64
+	NoPrompt        bool
+	Verbose         bool
+	DisableColor    bool
+
+	FeeOverestimateFactor float64
+	MaxFeePerRequestGwei  uint64
+}
+
+// ExitFlowCommand moves a set of validators through exiting a pod, one step per run:
+//
+//  1. request a full exit (`request-withdrawal full-exit`)
+//  2. wait for the validators to become withdrawable and be swept
+//  3. `checkpoint`, marking the validators WITHDRAWN in the pod
+//  4. `queue-withdrawal` of the pod's withdrawable ETH
+//  5. `complete-all-withdrawals`, once the withdrawal delay has passed
+//
+// Progress is derived from chain state on every run and recorded in `StateFile`, so the command
+// can be re-run (e.g. from cron) until it reports that the flow is complete.
+func ExitFlowCommand(args TExitFlowCommandArgs) error {
+	ctx := context.Background()
+	if args.DisableColor {
+		color.NoColor = true
+	}
+
+	stateFile := args.StateFile
+	if stateFile == "" {
+		stateFile = fmt.Sprintf("exit-flow-%s.json", common.HexToAddress(args.EigenpodAddress).Hex())
+	}
+
+	flow, err := core.LoadExitFlowState(stateFile, args.EigenpodAddress)
+	utils.PanicOnError("failed to load exit-flow state", err)
+	flow.AddValidators(args.Validators)
+
+	if len(flow.Validators) == 0 {
+		return fmt.Errorf("usage: exit-flow --validators <validatorIndexA>,<validatorIndexB>,... (only needed on the first run)")
+	}
+	if flow.Completed {
+		color.Green("This exit flow is complete. Delete %s to start a new one.", stateFile)
+		return nil
+	}
+
+	eth, beaconClient, chainId, err := utils.GetClients(ctx, args.Node, args.BeaconNode, args.Verbose)
+	utils.PanicOnError("failed to reach ethereum clients", err)
+
+	if flow.StartedAtBlock == 0 {
+		flow.StartedAtBlock, err = eth.BlockNumber(ctx)
+		utils.PanicOnError("failed to load current block number", err)
+	}
+
+	headState, err := utils.GetBeaconHeadState(ctx, beaconClient)
+	utils.PanicOnError("failed to fetch beacon chain head state", err)
+
+	err = core.UpdateExitFlowPhases(ctx, eth, headState, flow)
+	utils.PanicOnError("failed to determine exit-flow progress", err)
+	utils.PanicOnError("failed to save exit-flow state", flow.Save(stateFile))
+
+	printExitFlow(flow)
+
+	active := flow.InPhase(core.ExitPhaseActive)
+	exiting := flow.InPhase(core.ExitPhaseExiting)
+	swept := flow.InPhase(core.ExitPhaseSwept)
+
+	isSimulation := args.Sender == ""
+	if isSimulation {
+		color.Yellow("No --sender given. Pass --sender to perform the next step.")
+	}
+
+	switch {
+	case len(active) != 0:
+		color.Blue("Next step: request full exits for %d validator(s).", len(active))
+		if isSimulation {
+			return nil
+		}
+		return RequestFullExitCommand(TRequestFullExitCommandArgs{
+			WithdrawalBaseCommandArgs: WithdrawalBaseCommandArgs{
+				EigenpodAddress:       args.EigenpodAddress,
+				DisableColor:          args.DisableColor,
+				Node:                  args.Node,
+				BeaconNode:            args.BeaconNode,
+				Sender:                args.Sender,
+				BatchSize:             args.BatchSize,
+				NoPrompt:              args.NoPrompt,
+				Verbose:               args.Verbose,
+				FeeOverestimateFactor: args.FeeOverestimateFactor,
+				MaxFeePerRequestGwei:  args.MaxFeePerRequestGwei,
+			},
+			Validators: active,
+		})
+
+	case len(swept) != 0:
+		color.Blue("Next step: checkpoint the pod, to mark %d swept validator(s) as WITHDRAWN.", len(swept))
+		if isSimulation {
+			return nil
+		}
+		return CheckpointCommand(TCheckpointCommandArgs{
+			EigenpodAddress: args.EigenpodAddress,
+			Node:            args.Node,
+			BeaconNode:      args.BeaconNode,
+			Sender:          args.Sender,
+			DisableColor:    args.DisableColor,
+			NoPrompt:        args.NoPrompt,
+			BatchSize:       cliutils.DEFAULT_BATCH_CHECKPOINT,
+			Verbose:         args.Verbose,
+		})
+
+	case len(exiting) != 0:
+		color.Blue("Next step: wait for %d validator(s) to be withdrawable and swept, then re-run this command.", len(exiting))
+		return nil
+	}
+
+	// Every validator is WITHDRAWN in the pod; withdraw the ETH from EigenLayer. Which step that's
+	// at is read from the chain, so that a crash after queueing doesn't queue again, and a dropped
+	// queueing transaction is retried.
+	pod, err := EigenPod.NewEigenPod(common.HexToAddress(args.EigenpodAddress), eth)
+	utils.PanicOnError("failed to reach eigenpod", err)
+	podOwner, err := pod.PodOwner(nil)
+	utils.PanicOnError("failed to read podOwner", err)
+
+	withdrawal, err := core.FindExitFlowWithdrawal(ctx, eth, DelegationManager(chainId), podOwner, flow)
+	utils.PanicOnError("failed to find queued withdrawal", err)
+
+	curBlock, err := eth.BlockNumber(ctx)
+	utils.PanicOnError("failed to load current block number", err)
+
+	switch {
+	case withdrawal == nil:
+		color.Blue("Next step: queue a withdrawal of the pod's withdrawable ETH.")
+		if isSimulation {
+			return nil
+		}
+		return QueueWithdrawalCommand(TQueueWithdrawallArgs{
+			EthNode:  args.Node,
+			EigenPod: args.EigenpodAddress,
+			Sender:   args.Sender,
+			NoPrompt: args.NoPrompt,
+		})
+	case withdrawal.Completed:
+	case !withdrawal.Queued.IsCompletable(curBlock):
+		color.Blue("Next step: wait until block #%d for the queued withdrawal to become completable, then re-run this command.", withdrawal.Queued.CompletableAtBlock)
+		return nil
+	default:
+		color.Blue("Next step: complete the queued withdrawal.")
+		if isSimulation {
+			return nil
+		}
+		if err := CompleteAllWithdrawalsCommand(TCompleteWithdrawalArgs{
+			EthNode:         args.Node,
+			EigenPod:        args.EigenpodAddress,
+			Sender:          args.Sender,
+			NoPrompt:        args.NoPrompt,
+			WithdrawalRoots: []string{withdrawal.Root.Hex()},
+		}); err != nil {
+			return err
+		}
+
+		withdrawal, err = core.FindExitFlowWithdrawal(ctx, eth, DelegationManager(chainId), podOwner, flow)
+		utils.PanicOnError("failed to find queued withdrawal", err)
+		if withdrawal == nil || !withdrawal.Completed {
+			color.Yellow("The withdrawal's completion isn't onchain yet; re-run this command to confirm it.")
+			return nil
+		}
+	}
+
+	flow.Completed = true
+	color.Green("Exit flow complete: withdrawal %s was completed.", withdrawal.Root.Hex())
+	return flow.Save(stateFile)
+}
+
+func printExitFlow(flow *core.ExitFlowState) {
+	color.Blue("Exit flow for pod %s:", flow.EigenpodAddress)
+	for _, v := range flow.Validators {
+		switch v.Phase {
+		case core.ExitPhaseActive:
+			color.Yellow("\t- #%d: %s (exit not requested)", v.Index, v.Phase)
+		case core.ExitPhaseExiting:
+			if v.ETA != nil {
+				color.Yellow("\t- #%d: %s (withdrawable ~%s, swept ~%s)", v.Index, v.Phase, formatETA(v.ETA.WithdrawableTime), formatETA(v.ETA.SweepTime))
+			} else {
+				color.Yellow("\t- #%d: %s", v.Index, v.Phase)
+			}
+		case core.ExitPhaseSwept:
+			color.Yellow("\t- #%d: %s (needs a checkpoint)", v.Index, v.Phase)
+		default:
+			color.Green("\t- #%d: %s", v.Index, v.Phase)
+		}
+	}
+	fmt.Println()
+}
@@ -14,6 +14,27 @@ type TFindStalePodsCommandArgs struct {
 	BeaconNode string
 	Verbose    bool
 	Tolerance  float64
+	// optional path to a PodAddressCache
+	PodCache string
+	UseJSON  bool
+}
+
+type StalePodOutput struct {
+	Pod                   string
+	DeviationPercent      float64
+	SlashedValidators     []uint64
+	CorrectableValidators []uint64
+	// empty if none of the slashed validators can be used to correct the pod
+	CorrectStalePodCommand string `json:",omitempty"`
+}
+
+// correctStalePodCommand returns a `correct-stale-pod` invocation for `pod`, reading the nodes and
+// sender from the environment.
+func correctStalePodCommand(pod core.StaleEigenpod) string {
+	if len(pod.CorrectableValidators) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("./cli correct-stale-pod --execNode $NODE_ETH --beaconNode $NODE_BEACON --sender $SENDER_PK --podAddress %s --validatorIndex %d", pod.Pod, pod.CorrectableValidators[0])
 }
 
 func FindStalePodsCommand(args TFindStalePodsCommandArgs) error {
@@ -21,18 +42,49 @@ func FindStalePodsCommand(args TFindStalePodsCommandArgs) error {
 	eth, beacon, chainId, err := utils.GetClients(ctx, args.EthNode, args.BeaconNode /* verbose */, args.Verbose)
 	utils.PanicOnError("failed to dial clients", err)
 
-	results, err := core.FindStaleEigenpods(ctx, eth, args.EthNode, beacon, chainId, args.Verbose, args.Tolerance)
+	options := core.FindStalePodsOptions{
+		TolerancePercent: args.Tolerance,
+		Verbose:          args.Verbose,
+	}
+	if args.PodCache != "" {
+		options.Cache, err = core.LoadPodAddressCache(args.PodCache, chainId.Uint64())
+		utils.PanicOnError("failed to load pod cache", err)
+		if args.Verbose {
+			color.Cyan("loaded %d known addresses (%d eigenpods) from %s", len(options.Cache.Addresses), options.Cache.Pods(), args.PodCache)
+		}
+	}
+
+	results, err := core.FindStaleEigenpods(ctx, eth, beacon, chainId, options)
 	utils.PanicOnError("failed to find stale eigenpods", err)
 
-	if !args.Verbose {
-		PrintAsJSON(results)
+	if options.Cache != nil {
+		utils.PanicOnError("failed to save pod cache", options.Cache.Save())
+	}
+
+	if args.UseJSON {
+		out := make([]StalePodOutput, len(results))
+		for i, pod := range results {
+			out[i] = StalePodOutput{
+				Pod:                    pod.Pod,
+				DeviationPercent:       pod.DeviationPercent,
+				SlashedValidators:      pod.SlashedValidators,
+				CorrectableValidators:  pod.CorrectableValidators,
+				CorrectStalePodCommand: correctStalePodCommand(pod),
+			}
+		}
+		PrintAsJSON(out)
 		return nil
 	}
 
-	if args.Verbose {
-		for pod, res := range results {
-			color.Red("pod %s\n", pod)
-			for _, validator := range res {
+	if len(results) == 0 {
+		color.Green("No stale pods (tolerance: %.2f%%).", args.Tolerance)
+		return nil
+	}
+
+	for _, pod := range results {
+		color.Red("pod %s (balance deviation %.4f%%)\n", pod.Pod, pod.DeviationPercent)
+		if args.Verbose {
+			for _, validator := range pod.Validators {
 				fmt.Printf("\t[#%d] (%s) - %d\n", validator.Index, func() string {
 					if validator.Validator.Slashed {
 						return "slashed"
@@ -41,6 +93,14 @@ func FindStalePodsCommand(args TFindStalePodsCommandArgs) error {
 					}
 				}(), validator.Validator.EffectiveBalance)
 			}
+		} else {
+			fmt.Printf("\tslashed validators: %v\n", pod.SlashedValidators)
+		}
+
+		if command := correctStalePodCommand(pod); command != "" {
+			fmt.Printf("\t%s\n", command)
+		} else {
+			color.Yellow("\tnone of the slashed validators are active in the pod; it can't be corrected with `correct-stale-pod`.")
 		}
 	}
 	return nil
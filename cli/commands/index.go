@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/fatih/color"
+
+	// registers the "sqlite" database/sql driver
+	_ "modernc.org/sqlite"
+)
+
+const DEFAULT_INDEX_DB = "./eigenpod-index.db"
+
+const (
+	IndexQueryVerifications = "verifications"
+	IndexQueryCheckpoints   = "checkpoints"
+	IndexQuerySubmitters    = "submitters"
+)
+
+type TIndexCommandArgs struct {
+	Database     string
+	Pods         []string
+	Node         string
+	FromBlock    uint64
+	DisableColor bool
+	Verbose      bool
+}
+
+func IndexCommand(args TIndexCommandArgs) error {
+	ctx := context.Background()
+	if args.DisableColor {
+		color.NoColor = true
+	}
+
+	eth, _, err := utils.GetEthClient(ctx, args.Node)
+	utils.PanicOnError("failed to reach eth node", err)
+
+	index, err := core.OpenEventIndex(args.Database)
+	utils.PanicOnError("failed to open index", err)
+	defer index.Close()
+
+	pods := args.Pods
+	if len(pods) == 0 {
+		pods, err = index.Pods()
+		utils.PanicOnError("failed to read indexed pods", err)
+		if len(pods) == 0 {
+			return fmt.Errorf("%s doesn't index any pods yet; pass --pods", args.Database)
+		}
+	}
+
+	forkBlock, err := index.Rewind(ctx, eth)
+	utils.PanicOnError("failed to check the index for reorgs", err)
+	if forkBlock != 0 {
+		color.Yellow("Detected a reorg: re-indexing from block %d.", forkBlock)
+	}
+
+	head, err := eth.BlockNumber(ctx)
+	utils.PanicOnError("failed to fetch latest block number", err)
+
+	for _, pod := range pods {
+		progress, err := index.Index(ctx, eth, pod, args.FromBlock, head, args.Verbose)
+		utils.PanicOnError(fmt.Sprintf("failed to index %s", pod), err)
+
+		if progress.FromBlock > progress.ToBlock {
+			fmt.Printf("%s: already up to date (block %d)\n", progress.Pod, progress.ToBlock)
+			continue
+		}
+		color.Green("%s: indexed blocks %d-%d, %d new event(s)", progress.Pod, progress.FromBlock, progress.ToBlock, progress.EventCount)
+	}
+	return nil
+}
+
+type TIndexQueryCommandArgs struct {
+	Database     string
+	Pods         []string
+	Query        string
+	UseJSON      bool
+	DisableColor bool
+}
+
+func IndexQueryCommand(args TIndexQueryCommandArgs) error {
+	ctx := context.Background()
+	if args.DisableColor {
+		color.NoColor = true
+	}
+
+	index, err := core.OpenEventIndex(args.Database)
+	utils.PanicOnError("failed to open index", err)
+	defer index.Close()
+
+	switch args.Query {
+	case IndexQueryVerifications:
+		verifications, err := index.ValidatorVerifications(ctx, args.Pods)
+		utils.PanicOnError("failed to query index", err)
+		if args.UseJSON {
+			PrintAsJSON(verifications)
+			return nil
+		}
+
+		for _, v := range verifications {
+			validator := v.PubkeyHash
+			if v.ValidatorIndex != nil {
+				validator = fmt.Sprintf("#%d", *v.ValidatorIndex)
+			}
+			fmt.Printf("%s\tvalidator %s verified at block %d (%s) by %s\n", v.Pod, validator, v.BlockNumber, formatBlockTime(v.Timestamp), v.Submitter)
+		}
+	case IndexQueryCheckpoints:
+		checkpoints, err := index.Checkpoints(ctx, args.Pods)
+		utils.PanicOnError("failed to query index", err)
+		if args.UseJSON {
+			PrintAsJSON(checkpoints)
+			return nil
+		}
+
+		for _, c := range checkpoints {
+			if c.FinalizedBlock == 0 {
+				color.Yellow("%s\tcheckpoint %d (%s validators) started at block %d by %s, not finalized", c.Pod, c.CheckpointTimestamp, c.ValidatorCount, c.StartedBlock, c.StartedBy)
+				continue
+			}
+
+			delta := "?"
+			if deltaWei, ok := new(big.Int).SetString(c.TotalShareDeltaWei, 10); ok {
+				delta = utils.IweiToEther(deltaWei).String()
+			}
+			fmt.Printf("%s\tcheckpoint %d (%s validators) finalized at block %d (%s): %s ETH of shares, proofs by %v\n", c.Pod, c.CheckpointTimestamp, c.ValidatorCount, c.FinalizedBlock, formatBlockTime(c.FinalizedAt), delta, c.ProofSubmitters)
+		}
+	case IndexQuerySubmitters:
+		submitters, err := index.ProofSubmitters(ctx, args.Pods)
+		utils.PanicOnError("failed to query index", err)
+		if args.UseJSON {
+			PrintAsJSON(submitters)
+			return nil
+		}
+
+		for _, s := range submitters {
+			fmt.Printf("%s\t%s: %d credential proof tx(s), %d checkpoint proof tx(s) (blocks %d-%d)\n", s.Pod, s.Submitter, s.CredentialProofTxs, s.CheckpointProofTxs, s.FirstBlock, s.LastBlock)
+		}
+	default:
+		return fmt.Errorf("unknown query %q (expected one of %s, %s, %s)", args.Query, IndexQueryVerifications, IndexQueryCheckpoints, IndexQuerySubmitters)
+	}
+	return nil
+}
+
+func formatBlockTime(timestamp uint64) string {
+	return time.Unix(int64(timestamp), 0).UTC().Format(time.RFC3339)
+}
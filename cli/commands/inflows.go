@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/fatih/color"
+)
+
+type TInflowsCommandArgs struct {
+	EigenpodAddress string
+	Node            string
+	BeaconNode      string
+	FromTimestamp   uint64
+	ToTimestamp     uint64
+	UseJSON         bool
+	DisableColor    bool
+	Verbose         bool
+}
+
+func InflowsCommand(args TInflowsCommandArgs) error {
+	ctx := context.Background()
+	if args.DisableColor {
+		color.NoColor = true
+	}
+
+	eth, beaconClient, _, err := utils.GetClients(ctx, args.Node, args.BeaconNode, !args.UseJSON)
+	utils.PanicOnError("failed to reach ethereum clients", err)
+
+	inflows, err := core.GetPodInflows(ctx, eth, beaconClient, args.EigenpodAddress, args.FromTimestamp, args.ToTimestamp, args.Verbose && !args.UseJSON)
+	utils.PanicOnError("failed to attribute pod inflows", err)
+
+	if args.UseJSON {
+		PrintAsJSON(inflows)
+		return nil
+	}
+
+	color.Blue("ETH received by %s between %s (block %d) and %s (block %d):", args.EigenpodAddress,
+		formatBlockTime(inflows.FromTimestamp), inflows.FromBlock, formatBlockTime(inflows.ToTimestamp), inflows.ToBlock)
+	fmt.Printf("\tpartial withdrawals (sweeps): %s ETH\n", gweiToEtherString(inflows.PartialWithdrawalsGwei))
+	fmt.Printf("\tfull exits:                   %s ETH\n", gweiToEtherString(inflows.FullExitsGwei))
+	fmt.Printf("\tnon-beacon transfers:         %s ETH\n", utils.IweiToEther(inflows.NonBeaconWei).String())
+	fmt.Printf("\tcompleted withdrawals (out):  %s ETH\n", utils.IweiToEther(inflows.OutflowsWei).String())
+	color.Green("\tpod balance change:           %s ETH", utils.IweiToEther(inflows.PodBalanceDeltaWei).String())
+
+	if args.Verbose {
+		for _, withdrawal := range inflows.Withdrawals {
+			kind := "partial"
+			if withdrawal.FullExit {
+				kind = "full exit"
+			}
+			fmt.Printf("\t\tslot %d (block %d): validator %d, %s ETH (%s)\n", withdrawal.Slot, withdrawal.BlockNumber, withdrawal.ValidatorIndex, gweiToEtherString(withdrawal.AmountGwei), kind)
+		}
+	}
+	return nil
+}
+
+func gweiToEtherString(gwei uint64) string {
+	return utils.GweiToEther(new(big.Float).SetUint64(gwei)).String()
+}
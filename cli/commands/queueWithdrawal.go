@@ -12,6 +12,7 @@ import (
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/fatih/color"
 )
@@ -21,6 +22,7 @@ type TQueueWithdrawallArgs struct {
 	EigenPod    string
 	Sender      string
 	EstimateGas bool
+	NoPrompt    bool
 	AmountWei   uint64
 }
 
@@ -35,7 +37,7 @@ func QueueWithdrawalCommand(args TQueueWithdrawallArgs) error {
 	chainId, err := eth.ChainID(ctx)
 	utils.PanicOnError("failed to load chainId", err)
 
-	acc, err := utils.PrepareAccount(&args.Sender, chainId, args.EstimateGas)
+	txMgr, err := utils.PrepareTxManager(eth, args.Sender, chainId, args.EstimateGas, false /* verbose */)
 	utils.PanicOnError("failed to parse private key", err)
 
 	dm, err := IDelegationManager.NewIDelegationManager(DelegationManager(chainId), eth)
@@ -93,22 +95,26 @@ func QueueWithdrawalCommand(args TQueueWithdrawallArgs) error {
 	color.Yellow("NOTE: If you were or become slashed on EigenLayer during the withdrawal period, the total amount received will be less any slashed amount.\n")
 
 	if !isSimulation {
-		utils.PanicIfNoConsent(fmt.Sprintf("Would you like to queue a withdrawal %sETH from the Native ETH strategy? This will be withdrawable after approximately block #%d (current block: %d)\n", requestedWithdrawalSizeEth.String(), curBlock+uint64(minWithdrawalDelay), curBlock))
+		if !args.NoPrompt {
+			utils.PanicIfNoConsent(fmt.Sprintf("Would you like to queue a withdrawal %sETH from the Native ETH strategy? This will be withdrawable after approximately block #%d (current block: %d)\n", requestedWithdrawalSizeEth.String(), curBlock+uint64(minWithdrawalDelay), curBlock))
+		}
 	} else {
 		fmt.Printf("THIS IS A SIMULATION. No transaction will be recorded onchain.\n")
 	}
-	txn, err := dm.QueueWithdrawals(acc.TransactionOptions, []IDelegationManager.IDelegationManagerTypesQueuedWithdrawalParams{
-		{
-			Strategies:           []common.Address{core.BeaconStrategy()},
-			DepositShares:        []*big.Int{depositShares},
-			DeprecatedWithdrawer: podOwner,
-		},
+	txn, err := txMgr.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return dm.QueueWithdrawals(opts, []IDelegationManager.IDelegationManagerTypesQueuedWithdrawalParams{
+			{
+				Strategies:           []common.Address{core.BeaconStrategy()},
+				DepositShares:        []*big.Int{depositShares},
+				DeprecatedWithdrawer: podOwner,
+			},
+		})
 	})
 	utils.PanicOnError("failed to queue withdrawal", err)
 	if !isSimulation {
-		txnReceipt, err := bind.WaitMined(ctx, eth, txn)
+		receipts, err := txMgr.WaitAll(ctx)
 		utils.PanicOnError("failed to wait for txn", err)
-		color.Green("%s\n", txnReceipt.TxHash.Hex())
+		color.Green("%s\n", receipts[0].TxHash.Hex())
 	} else {
 		PrintAsJSON(Transaction{
 			Type:     "queue-withdrawal",
@@ -3,6 +3,7 @@ package commands
 import (
 	"context"
 	"fmt"
+	"math/big"
 
 	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
@@ -29,6 +30,9 @@ type WithdrawalBaseCommandArgs struct {
 	CheckFee              bool
 	NoWarn                bool
 	FeeOverestimateFactor float64
+
+	// when set, chunks are sent one block apart, each once the per-request fee is at or under this cap
+	MaxFeePerRequestGwei uint64
 }
 
 type TRequestFullExitCommandArgs struct {
@@ -85,11 +89,30 @@ func RequestFullExitCommand(args TRequestFullExitCommandArgs) error {
 		}
 	}
 
+	report, err := core.ValidateWithdrawalRequests(args.EigenpodAddress, headState, requests)
+	utils.PanicOnError("failed to check withdrawal requests against beacon state", err)
+	if err := handlePreflightReport(&report.PreflightReport, args.NoWarn, args.NoPrompt, args.SimulateTransaction); err != nil {
+		return err
+	}
+	if enableLogs {
+		printWithdrawableAmounts(report.Withdrawals)
+	}
+
+	txMgr, err := utils.PrepareTxManager(eth, args.Sender, chainId, args.SimulateTransaction, isVerbose)
+	utils.PanicOnError("failed to parse private key", err)
+
+	scheduler := newPredeployScheduler(utils.NewWithdrawalScheduler, eth, args.MaxFeePerRequestGwei, isVerbose)
+	if scheduler != nil {
+		args.BatchSize = scheduler.ChunkSize(args.BatchSize)
+	}
+
 	requestChunks := utils.Chunk(requests, args.BatchSize)
 	txns := make([]*types.Transaction, 0)
 
 	for i, chunk := range requestChunks {
-		feeInfo, err := utils.GetWithdrawalFeeInfoForRequest(eth, chunk, args.FeeOverestimateFactor)
+		feeInfo, err := chunkFeeInfo(ctx, scheduler, args.SimulateTransaction, len(chunk), func() (*utils.PredeployFeeInfo, error) {
+			return utils.GetWithdrawalFeeInfoForRequest(eth, chunk, args.FeeOverestimateFactor)
+		})
 		utils.PanicOnError("error getting withdrawal fee info", err)
 
 		isSimulatedStr := ""
@@ -102,14 +125,16 @@ func RequestFullExitCommand(args TRequestFullExitCommandArgs) error {
 		// Prompt the user for consent.
 		// We prompt for individual chunks because the predeploy includes exponential fee growth depending
 		// on the size of the queue, and we want to make sure the user is aware of the rising fee.
-		utils.PanicIfNoConsent(utils.SubmitFullExitRequestConsent(
-			len(chunk),
-			feeInfo.CurrentQueueSize,
-			toPrintableUnits(feeInfo.FeePerRequest),
-			toPrintableUnits(feeInfo.TotalFee),
-			toPrintableUnits(feeInfo.OverestimateFee),
-			isSimulatedStr,
-		))
+		if !args.NoPrompt {
+			utils.PanicIfNoConsent(utils.SubmitFullExitRequestConsent(
+				len(chunk),
+				feeInfo.CurrentQueueSize,
+				toPrintableUnits(feeInfo.FeePerRequest),
+				toPrintableUnits(feeInfo.TotalFee),
+				toPrintableUnits(feeInfo.OverestimateFee),
+				isSimulatedStr,
+			))
+		}
 
 		if isVerbose {
 			color.Green("Submitting chunk %d/%d (msg.value: %s)", i+1, len(requestChunks), toPrintableUnits(feeInfo.OverestimateFee))
@@ -117,13 +142,11 @@ func RequestFullExitCommand(args TRequestFullExitCommandArgs) error {
 
 		txn, err := core.SubmitWithdrawalRequests(
 			ctx,
-			args.Sender,
+			txMgr,
 			args.EigenpodAddress,
-			chainId,
 			eth,
 			chunk,
 			feeInfo.OverestimateFee,
-			args.SimulateTransaction,
 			isVerbose,
 		)
 
@@ -137,11 +160,25 @@ func RequestFullExitCommand(args TRequestFullExitCommandArgs) error {
 			utils.PanicOnError("withdrawal request submission failed", err)
 		} else {
 			if isVerbose {
-				color.Green("transaction %d/%d succeeded: %s", i+1, len(requestChunks), txn.Hash().Hex())
+				color.Green("transaction %d/%d submitted: %s", i+1, len(requestChunks), txn.Hash().Hex())
 			}
 
 			txns = append(txns, txn)
 		}
+
+		// Wait for each chunk to be included, so the next one lands in a later block
+		if scheduler != nil && !args.SimulateTransaction {
+			receipts, err := txMgr.WaitAll(ctx)
+			utils.PanicOnError("failed waiting for withdrawal request transactions", err)
+			for _, receipt := range receipts {
+				scheduler.Included(receipt.BlockNumber.Uint64())
+			}
+		}
+	}
+
+	if !args.SimulateTransaction {
+		_, err = txMgr.WaitAll(ctx)
+		utils.PanicOnError("failed waiting for withdrawal request transactions", err)
 	}
 
 	if isVerbose {
@@ -207,11 +244,30 @@ func RequestPartialWithdrawalCommand(args TRequestPartialWithdrawalCommandArgs)
 		}
 	}
 
+	report, err := core.ValidateWithdrawalRequests(args.EigenpodAddress, headState, requests)
+	utils.PanicOnError("failed to check withdrawal requests against beacon state", err)
+	if err := handlePreflightReport(&report.PreflightReport, args.NoWarn, args.NoPrompt, args.SimulateTransaction); err != nil {
+		return err
+	}
+	if enableLogs {
+		printWithdrawableAmounts(report.Withdrawals)
+	}
+
+	txMgr, err := utils.PrepareTxManager(eth, args.Sender, chainId, args.SimulateTransaction, isVerbose)
+	utils.PanicOnError("failed to parse private key", err)
+
+	scheduler := newPredeployScheduler(utils.NewWithdrawalScheduler, eth, args.MaxFeePerRequestGwei, isVerbose)
+	if scheduler != nil {
+		args.BatchSize = scheduler.ChunkSize(args.BatchSize)
+	}
+
 	requestChunks := utils.Chunk(requests, args.BatchSize)
 	txns := make([]*types.Transaction, 0)
 
 	for i, chunk := range requestChunks {
-		feeInfo, err := utils.GetWithdrawalFeeInfoForRequest(eth, chunk, args.FeeOverestimateFactor)
+		feeInfo, err := chunkFeeInfo(ctx, scheduler, args.SimulateTransaction, len(chunk), func() (*utils.PredeployFeeInfo, error) {
+			return utils.GetWithdrawalFeeInfoForRequest(eth, chunk, args.FeeOverestimateFactor)
+		})
 		utils.PanicOnError("error getting withdrawal fee info", err)
 
 		isSimulatedStr := ""
@@ -224,14 +280,16 @@ func RequestPartialWithdrawalCommand(args TRequestPartialWithdrawalCommandArgs)
 		// Prompt the user for consent.
 		// We prompt for individual chunks because the predeploy includes exponential fee growth depending
 		// on the size of the queue, and we want to make sure the user is aware of the rising fee.
-		utils.PanicIfNoConsent(utils.SubmitPartialExitRequestConsent(
-			len(chunk),
-			feeInfo.CurrentQueueSize,
-			toPrintableUnits(feeInfo.FeePerRequest),
-			toPrintableUnits(feeInfo.TotalFee),
-			toPrintableUnits(feeInfo.OverestimateFee),
-			isSimulatedStr,
-		))
+		if !args.NoPrompt {
+			utils.PanicIfNoConsent(utils.SubmitPartialExitRequestConsent(
+				len(chunk),
+				feeInfo.CurrentQueueSize,
+				toPrintableUnits(feeInfo.FeePerRequest),
+				toPrintableUnits(feeInfo.TotalFee),
+				toPrintableUnits(feeInfo.OverestimateFee),
+				isSimulatedStr,
+			))
+		}
 
 		if isVerbose {
 			color.Green("Submitting chunk %d/%d (msg.value: %s)", i+1, len(requestChunks), toPrintableUnits(feeInfo.OverestimateFee))
@@ -239,13 +297,11 @@ func RequestPartialWithdrawalCommand(args TRequestPartialWithdrawalCommandArgs)
 
 		txn, err := core.SubmitWithdrawalRequests(
 			ctx,
-			args.Sender,
+			txMgr,
 			args.EigenpodAddress,
-			chainId,
 			eth,
 			chunk,
 			feeInfo.OverestimateFee,
-			args.SimulateTransaction,
 			isVerbose,
 		)
 
@@ -259,11 +315,25 @@ func RequestPartialWithdrawalCommand(args TRequestPartialWithdrawalCommandArgs)
 			utils.PanicOnError("withdrawal request submission failed", err)
 		} else {
 			if isVerbose {
-				color.Green("transaction %d/%d succeeded: %s", i+1, len(requestChunks), txn.Hash().Hex())
+				color.Green("transaction %d/%d submitted: %s", i+1, len(requestChunks), txn.Hash().Hex())
 			}
 
 			txns = append(txns, txn)
 		}
+
+		// Wait for each chunk to be included, so the next one lands in a later block
+		if scheduler != nil && !args.SimulateTransaction {
+			receipts, err := txMgr.WaitAll(ctx)
+			utils.PanicOnError("failed waiting for withdrawal request transactions", err)
+			for _, receipt := range receipts {
+				scheduler.Included(receipt.BlockNumber.Uint64())
+			}
+		}
+	}
+
+	if !args.SimulateTransaction {
+		_, err = txMgr.WaitAll(ctx)
+		utils.PanicOnError("failed waiting for withdrawal request transactions", err)
 	}
 
 	if isVerbose {
@@ -282,6 +352,18 @@ func RequestPartialWithdrawalCommand(args TRequestPartialWithdrawalCommandArgs)
 	return nil
 }
 
+func printWithdrawableAmounts(withdrawals []core.WithdrawableAmount) {
+	color.Cyan("Once processed, these requests will withdraw:")
+	for _, w := range withdrawals {
+		if w.Requested == 0 {
+			fmt.Printf("\t- validator #%d: full exit (%s)\n", w.Validator, toPrintableUnits(utils.IGweiToWei(new(big.Int).SetUint64(w.Withdrawable))))
+		} else {
+			fmt.Printf("\t- validator #%d: %s\n", w.Validator, toPrintableUnits(utils.IGweiToWei(new(big.Int).SetUint64(w.Withdrawable))))
+		}
+	}
+	fmt.Println()
+}
+
 func printWithdrawalTxnsAsJSON(txns []*types.Transaction) {
 	printableTxns := lo.Map(txns, func(txn *types.Transaction, _ int) PredeployRequestTransaction {
 		gas := txn.Gas()
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/fatih/color"
+)
+
+// How far back to look for requests when --from-block isn't given (~14 days)
+const DEFAULT_REQUESTS_LOOKBACK_BLOCKS = uint64(100_800)
+
+type TRequestsStatusCommandArgs struct {
+	EigenpodAddress string
+	DisableColor    bool
+	UseJSON         bool
+	Node            string
+	BeaconNode      string
+	FromBlock       uint64
+	Verbose         bool
+}
+
+func RequestsStatusCommand(args TRequestsStatusCommandArgs) error {
+	ctx := context.Background()
+	if args.DisableColor {
+		color.NoColor = true
+	}
+
+	eth, beaconClient, _, err := utils.GetClients(ctx, args.Node, args.BeaconNode, !args.UseJSON)
+	utils.PanicOnError("failed to reach ethereum clients", err)
+
+	headState, err := utils.GetBeaconHeadState(ctx, beaconClient)
+	utils.PanicOnError("failed to fetch beacon chain head state", err)
+
+	head, err := eth.BlockNumber(ctx)
+	utils.PanicOnError("failed to fetch latest block number", err)
+
+	fromBlock := args.FromBlock
+	if fromBlock == 0 && head > DEFAULT_REQUESTS_LOOKBACK_BLOCKS {
+		fromBlock = head - DEFAULT_REQUESTS_LOOKBACK_BLOCKS
+	}
+
+	requests, err := core.GetRequestStatuses(ctx, eth, beaconClient, args.EigenpodAddress, headState, fromBlock, head)
+	utils.PanicOnError("failed to get request statuses", err)
+
+	if args.UseJSON {
+		PrintAsJSON(requests)
+		return nil
+	}
+
+	if len(requests) == 0 {
+		fmt.Printf("No withdrawal or consolidation requests found since block %d.\n", fromBlock)
+		return nil
+	}
+
+	color.Blue("Requests sent by %s since block %d:", args.EigenpodAddress, fromBlock)
+	for _, request := range requests {
+		validators := formatValidatorIndex(request.Validator)
+		if request.Kind == core.RequestKindConsolidation {
+			validators = fmt.Sprintf("%s -> %s", validators, formatValidatorIndex(request.Target))
+		}
+		if request.Kind == core.RequestKindPartialWithdrawal {
+			validators = fmt.Sprintf("%s (%d gwei)", validators, request.AmountGwei)
+		}
+
+		line := fmt.Sprintf("\t[%s] %s %s: %s", request.Status, request.Kind, validators, request.Detail)
+		switch request.Status {
+		case core.RequestStatusProcessed:
+			color.Green("%s", line)
+		case core.RequestStatusDropped:
+			color.Red("%s", line)
+		default:
+			color.Yellow("%s", line)
+		}
+		if args.Verbose {
+			fmt.Printf("\t\ttx %s (block %d)\n", request.TxHash, request.BlockNumber)
+		}
+	}
+	return nil
+}
+
+func formatValidatorIndex(index *uint64) string {
+	if index == nil {
+		return "#?"
+	}
+	return fmt.Sprintf("#%d", *index)
+}
@@ -2,28 +2,60 @@ package commands
 
 import (
 	"context"
+	"encoding/csv"
+	"fmt"
 	"math/big"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
 	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/IDelegationManager"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
 	"github.com/ethereum/go-ethereum/common"
+	lo "github.com/samber/lo"
 )
 
 type TShowWithdrawalArgs struct {
 	EthNode    string
 	EigenPod   string
 	Strategies common.Address
+
+	// "json" (default) or "csv"
+	Format string
+}
+
+type TWithdrawalInfo struct {
+	Root                string
+	QueuedTxHash        string
+	Staker              string
+	Strategy            common.Address
+	Strategies          []common.Address
+	SharesWei           []*big.Int
+	StartBlock          uint64
+	CurrentBlock        uint64
+	AvailableAfterBlock *big.Int
+	BlocksRemaining     uint64
+	EstimatedReadyAt    time.Time
+	Ready               bool
+
+	// native ETH in the withdrawal
+	TotalAmountETH *big.Float
 }
 
 func ShowWithdrawalsCommand(args TShowWithdrawalArgs) error {
 	ctx := context.Background()
+	if args.Format != "" && args.Format != "json" && args.Format != "csv" {
+		return fmt.Errorf("unsupported --format %s (expected json or csv)", args.Format)
+	}
+
 	eth, chainId, err := utils.GetEthClient(ctx, args.EthNode)
 	utils.PanicOnError("failed to reach eth and beacon node", err)
 
 	curBlock, err := eth.BlockByNumber(ctx, nil) /* head */
 	utils.PanicOnError("failed to load curBlock", err)
+	curBlockTime := time.Unix(int64(curBlock.Time()), 0)
 
 	dm, err := IDelegationManager.NewIDelegationManager(DelegationManager(chainId), eth)
 	utils.PanicOnError("failed to reach delegation manager", err)
@@ -34,43 +66,72 @@ func ShowWithdrawalsCommand(args TShowWithdrawalArgs) error {
 	podOwner, err := pod.PodOwner(nil)
 	utils.PanicOnError("failed to load podOwner", err)
 
-	allWithdrawals, err := dm.GetQueuedWithdrawals(nil, podOwner)
+	allWithdrawals, err := core.GetQueuedWithdrawals(dm, podOwner)
 	utils.PanicOnError("failed to get queued withdrawals", err)
 
-	type TWithdrawalInfo struct {
-		Staker              string
-		Strategy            common.Address
-		CurrentBlock        uint64
-		AvailableAfterBlock *big.Int
-		Ready               bool
-		TotalAmountETH      *big.Float
-	}
+	queuedTxs, err := core.FindWithdrawalQueuedTxs(ctx, eth, DelegationManager(chainId), allWithdrawals)
+	utils.PanicOnError("failed to find withdrawal queued events", err)
 
-	minDelay, err := dm.MinWithdrawalDelayBlocks(nil)
-	utils.PanicOnError("failed to get minWithdrawalDelay", err)
+	withdrawalInfo := lo.Map(allWithdrawals, func(w core.QueuedWithdrawal, _ int) TWithdrawalInfo {
+		// available after this block; completable in the next one
+		targetBlock := w.CompletableAtBlock - 1
 
-	withdrawalInfo := []TWithdrawalInfo{}
-
-	for i, shares := range allWithdrawals.Shares {
-		withdrawal := allWithdrawals.Withdrawals[i]
-
-		// this cli is only for withdrawals of beaconstrategy for a single strategy
-		if withdrawal.Strategies[0].Cmp(core.BeaconStrategy()) != 0 || len(withdrawal.Strategies) != 1 {
-			continue
+		info := TWithdrawalInfo{
+			Root:                w.Root.Hex(),
+			Staker:              w.Withdrawal.Staker.Hex(),
+			Strategy:            w.Withdrawal.Strategies[0],
+			Strategies:          w.Withdrawal.Strategies,
+			SharesWei:           w.Shares,
+			StartBlock:          uint64(w.Withdrawal.StartBlock),
+			CurrentBlock:        curBlock.NumberU64(),
+			AvailableAfterBlock: new(big.Int).SetUint64(targetBlock),
+			EstimatedReadyAt:    core.EstimateBlockTime(w.CompletableAtBlock, curBlock.NumberU64(), curBlockTime),
+			Ready:               w.IsCompletable(curBlock.NumberU64()),
+			TotalAmountETH:      utils.GweiToEther(utils.WeiToGwei(w.BeaconSharesWei)),
 		}
+		if !info.Ready {
+			info.BlocksRemaining = w.CompletableAtBlock - curBlock.NumberU64()
+		}
+		if tx, ok := queuedTxs[w.Root]; ok {
+			info.QueuedTxHash = tx.Hex()
+		}
+		return info
+	})
 
-		targetBlock := new(big.Int).SetUint64(uint64(allWithdrawals.Withdrawals[i].StartBlock + minDelay))
-
-		withdrawalInfo = append(withdrawalInfo, TWithdrawalInfo{
-			TotalAmountETH:      utils.GweiToEther(utils.WeiToGwei(shares[0])),
-			Strategy:            allWithdrawals.Withdrawals[i].Strategies[0],
-			Staker:              allWithdrawals.Withdrawals[i].Staker.Hex(),
-			CurrentBlock:        curBlock.NumberU64(),
-			AvailableAfterBlock: targetBlock,
-			Ready:               targetBlock.Uint64() < curBlock.NumberU64(),
-		})
+	if args.Format == "csv" {
+		return printWithdrawalsAsCSV(withdrawalInfo)
 	}
-
 	PrintAsJSON(withdrawalInfo)
 	return nil
 }
+
+func printWithdrawalsAsCSV(withdrawals []TWithdrawalInfo) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write([]string{
+		"root", "queuedTxHash", "staker", "strategies", "sharesWei", "nativeETH",
+		"startBlock", "availableAfterBlock", "blocksRemaining", "estimatedReadyAt", "ready",
+	}); err != nil {
+		return err
+	}
+
+	for _, info := range withdrawals {
+		if err := w.Write([]string{
+			info.Root,
+			info.QueuedTxHash,
+			info.Staker,
+			strings.Join(lo.Map(info.Strategies, func(s common.Address, _ int) string { return s.Hex() }), ";"),
+			strings.Join(lo.Map(info.SharesWei, func(s *big.Int, _ int) string { return s.String() }), ";"),
+			info.TotalAmountETH.String(),
+			fmt.Sprintf("%d", info.StartBlock),
+			info.AvailableAfterBlock.String(),
+			fmt.Sprintf("%d", info.BlocksRemaining),
+			info.EstimatedReadyAt.UTC().Format(time.RFC3339),
+			fmt.Sprintf("%t", info.Ready),
+		}); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/fatih/color"
+)
+
+// How often the keeper scans for stale pods when --interval isn't given.
+const DEFAULT_KEEPER_INTERVAL = time.Hour
+
+type TStalePodKeeperArgs struct {
+	Node       string
+	BeaconNode string
+	Sender     string
+	Tolerance  float64
+	Interval   time.Duration
+	// 0 for no limit
+	MaxSpendGwei   uint64
+	MaxBaseFeeGwei uint64
+	DryRun         bool
+	// optional path to a PodAddressCache
+	PodCache     string
+	UseJSON      bool
+	DisableColor bool
+	Verbose      bool
+}
+
+func StalePodKeeperCommand(args TStalePodKeeperArgs) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if args.DisableColor {
+		color.NoColor = true
+	}
+	if args.Sender == "" && !args.DryRun {
+		return fmt.Errorf("no --sender given; pass one, or use --dry-run to only report stale pods")
+	}
+	if args.Interval <= 0 {
+		args.Interval = DEFAULT_KEEPER_INTERVAL
+	}
+
+	eth, beaconClient, chainId, err := utils.GetClients(ctx, args.Node, args.BeaconNode, args.Verbose)
+	utils.PanicOnError("failed to reach ethereum clients", err)
+
+	txMgr, err := utils.PrepareTxManager(eth, args.Sender, chainId, args.DryRun /* noSend */, args.Verbose)
+	utils.PanicOnError("failed to parse sender PK", err)
+
+	options := core.StalePodKeeperOptions{
+		TolerancePercent: args.Tolerance,
+		Verbose:          args.Verbose,
+	}
+	if args.MaxSpendGwei > 0 {
+		options.MaxSpendWei = utils.IGweiToWei(new(big.Int).SetUint64(args.MaxSpendGwei))
+	}
+	if args.MaxBaseFeeGwei > 0 {
+		options.MaxBaseFeeWei = utils.IGweiToWei(new(big.Int).SetUint64(args.MaxBaseFeeGwei))
+	}
+	if args.PodCache != "" {
+		options.Cache, err = core.LoadPodAddressCache(args.PodCache, chainId.Uint64())
+		utils.PanicOnError("failed to load pod cache", err)
+	}
+
+	keeper, err := core.NewStalePodKeeper(eth, beaconClient, chainId, txMgr, options)
+	if err != nil {
+		return err
+	}
+
+	if args.DryRun {
+		round, err := keeper.RunRound(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to scan for stale pods: %w", err)
+		}
+		printStalePodKeeperRound(round, args.UseJSON)
+		return nil
+	}
+
+	if !args.UseJSON {
+		color.Blue("Scanning for stale pods every %s (tolerance: %.2f%%). Press Ctrl+C to stop.", args.Interval, args.Tolerance)
+	}
+	for {
+		round, err := keeper.RunRound(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			color.Red("failed to scan for stale pods: %v", err)
+		} else {
+			printStalePodKeeperRound(round, args.UseJSON)
+		}
+
+		if keeper.BudgetExhausted() {
+			color.Yellow("Gas budget exhausted (spent %s ETH); stopping.", utils.IweiToEther(keeper.SpentWei()).String())
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(args.Interval):
+		}
+	}
+}
+
+func printStalePodKeeperRound(round *core.StalePodKeeperRound, useJSON bool) {
+	if useJSON {
+		PrintAsJSON(round)
+		return
+	}
+
+	fmt.Printf("block %d: %d pod(s) with slashed validators, %d stale\n", round.BlockNumber, round.SlashedPods, len(round.StalePods))
+	for _, pod := range round.StalePods {
+		line := fmt.Sprintf("\t%s  deviation %.4f%%  slashed validators %v  %s", pod.Pod, pod.DeviationPercent, pod.SlashedValidators, pod.Action)
+		switch pod.Action {
+		case core.StalePodSubmitted:
+			color.Green("%s %s (%s ETH)", line, pod.Tx, utils.IweiToEther(pod.CostWei).String())
+		case core.StalePodWouldSubmit:
+			color.Yellow("%s (validator %d, ~%d gas, at most %s ETH)", line, *pod.ProvenValidator, pod.EstimatedGas, utils.IweiToEther(pod.EstimatedCostWei).String())
+		default:
+			color.Red("%s: %s", line, pod.Reason)
+		}
+	}
+	if round.SpentWei.Sign() > 0 {
+		fmt.Printf("spent %s ETH on gas\n", utils.IweiToEther(round.SpentWei).String())
+	}
+}
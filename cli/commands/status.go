@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"sort"
 	"time"
 
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
@@ -21,6 +22,7 @@ type TStatusArgs struct {
 	Node            string
 	BeaconNode      string
 	Verbose         bool
+	Light           bool
 }
 
 func StatusCommand(args TStatusArgs) error {
@@ -42,7 +44,12 @@ func StatusCommand(args TStatusArgs) error {
 	eth, beaconClient, _, err := utils.GetClients(ctx, args.Node, args.BeaconNode, enableLogs)
 	utils.PanicOnError("failed to load ethereum clients", err)
 
-	status := core.GetStatus(ctx, args.EigenpodAddress, eth, beaconClient)
+	var status core.EigenpodStatus
+	if args.Light {
+		status = core.GetStatusLight(ctx, args.EigenpodAddress, eth, beaconClient)
+	} else {
+		status = core.GetStatus(ctx, args.EigenpodAddress, eth, beaconClient)
+	}
 
 	if args.UseJSON {
 		bytes, err := json.MarshalIndent(status, "", "      ")
@@ -128,6 +135,29 @@ func StatusCommand(args TStatusArgs) error {
 			fmt.Println()
 		}
 
+		// a light status doesn't load the beacon state, so has no queue estimates
+		if status.ExitQueue != nil {
+			bold.Printf("Beacon chain queues:\n")
+			ital.Printf("\t- A full exit requested now would exit at epoch %d (~%s), and be withdrawable %d epochs later\n", status.ExitQueue.ExitEpoch, formatETA(status.ExitQueue.ExitTime), utils.MIN_VALIDATOR_WITHDRAWABILITY_DELAY)
+			if status.ExitQueue.ConsolidationEpoch == utils.FAR_FUTURE_EPOCH {
+				ital.Printf("\t- The beacon chain is not processing consolidations (no consolidation churn)\n")
+			} else {
+				ital.Printf("\t- A consolidation requested now would exit its source at epoch %d (~%s), and move its balance to the target %d epochs later\n", status.ExitQueue.ConsolidationEpoch, formatETA(status.ExitQueue.ConsolidationTime), utils.MIN_VALIDATOR_WITHDRAWABILITY_DELAY)
+			}
+			if len(status.ExitingValidators) != 0 {
+				ital.Printf("\t- Exiting validators:\n")
+				for _, eta := range sortedETAs(status.ExitingValidators) {
+					ylw.Printf("\t\t#%d: exits at epoch %d (~%s), withdrawable at epoch %d (~%s), swept ~%s\n",
+						eta.Index,
+						eta.ExitEpoch, formatETA(eta.ExitTime),
+						eta.WithdrawableEpoch, formatETA(eta.WithdrawableTime),
+						formatETA(eta.SweepTime),
+					)
+				}
+			}
+			fmt.Println()
+		}
+
 		// Calculate the change in shares for completing a checkpoint
 		deltaETH := new(big.Float).Sub(
 			status.TotalSharesAfterCheckpointETH,
@@ -166,3 +196,24 @@ func printValidator(validator utils.Validator, targetColor *color.Color, isVerbo
 		targetColor.Printf("\t- #%d (%#02x) (%s) [%d] [%d]\n", validator.Index, validator.WithdrawalPrefix, publicKey, validator.EffectiveBalance, validator.CurrentBalance)
 	}
 }
+
+func sortedETAs(etas map[string]core.ValidatorETA) []core.ValidatorETA {
+	out := make([]core.ValidatorETA, 0, len(etas))
+	for _, eta := range etas {
+		out = append(out, eta)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Index < out[j].Index
+	})
+	return out
+}
+
+func formatETA(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	if d := time.Until(t); d > 0 {
+		return fmt.Sprintf("%s, in %s", t.Format(time.RFC822), d.Round(time.Minute))
+	}
+	return t.Format(time.RFC822)
+}
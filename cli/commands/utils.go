@@ -1,11 +1,16 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"os"
 
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/fatih/color"
 )
 
 type Transaction struct {
@@ -31,3 +36,110 @@ func PrintAsJSON(txns any) {
 	utils.PanicOnError("failed to serialize", err)
 	fmt.Println(string(out))
 }
+
+// handlePreflightReport prints the problems found by a preflight check to stderr, keeping them out of
+// --json output. Requests the beacon chain would drop are refused outright; warnings are confirmed
+// with the user unless `noWarn` or `noPrompt` is set, or nothing will be sent (`simulated`).
+func handlePreflightReport(report *core.PreflightReport, noWarn bool, noPrompt bool, simulated bool) error {
+	describe := func(issue core.PreflightIssue) string {
+		if issue.Request < 0 {
+			return issue.Message
+		}
+		return fmt.Sprintf("request %d: %s", issue.Request+1, issue.Message)
+	}
+
+	if len(report.Errors) > 0 {
+		for _, issue := range report.Errors {
+			color.New(color.FgRed).Fprintf(os.Stderr, "ERROR: %s\n", describe(issue))
+		}
+		return fmt.Errorf("%d problem(s) found that would cause the beacon chain to drop your request(s). No transactions were sent", len(report.Errors))
+	}
+
+	if len(report.Warnings) > 0 && !noWarn {
+		for _, issue := range report.Warnings {
+			color.New(color.FgYellow).Fprintf(os.Stderr, "WARN: %s\n", describe(issue))
+		}
+		if !noPrompt && !simulated {
+			utils.PanicIfNoConsent(fmt.Sprintf("%d warning(s) found (pass --no-warn to skip these checks)", len(report.Warnings)))
+		}
+	}
+	return nil
+}
+
+// newPredeployScheduler returns a scheduler capping the per-request predeploy fee at `maxFeeGwei`,
+// or nil if no cap is set.
+func newPredeployScheduler(
+	newScheduler func(*ethclient.Client, utils.PredeployScheduleOptions) *utils.PredeployScheduler,
+	eth *ethclient.Client,
+	maxFeeGwei uint64,
+	verbose bool,
+) *utils.PredeployScheduler {
+	if maxFeeGwei == 0 {
+		return nil
+	}
+
+	opts := utils.DefaultPredeployScheduleOptions()
+	opts.MaxFeePerRequest = utils.IGweiToWei(new(big.Int).SetUint64(maxFeeGwei))
+	opts.Verbose = verbose
+	return newScheduler(eth, opts)
+}
+
+// chunkFeeInfo returns the fee info of a chunk of `requests` predeploy requests, from `getFeeInfo`.
+// With a scheduler, it first waits for the fee to fit under the cap, and caps the overestimate; if
+// the fee rose past the cap in between, it waits again. Simulations don't wait, and keep the
+// overestimate if the fee is over the cap.
+func chunkFeeInfo(
+	ctx context.Context,
+	scheduler *utils.PredeployScheduler,
+	simulate bool,
+	requests int,
+	getFeeInfo func() (*utils.PredeployFeeInfo, error),
+) (*utils.PredeployFeeInfo, error) {
+	for {
+		if scheduler != nil && !simulate {
+			if _, err := scheduler.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		feeInfo, err := getFeeInfo()
+		if err != nil || scheduler == nil {
+			return feeInfo, err
+		}
+		if capped, ok := scheduler.CapValue(feeInfo.OverestimateFee, feeInfo.TotalFee, requests); ok {
+			feeInfo.OverestimateFee = capped
+			return feeInfo, nil
+		}
+		if simulate {
+			return feeInfo, nil
+		}
+	}
+}
+
+// LoadWithdrawalAddressIndex makes FindAllValidatorsForEigenpod use the index saved at `path`,
+// which is created by SaveWithdrawalAddressIndex if it doesn't exist yet.
+func LoadWithdrawalAddressIndex(path string) error {
+	index, err := utils.LoadWithdrawalAddressIndex(path)
+	if err != nil {
+		return err
+	}
+	utils.UseWithdrawalAddressIndex(index)
+	return nil
+}
+
+// SaveWithdrawalAddressIndex writes the index FindAllValidatorsForEigenpod used back to `path`, if
+// any newer state was indexed.
+func SaveWithdrawalAddressIndex(path string) error {
+	return utils.SharedWithdrawalAddressIndex().Save(path)
+}
+
+// UseStateCache makes the beacon clients of every command cache the states they fetch in `dir`
+// (see utils.DefaultStateCacheDir), or not at all if `dir` is empty.
+func UseStateCache(dir string) {
+	utils.UseStateCache(dir)
+}
+
+// DefaultStateCacheDir is where states are cached unless --stateCache says otherwise.
+func DefaultStateCacheDir() string {
+	return utils.DefaultStateCacheDir()
+}
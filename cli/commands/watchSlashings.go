@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/fatih/color"
+)
+
+type TWatchSlashingsArgs struct {
+	Pods                []string
+	Node                string
+	BeaconNode          string
+	Sender              string
+	Tolerance           float64
+	CheckIntervalEpochs uint64
+	CheckpointBatchSize uint64
+	DisableColor        bool
+	Verbose             bool
+}
+
+func WatchSlashingsCommand(args TWatchSlashingsArgs) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if args.DisableColor {
+		color.NoColor = true
+	}
+	if len(args.Pods) == 0 {
+		return fmt.Errorf("no pods to watch; pass --pods")
+	}
+
+	eth, beaconClient, chainId, err := utils.GetClients(ctx, args.Node, args.BeaconNode, args.Verbose)
+	utils.PanicOnError("failed to reach ethereum clients", err)
+
+	var txMgr *utils.TxManager
+	if args.Sender != "" {
+		txMgr, err = utils.PrepareTxManager(eth, args.Sender, chainId, false /* noSend */, args.Verbose)
+		utils.PanicOnError("failed to parse sender PK", err)
+	} else {
+		color.Yellow("No --sender given: pods that need correcting will only be reported.")
+	}
+
+	watcher := core.NewSlashingWatcher(eth, beaconClient, chainId, txMgr, args.Pods, core.SlashingWatcherOptions{
+		TolerancePercent:    args.Tolerance,
+		CheckIntervalEpochs: args.CheckIntervalEpochs,
+		CheckpointBatchSize: args.CheckpointBatchSize,
+		Verbose:             args.Verbose,
+	})
+
+	color.Blue("Watching %d pod(s) for slashings (tolerance: %.2f%%). Press Ctrl+C to stop.", len(args.Pods), args.Tolerance)
+	if err := watcher.Run(ctx); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
@@ -21,21 +21,42 @@ import (
 	"github.com/fatih/color"
 )
 
-func SubmitCheckpointProof(ctx context.Context, owner, eigenpodAddress string, chainId *big.Int, proof *eigenpodproofs.VerifyCheckpointProofsCallParams, eth *ethclient.Client, batchSize uint64, noPrompt bool, noSend bool, verbose bool) ([]*types.Transaction, error) {
+// SubmitCheckpointProof submits `proof` in chunks of `batchSize`, keeping several chunks in flight at once.
+// Live submissions wait for every chunk to be mined before returning.
+func SubmitCheckpointProof(ctx context.Context, txMgr *utils.TxManager, eigenpodAddress string, proof *eigenpodproofs.VerifyCheckpointProofsCallParams, eth *ethclient.Client, batchSize uint64, noPrompt bool, verbose bool) ([]*types.Transaction, error) {
 	tracing := utils.GetContextTracingCallbacks(ctx)
 
 	allProofChunks := utils.Chunk(proof.BalanceProofs, batchSize)
 	transactions := []*types.Transaction{}
 	if verbose {
 		color.Green("calling EigenPod.VerifyCheckpointProofs() (using %d txn(s), max(%d) proofs per txn)", len(allProofChunks), batchSize)
+		fmt.Printf("Using account(0x%s) to submit onchain\n", common.Bytes2Hex(txMgr.Owner().FromAddress[:]))
+	}
+
+	eigenPod, err := EigenPod.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
+	if err != nil {
+		return nil, err
 	}
 
 	for i := 0; i < len(allProofChunks); i++ {
 		balanceProofs := allProofChunks[i]
+
+		// The last chunk to be mined finalizes the checkpoint, which costs far more gas than a proof.
+		// Its gas is estimated against the latest state, so send it only once every earlier chunk is
+		// mined.
+		if i > 0 && i == len(allProofChunks)-1 && !txMgr.IsDryRun() {
+			tracing.OnStartSection("pepe::proof::checkpoint::batch::wait", map[string]string{})
+			_, err := txMgr.WaitAll(ctx)
+			tracing.OnEndSection()
+			if err != nil {
+				return transactions, err
+			}
+		}
+
 		tracing.OnStartSection("pepe::proof::checkpoint::batch::submit", map[string]string{
 			"chunk": fmt.Sprintf("%d", i),
 		})
-		txn, err := SubmitCheckpointProofBatch(ctx, owner, eigenpodAddress, chainId, proof.ValidatorBalancesRootProof, balanceProofs, eth, noSend, verbose)
+		txn, err := SubmitCheckpointProofBatch(ctx, txMgr, eigenPod, eigenpodAddress, proof.ValidatorBalancesRootProof, balanceProofs)
 		tracing.OnEndSection()
 		if err != nil {
 			// failed to submit batch.
@@ -43,23 +64,27 @@ func SubmitCheckpointProof(ctx context.Context, owner, eigenpodAddress string, c
 		}
 		transactions = append(transactions, txn)
 		if verbose {
-			fmt.Printf("Submitted chunk %d/%d -- waiting for transaction...: ", i+1, len(allProofChunks))
+			fmt.Printf("Submitted chunk %d/%d: %s\n", i+1, len(allProofChunks), txn.Hash().Hex())
 		}
-		tracing.OnStartSection("pepe::proof::checkpoint::batch::wait", map[string]string{
-			"chunk": fmt.Sprintf("%d", i),
-		})
+	}
 
-		if !noSend {
-			bind.WaitMined(ctx, eth, txn)
+	if !txMgr.IsDryRun() {
+		if verbose {
+			fmt.Printf("waiting for %d transaction(s)...: ", len(transactions))
 		}
+		tracing.OnStartSection("pepe::proof::checkpoint::batch::wait", map[string]string{})
+		_, err := txMgr.WaitAll(ctx)
 		tracing.OnEndSection()
+		if err != nil {
+			return transactions, err
+		}
 		if verbose {
 			color.Green("OK")
 		}
 	}
 
 	if verbose {
-		if !noSend {
+		if !txMgr.IsDryRun() {
 			color.Green("Complete! re-run with `status` to see the updated Eigenpod state.")
 		} else {
 			color.Yellow("Submit these proofs to network and re-run with `status` to see the updated Eigenpod state.")
@@ -68,34 +93,22 @@ func SubmitCheckpointProof(ctx context.Context, owner, eigenpodAddress string, c
 	return transactions, nil
 }
 
-func SubmitCheckpointProofBatch(ctx context.Context, owner, eigenpodAddress string, chainId *big.Int, proof *eigenpodproofs.ValidatorBalancesRootProof, balanceProofs []*eigenpodproofs.BalanceProof, eth *ethclient.Client, noSend bool, verbose bool) (*types.Transaction, error) {
+func SubmitCheckpointProofBatch(ctx context.Context, txMgr *utils.TxManager, eigenPod *EigenPod.EigenPod, eigenpodAddress string, proof *eigenpodproofs.ValidatorBalancesRootProof, balanceProofs []*eigenpodproofs.BalanceProof) (*types.Transaction, error) {
 	tracing := utils.GetContextTracingCallbacks(ctx)
 
-	ownerAccount, err := utils.PrepareAccount(&owner, chainId, noSend)
-	if err != nil {
-		return nil, err
-	}
-
-	if verbose {
-		fmt.Printf("Using account(0x%s) to submit onchain\n", common.Bytes2Hex(ownerAccount.FromAddress[:]))
-	}
-
-	eigenPod, err := EigenPod.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
-	if err != nil {
-		return nil, err
-	}
-
 	tracing.OnStartSection("pepe::proof::checkpoint::onchain::VerifyCheckpointProofs", map[string]string{
 		"eigenpod": eigenpodAddress,
 	})
-	txn, err := eigenPod.VerifyCheckpointProofs(
-		ownerAccount.TransactionOptions,
-		EigenPod.BeaconChainProofsBalanceContainerProof{
-			BalanceContainerRoot: proof.ValidatorBalancesRoot,
-			Proof:                proof.Proof.ToByteSlice(),
-		},
-		utils.CastBalanceProofs(balanceProofs),
-	)
+	txn, err := txMgr.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return eigenPod.VerifyCheckpointProofs(
+			opts,
+			EigenPod.BeaconChainProofsBalanceContainerProof{
+				BalanceContainerRoot: proof.ValidatorBalancesRoot,
+				Proof:                proof.Proof.ToByteSlice(),
+			},
+			utils.CastBalanceProofs(balanceProofs),
+		)
+	})
 	tracing.OnEndSection()
 	if err != nil {
 		return nil, err
@@ -125,39 +138,52 @@ func asJSON(obj interface{}) string {
 	return string(bytes)
 }
 
-func GenerateCheckpointProof(ctx context.Context, eigenpodAddress string, eth *ethclient.Client, chainId *big.Int, beaconClient utils.BeaconClient, verbose bool) (*eigenpodproofs.VerifyCheckpointProofsCallParams, error) {
+// getCheckpointHeader returns the pod's current checkpoint timestamp, and the header of the block
+// whose state the checkpoint is proven against.
+func getCheckpointHeader(ctx context.Context, eigenpodAddress string, eth *ethclient.Client, beaconClient utils.BeaconClient) (uint64, *v1.BeaconBlockHeader, error) {
 	tracing := utils.GetContextTracingCallbacks(ctx)
 
 	tracing.OnStartSection("GetCurrentCheckpoint", map[string]string{})
 	currentCheckpoint, err := utils.GetCurrentCheckpoint(eigenpodAddress, eth)
 	if err != nil {
-		return nil, err
+		return 0, nil, err
 	}
 	tracing.OnEndSection()
 
 	tracing.OnStartSection("GetCurrentCheckpointBlockRoot", map[string]string{})
 	blockRoot, err := utils.GetCurrentCheckpointBlockRoot(eigenpodAddress, eth)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch last checkpoint: %w", err)
+		return 0, nil, fmt.Errorf("failed to fetch last checkpoint: %w", err)
 	}
 	if blockRoot == nil {
-		return nil, fmt.Errorf("failed to fetch last checkpoint - nil blockRoot")
+		return 0, nil, fmt.Errorf("failed to fetch last checkpoint - nil blockRoot")
 	}
 	tracing.OnEndSection()
 
 	rootBytes := *blockRoot
 	if utils.AllZero(rootBytes[:]) {
-		return nil, fmt.Errorf("no checkpoint active. Are you sure you started a checkpoint?")
+		return 0, nil, fmt.Errorf("no checkpoint active. Are you sure you started a checkpoint?")
 	}
 
 	headerBlock := "0x" + hex.EncodeToString((*blockRoot)[:])
 	tracing.OnStartSection("GetBeaconHeader", map[string]string{})
 	header, err := beaconClient.GetBeaconHeader(ctx, headerBlock)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch beacon header (%s): %w", headerBlock, err)
+		return 0, nil, fmt.Errorf("failed to fetch beacon header (%s): %w", headerBlock, err)
 	}
 	tracing.OnEndSection()
 
+	return currentCheckpoint, header, nil
+}
+
+func GenerateCheckpointProof(ctx context.Context, eigenpodAddress string, eth *ethclient.Client, chainId *big.Int, beaconClient utils.BeaconClient, verbose bool) (*eigenpodproofs.VerifyCheckpointProofsCallParams, error) {
+	tracing := utils.GetContextTracingCallbacks(ctx)
+
+	currentCheckpoint, header, err := getCheckpointHeader(ctx, eigenpodAddress, eth, beaconClient)
+	if err != nil {
+		return nil, err
+	}
+
 	tracing.OnStartSection("GetBeaconState", map[string]string{})
 	beaconState, err := beaconClient.GetBeaconState(ctx, strconv.FormatUint(uint64(header.Header.Message.Slot), 10))
 	if err != nil {
@@ -173,6 +199,77 @@ func GenerateCheckpointProof(ctx context.Context, eigenpodAddress string, eth *e
 	return GenerateCheckpointProofForState(ctx, eigenpodAddress, beaconState, header, eth, currentCheckpoint, proofs, verbose)
 }
 
+// GenerateCheckpointProofFromNode is GenerateCheckpointProof without downloading the beacon state,
+// for beacon nodes that serve state proofs (see BeaconClient.GetStateProof). The pod's validators
+// are found from its ValidatorRestaked events.
+func GenerateCheckpointProofFromNode(ctx context.Context, eigenpodAddress string, eth *ethclient.Client, chainId *big.Int, beaconClient utils.BeaconClient, verbose bool) (*eigenpodproofs.VerifyCheckpointProofsCallParams, error) {
+	tracing := utils.GetContextTracingCallbacks(ctx)
+
+	currentCheckpoint, header, err := getCheckpointHeader(ctx, eigenpodAddress, eth, beaconClient)
+	if err != nil {
+		return nil, err
+	}
+	stateId := strconv.FormatUint(uint64(header.Header.Message.Slot), 10)
+
+	// the proof layout depends on the fork of the state
+	block, err := beaconClient.GetBlock(ctx, header.Root.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch beacon block: %w", err)
+	}
+	if block == nil {
+		return nil, fmt.Errorf("beacon block %s not found", header.Root)
+	}
+
+	tracing.OnStartSection("FindRestakedValidatorIndices", map[string]string{})
+	indices, err := utils.FindRestakedValidatorIndices(ctx, eth, eigenpodAddress)
+	if err != nil {
+		return nil, err
+	}
+	tracing.OnEndSection()
+
+	beaconValidators, err := utils.GetValidatorsByIndex(ctx, beaconClient, stateId, indices)
+	if err != nil {
+		return nil, err
+	}
+	allValidators := make([]utils.ValidatorWithIndex, len(indices))
+	for i, validator := range beaconValidators {
+		allValidators[i] = utils.ValidatorWithIndex{Validator: validator.Validator, Index: indices[i]}
+	}
+
+	allValidatorsWithInfo, err := utils.FetchMultipleOnchainValidatorInfo(ctx, eth, eigenpodAddress, allValidators)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpointValidators, err := utils.SelectCheckpointableValidators(eth, eigenpodAddress, allValidatorsWithInfo, currentCheckpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	validatorIndices := make([]uint64, len(checkpointValidators))
+	for i, v := range checkpointValidators {
+		validatorIndices[i] = v.Index
+	}
+
+	if verbose {
+		color.Yellow("Proving validators at indices: %s", asJSON(validatorIndices))
+	}
+
+	proofs, err := eigenpodproofs.NewEigenPodProofs(chainId.Uint64(), 300 /* oracleStateCacheExpirySeconds - 5min */)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize prover: %w", err)
+	}
+
+	tracing.OnStartSection("ProveCheckpointProofsFromNode", map[string]string{})
+	proof, err := proofs.ProveCheckpointProofsFromNode(ctx, beaconClient, header.Header.Message, block.Version, validatorIndices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prove checkpoint: %w", err)
+	}
+	tracing.OnEndSection()
+
+	return proof, nil
+}
+
 func GenerateCheckpointProofForState(ctx context.Context, eigenpodAddress string, beaconState *spec.VersionedBeaconState, header *v1.BeaconBlockHeader, eth *ethclient.Client, currentCheckpointTimestamp uint64, proofs *eigenpodproofs.EigenPodProofs, verbose bool) (*eigenpodproofs.VerifyCheckpointProofsCallParams, error) {
 	tracing := utils.GetContextTracingCallbacks(ctx)
 
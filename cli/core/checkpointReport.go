@@ -0,0 +1,444 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPodManager"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type ValidatorBalanceDelta struct {
+	// identifies the validator; checkpoints from before the slashing upgrade identify validators by
+	// index instead, leaving PubkeyHash empty
+	PubkeyHash     string
+	ValidatorIndex *uint64 `json:",omitempty"`
+
+	// nil if the validator's balance before the checkpoint couldn't be determined (e.g. the
+	// node isn't an archive node)
+	PreviousBalanceGwei *uint64
+	NewBalanceGwei      uint64
+	// nil if PreviousBalanceGwei is unknown
+	DeltaGwei *big.Int
+
+	// whether the checkpoint found the validator fully exited
+	Withdrawn bool
+}
+
+type SlashingFactorChange struct {
+	Previous uint64
+	New      uint64
+}
+
+// CheckpointLedgerEntry accounts for the shares a single completed checkpoint awarded the pod owner:
+//
+//	TotalShareDeltaWei = (ValidatorBalanceDeltaGwei + PodBalanceDeltaGwei) * 1 gwei
+type CheckpointLedgerEntry struct {
+	CheckpointTimestamp uint64
+	BeaconBlockRoot     string
+	ValidatorCount      uint64
+
+	// 0/empty if the checkpoint was started before the reported range
+	StartedBlock uint64
+	StartedTx    string
+
+	FinalizedBlock uint64
+	FinalizedTx    string
+	// unix timestamp of FinalizedBlock
+	FinalizedAt uint64
+
+	TotalShareDeltaWei *big.Int
+	// sum of the validators' balance changes; nil if any validator's previous balance is unknown, or
+	// the checkpoint is Incomplete
+	ValidatorBalanceDeltaGwei *big.Int
+	// native ETH that arrived in the pod since the previous checkpoint (e.g. skimmed rewards, or
+	// exited validators' balances); nil if ValidatorBalanceDeltaGwei is nil
+	PodBalanceDeltaGwei *big.Int
+	Validators          []ValidatorBalanceDelta
+
+	// set if finalizing the checkpoint decreased the owner's beacon chain slashing factor
+	SlashingFactor *SlashingFactorChange
+	// the owner's total beacon chain ETH shares after the checkpoint; nil if not emitted
+	NewTotalSharesWei *big.Int
+
+	// whether some of the pod's logs while the checkpoint was active couldn't be decoded, so
+	// Validators may be missing some
+	Incomplete bool
+}
+
+type checkpointEvents struct {
+	created   *utils.DecodedEvent
+	createdAt uint64
+	createdTx common.Hash
+
+	finalized   *utils.DecodedEvent
+	finalizedAt uint64
+	finalizedTx common.Hash
+
+	// ValidatorBalanceUpdated and ValidatorWithdrawn events, in order
+	validatorEvents []utils.DecodedEvent
+	// block of the first proof
+	firstProofBlock uint64
+}
+
+// GetCheckpointLedger reconstructs every checkpoint of `eigenpodAddress` finalized between
+// `fromBlock` and `toBlock` from the pod's events, and the owner's shares and slashing factor
+// updates from the EigenPodManager's.
+func GetCheckpointLedger(ctx context.Context, eth *ethclient.Client, eigenpodAddress string, fromBlock, toBlock uint64) ([]CheckpointLedgerEntry, error) {
+	podAddress := common.HexToAddress(eigenpodAddress)
+	pod, err := EigenPod.NewEigenPod(podAddress, eth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach eigenpod: %w", err)
+	}
+	owner, err := pod.PodOwner(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pod owner: %w", err)
+	}
+	eigenPodManagerAddress, err := pod.EigenPodManager(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch eigenpod manager: %w", err)
+	}
+
+	podLogs, err := utils.FilterLogsChunked(ctx, eth, ethereum.FilterQuery{Addresses: []common.Address{podAddress}}, fromBlock, toBlock, utils.DEFAULT_LOG_FILTER_CHUNK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch eigenpod events: %w", err)
+	}
+
+	epmEvents, err := eventIDs(EigenPodManager.EigenPodManagerMetaData.GetAbi, "BeaconChainSlashingFactorDecreased", "NewTotalShares")
+	if err != nil {
+		return nil, err
+	}
+	epmLogs, err := utils.FilterLogsChunked(ctx, eth, ethereum.FilterQuery{
+		Addresses: []common.Address{eigenPodManagerAddress},
+		Topics:    [][]common.Hash{epmEvents},
+	}, fromBlock, toBlock, utils.DEFAULT_LOG_FILTER_CHUNK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch eigenpod manager events: %w", err)
+	}
+
+	// the owner's slashing factor and total shares are updated in the tx that finalizes a checkpoint
+	slashingFactors := map[common.Hash]*SlashingFactorChange{}
+	totalShares := map[common.Hash]*big.Int{}
+	for i := range epmLogs {
+		event, ok := utils.DecodeLog(&epmLogs[i])
+		if !ok {
+			continue
+		}
+		args := &eventArgs{event: event}
+		switch event.Name {
+		case "BeaconChainSlashingFactorDecreased":
+			if args.addressArg("staker") == owner {
+				slashingFactors[epmLogs[i].TxHash] = &SlashingFactorChange{
+					Previous: args.uintArg("prevBeaconChainSlashingFactor"),
+					New:      args.uintArg("newBeaconChainSlashingFactor"),
+				}
+			}
+		case "NewTotalShares":
+			if args.addressArg("podOwner") == owner {
+				totalShares[epmLogs[i].TxHash] = args.bigArg("newTotalShares")
+			}
+		}
+		if args.err != nil {
+			return nil, args.err
+		}
+	}
+
+	events := []loggedEvent{}
+	for i := range podLogs {
+		event, ok := utils.DecodeLog(&podLogs[i])
+		events = append(events, loggedEvent{event: event, undecodable: !ok, block: podLogs[i].BlockNumber, tx: podLogs[i].TxHash})
+	}
+
+	blockTime := func(block uint64) (uint64, error) {
+		header, err := eth.HeaderByNumber(ctx, new(big.Int).SetUint64(block))
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch block %d: %w", block, err)
+		}
+		return header.Time, nil
+	}
+	restakedBalance := func(pubkeyHash [32]byte, block uint64) (uint64, bool) {
+		return restakedBalanceAt(pod, pubkeyHash, block)
+	}
+	return buildCheckpointLedger(events, slashingFactors, totalShares, blockTime, restakedBalance)
+}
+
+// loggedEvent is a decoded event, with the block and transaction that emitted it.
+type loggedEvent struct {
+	event utils.DecodedEvent
+	// set for logs DecodeLog doesn't know, whose event is empty
+	undecodable bool
+	block       uint64
+	tx          common.Hash
+}
+
+// buildCheckpointLedger accounts for the checkpoints finalized in `events` (a pod's, in order).
+// `slashingFactors` and `totalShares` are the owner's updates, by transaction. Validators whose
+// balance before a checkpoint isn't in `events` are looked up with `restakedBalance` as of the block
+// before the checkpoint's first proof.
+func buildCheckpointLedger(
+	events []loggedEvent,
+	slashingFactors map[common.Hash]*SlashingFactorChange,
+	totalShares map[common.Hash]*big.Int,
+	blockTime func(block uint64) (uint64, error),
+	restakedBalance func(pubkeyHash [32]byte, block uint64) (uint64, bool),
+) ([]CheckpointLedgerEntry, error) {
+	// Credential proofs also emit ValidatorBalanceUpdated, with the timestamp of the proven state.
+	// Balance updates at a checkpoint's timestamp are checkpoint proofs.
+	checkpointTimestamps := map[uint64]bool{}
+	for _, logged := range events {
+		switch logged.event.Name {
+		case "CheckpointCreated", "CheckpointFinalized", "ValidatorCheckpointed", "ValidatorWithdrawn":
+			args := &eventArgs{event: logged.event}
+			checkpointTimestamps[args.uintArg("checkpointTimestamp")] = true
+			if args.err != nil {
+				return nil, args.err
+			}
+		}
+	}
+
+	checkpoints := map[uint64]*checkpointEvents{}
+	order := []uint64{}
+	checkpointFor := func(timestamp uint64) *checkpointEvents {
+		if _, ok := checkpoints[timestamp]; !ok {
+			checkpoints[timestamp] = &checkpointEvents{}
+			order = append(order, timestamp)
+		}
+		return checkpoints[timestamp]
+	}
+
+	// last known balance of each validator, from the balance updates seen so far
+	balances := map[string]uint64{}
+	// balances as of each checkpoint's start, for the validators it proved
+	previousBalances := map[uint64]map[string]uint64{}
+	proved := map[uint64]map[string]bool{}
+	undecodableBlocks := []uint64{}
+
+	for _, logged := range events {
+		if logged.undecodable {
+			undecodableBlocks = append(undecodableBlocks, logged.block)
+			continue
+		}
+		event := logged.event
+		args := &eventArgs{event: event}
+
+		switch event.Name {
+		case "CheckpointCreated":
+			checkpoint := checkpointFor(args.uintArg("checkpointTimestamp"))
+			checkpoint.created = &event
+			checkpoint.createdAt = logged.block
+			checkpoint.createdTx = logged.tx
+		case "CheckpointFinalized":
+			checkpoint := checkpointFor(args.uintArg("checkpointTimestamp"))
+			checkpoint.finalized = &event
+			checkpoint.finalizedAt = logged.block
+			checkpoint.finalizedTx = logged.tx
+		case "ValidatorBalanceUpdated", "ValidatorWithdrawn":
+			validator := args.validator().key()
+			timestamp, ok := event.ArgUint64("checkpointTimestamp")
+			if !ok {
+				timestamp = args.uintArg("balanceTimestamp")
+			}
+			if checkpointTimestamps[timestamp] {
+				checkpoint := checkpointFor(timestamp)
+				if checkpoint.firstProofBlock == 0 {
+					checkpoint.firstProofBlock = logged.block
+				}
+				if proved[timestamp] == nil {
+					proved[timestamp] = map[string]bool{}
+					previousBalances[timestamp] = map[string]uint64{}
+				}
+				if balance, ok := balances[validator]; ok && !proved[timestamp][validator] {
+					previousBalances[timestamp][validator] = balance
+				}
+				proved[timestamp][validator] = true
+				checkpoint.validatorEvents = append(checkpoint.validatorEvents, event)
+			}
+			if event.Name == "ValidatorBalanceUpdated" {
+				balances[validator] = args.uintArg("newValidatorBalanceGwei")
+			} else {
+				balances[validator] = 0
+			}
+		}
+		if args.err != nil {
+			return nil, args.err
+		}
+	}
+
+	ledger := []CheckpointLedgerEntry{}
+	for _, timestamp := range order {
+		checkpoint := checkpoints[timestamp]
+		if checkpoint.finalized == nil {
+			continue
+		}
+
+		// every event's arguments were checked above
+		finalized := &eventArgs{event: *checkpoint.finalized}
+		entry := CheckpointLedgerEntry{
+			CheckpointTimestamp: timestamp,
+			FinalizedBlock:      checkpoint.finalizedAt,
+			FinalizedTx:         checkpoint.finalizedTx.Hex(),
+			TotalShareDeltaWei:  finalized.bigArg("totalShareDeltaWei"),
+			SlashingFactor:      slashingFactors[checkpoint.finalizedTx],
+			NewTotalSharesWei:   totalShares[checkpoint.finalizedTx],
+			Validators:          []ValidatorBalanceDelta{},
+		}
+		if checkpoint.created != nil {
+			created := &eventArgs{event: *checkpoint.created}
+			entry.BeaconBlockRoot = common.Hash(created.bytes32Arg("beaconBlockRoot")).Hex()
+			entry.ValidatorCount = created.bigArg("validatorCount").Uint64()
+			entry.StartedBlock = checkpoint.createdAt
+			entry.StartedTx = checkpoint.createdTx.Hex()
+			if created.err != nil {
+				return nil, created.err
+			}
+		}
+		if finalized.err != nil {
+			return nil, finalized.err
+		}
+
+		finalizedAt, err := blockTime(checkpoint.finalizedAt)
+		if err != nil {
+			return nil, err
+		}
+		entry.FinalizedAt = finalizedAt
+
+		sumDeltasGwei := big.NewInt(0)
+		for _, event := range checkpoint.validatorEvents {
+			args := &eventArgs{event: event}
+			delta := args.validator()
+			delta.Withdrawn = event.Name == "ValidatorWithdrawn"
+			if !delta.Withdrawn {
+				delta.NewBalanceGwei = args.uintArg("newValidatorBalanceGwei")
+			}
+			if args.err != nil {
+				return nil, args.err
+			}
+			// a withdrawn validator also emits a balance update to 0 in the same proof
+			if i := findValidatorDelta(entry.Validators, delta.key()); i >= 0 {
+				entry.Validators[i].Withdrawn = entry.Validators[i].Withdrawn || delta.Withdrawn
+				continue
+			}
+
+			previous, ok := previousBalances[timestamp][delta.key()]
+			if !ok && delta.ValidatorIndex == nil {
+				// the pod can only be asked about validators by pubkey hash
+				previous, ok = restakedBalance(common.HexToHash(delta.PubkeyHash), checkpoint.firstProofBlock-1)
+			}
+			if ok {
+				delta.PreviousBalanceGwei = &previous
+				delta.DeltaGwei = new(big.Int).Sub(new(big.Int).SetUint64(delta.NewBalanceGwei), new(big.Int).SetUint64(previous))
+				if sumDeltasGwei != nil {
+					sumDeltasGwei.Add(sumDeltasGwei, delta.DeltaGwei)
+				}
+			} else {
+				sumDeltasGwei = nil
+			}
+			entry.Validators = append(entry.Validators, delta)
+		}
+
+		// checkpoints started before the range cover every undecodable log before they were finalized
+		for _, block := range undecodableBlocks {
+			if checkpoint.createdAt <= block && block <= checkpoint.finalizedAt {
+				entry.Incomplete = true
+			}
+		}
+
+		if sumDeltasGwei != nil && !entry.Incomplete {
+			entry.ValidatorBalanceDeltaGwei = sumDeltasGwei
+			totalDeltaGwei := new(big.Int).Quo(entry.TotalShareDeltaWei, big.NewInt(params.GWei))
+			entry.PodBalanceDeltaGwei = new(big.Int).Sub(totalDeltaGwei, sumDeltasGwei)
+		}
+		ledger = append(ledger, entry)
+	}
+	return ledger, nil
+}
+
+// eventArgs reads an event's arguments, keeping the first that's missing or of an unexpected type
+// in `err`; its accessors return zero values (and a zero *big.Int) for those.
+type eventArgs struct {
+	event utils.DecodedEvent
+	err   error
+}
+
+// validator identifies the validator of a ValidatorBalanceUpdated or ValidatorWithdrawn event: by
+// pubkey hash, or by index for events emitted before the slashing upgrade.
+func (a *eventArgs) validator() ValidatorBalanceDelta {
+	if index, ok := a.event.ArgBig("validatorIndex"); ok {
+		i := index.Uint64()
+		return ValidatorBalanceDelta{ValidatorIndex: &i}
+	}
+	return ValidatorBalanceDelta{PubkeyHash: common.Hash(a.bytes32Arg("pubkeyHash")).Hex()}
+}
+
+func (a *eventArgs) fail(name string) {
+	if a.err == nil {
+		a.err = fmt.Errorf("%s event has no valid %s argument", a.event.Name, name)
+	}
+}
+
+func (a *eventArgs) uintArg(name string) uint64 {
+	v, ok := a.event.ArgUint64(name)
+	if !ok {
+		a.fail(name)
+	}
+	return v
+}
+
+func (a *eventArgs) bigArg(name string) *big.Int {
+	v, ok := a.event.ArgBig(name)
+	if !ok {
+		a.fail(name)
+		return big.NewInt(0)
+	}
+	return v
+}
+
+func (a *eventArgs) bytes32Arg(name string) [32]byte {
+	v, ok := a.event.ArgBytes32(name)
+	if !ok {
+		a.fail(name)
+	}
+	return v
+}
+
+func (a *eventArgs) addressArg(name string) common.Address {
+	v, ok := a.event.ArgAddress(name)
+	if !ok {
+		a.fail(name)
+	}
+	return v
+}
+
+// key identifies the validator among a pod's others.
+func (d ValidatorBalanceDelta) key() string {
+	if d.ValidatorIndex != nil {
+		return fmt.Sprintf("#%d", *d.ValidatorIndex)
+	}
+	return d.PubkeyHash
+}
+
+func findValidatorDelta(deltas []ValidatorBalanceDelta, key string) int {
+	for i, delta := range deltas {
+		if delta.key() == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// restakedBalanceAt reads the validator's restaked balance as of `block`. This needs an archive node
+// for blocks older than ~128 blocks.
+func restakedBalanceAt(pod *EigenPod.EigenPod, pubkeyHash [32]byte, block uint64) (uint64, bool) {
+	info, err := pod.ValidatorPubkeyHashToInfo(&bind.CallOpts{BlockNumber: new(big.Int).SetUint64(block)}, pubkeyHash)
+	if err != nil {
+		return 0, false
+	}
+	return info.RestakedBalanceGwei, true
+}
@@ -0,0 +1,228 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// podEvents builds a pod's event log, one block per call to `at`.
+type podEvents struct {
+	events []loggedEvent
+}
+
+func (p *podEvents) at(block uint64, name string, args ...utils.EventArg) {
+	p.events = append(p.events, loggedEvent{
+		event: utils.DecodedEvent{Contract: "EigenPod", Name: name, Args: args},
+		block: block,
+		tx:    common.BigToHash(new(big.Int).SetUint64(block)),
+	})
+}
+
+func arg(name string, value interface{}) utils.EventArg {
+	return utils.EventArg{Name: name, Value: value}
+}
+
+func (p *podEvents) created(block, timestamp uint64, validatorCount int64) {
+	p.at(block, "CheckpointCreated", arg("checkpointTimestamp", timestamp), arg("beaconBlockRoot", [32]byte{byte(timestamp)}), arg("validatorCount", big.NewInt(validatorCount)))
+}
+
+func (p *podEvents) proved(block, timestamp uint64, pubkeyHash [32]byte, balanceGwei uint64) {
+	p.at(block, "ValidatorCheckpointed", arg("checkpointTimestamp", timestamp), arg("pubkeyHash", pubkeyHash))
+	p.at(block, "ValidatorBalanceUpdated", arg("pubkeyHash", pubkeyHash), arg("balanceTimestamp", timestamp), arg("newValidatorBalanceGwei", balanceGwei))
+}
+
+func (p *podEvents) finalized(block, timestamp uint64, totalShareDeltaGwei int64) {
+	p.at(block, "CheckpointFinalized", arg("checkpointTimestamp", timestamp), arg("totalShareDeltaWei", new(big.Int).Mul(big.NewInt(totalShareDeltaGwei), big.NewInt(1e9))))
+}
+
+func TestBuildCheckpointLedger(t *testing.T) {
+	a, b, c := [32]byte{0xa}, [32]byte{0xb}, [32]byte{0xc}
+	p := &podEvents{}
+
+	// a credential proof, which isn't part of a checkpoint
+	p.at(5, "ValidatorBalanceUpdated", arg("pubkeyHash", a), arg("balanceTimestamp", uint64(50)), arg("newValidatorBalanceGwei", uint64(32_000_000_000)))
+
+	// b's previous balance isn't in the events; the pod gained 2 ETH
+	p.created(10, 100, 2)
+	p.proved(11, 100, a, 32_100_000_000)
+	p.proved(11, 100, b, 31_000_000_000)
+	p.finalized(12, 100, 100_000_000-1_000_000_000+2_000_000_000)
+
+	// a exits; its balance arrives in the pod, with 0.05 ETH of rewards
+	p.created(20, 200, 2)
+	p.proved(21, 200, a, 0)
+	p.at(21, "ValidatorWithdrawn", arg("checkpointTimestamp", uint64(200)), arg("pubkeyHash", a))
+	p.proved(21, 200, b, 31_000_000_000)
+	p.finalized(22, 200, 50_000_000)
+
+	// c's previous balance is unknown
+	p.created(30, 300, 2)
+	p.proved(31, 300, b, 31_010_000_000)
+	p.proved(31, 300, c, 32_000_000_000)
+	p.finalized(32, 300, 10_000_000)
+
+	// not finalized
+	p.created(40, 400, 2)
+	p.proved(41, 400, b, 31_020_000_000)
+
+	slashingFactors := map[common.Hash]*SlashingFactorChange{
+		common.BigToHash(big.NewInt(22)): {Previous: 1e18, New: 9e17},
+	}
+	totalShares := map[common.Hash]*big.Int{
+		common.BigToHash(big.NewInt(12)): big.NewInt(123),
+	}
+	blockTime := func(block uint64) (uint64, error) {
+		return 1000 + block, nil
+	}
+	var lookups []uint64
+	restakedBalance := func(pubkeyHash [32]byte, block uint64) (uint64, bool) {
+		lookups = append(lookups, block)
+		if pubkeyHash == b {
+			return 32_000_000_000, true
+		}
+		return 0, false
+	}
+
+	ledger, err := buildCheckpointLedger(p.events, slashingFactors, totalShares, blockTime, restakedBalance)
+	require.NoError(t, err)
+	require.Len(t, ledger, 3)
+	// as of the block before each checkpoint's first proof
+	assert.Equal(t, []uint64{10, 30}, lookups)
+
+	first := ledger[0]
+	assert.Equal(t, uint64(100), first.CheckpointTimestamp)
+	assert.Equal(t, uint64(2), first.ValidatorCount)
+	assert.Equal(t, uint64(10), first.StartedBlock)
+	assert.Equal(t, uint64(12), first.FinalizedBlock)
+	assert.Equal(t, uint64(1012), first.FinalizedAt)
+	assert.Equal(t, big.NewInt(123), first.NewTotalSharesWei)
+	assert.Nil(t, first.SlashingFactor)
+	require.Len(t, first.Validators, 2)
+	assert.Equal(t, uint64(32_000_000_000), *first.Validators[0].PreviousBalanceGwei)
+	assert.Equal(t, int64(100_000_000), first.Validators[0].DeltaGwei.Int64())
+	assert.Equal(t, uint64(32_000_000_000), *first.Validators[1].PreviousBalanceGwei)
+	assert.Equal(t, int64(-1_000_000_000), first.Validators[1].DeltaGwei.Int64())
+	assert.Equal(t, int64(-900_000_000), first.ValidatorBalanceDeltaGwei.Int64())
+	assert.Equal(t, int64(2_000_000_000), first.PodBalanceDeltaGwei.Int64())
+
+	second := ledger[1]
+	assert.Equal(t, &SlashingFactorChange{Previous: 1e18, New: 9e17}, second.SlashingFactor)
+	require.Len(t, second.Validators, 2)
+	assert.True(t, second.Validators[0].Withdrawn)
+	assert.Equal(t, int64(-32_100_000_000), second.Validators[0].DeltaGwei.Int64())
+	assert.False(t, second.Validators[1].Withdrawn)
+	assert.Equal(t, int64(0), second.Validators[1].DeltaGwei.Int64())
+	assert.Equal(t, int64(32_150_000_000), second.PodBalanceDeltaGwei.Int64())
+
+	third := ledger[2]
+	require.Len(t, third.Validators, 2)
+	assert.Nil(t, third.Validators[1].PreviousBalanceGwei)
+	assert.Nil(t, third.ValidatorBalanceDeltaGwei)
+	assert.Nil(t, third.PodBalanceDeltaGwei)
+
+	// TotalShareDeltaWei = (ValidatorBalanceDeltaGwei + PodBalanceDeltaGwei) * 1 gwei
+	for _, entry := range ledger {
+		if entry.ValidatorBalanceDeltaGwei == nil {
+			continue
+		}
+		sumGwei := new(big.Int).Add(entry.ValidatorBalanceDeltaGwei, entry.PodBalanceDeltaGwei)
+		assert.Equal(t, 0, new(big.Int).Mul(sumGwei, big.NewInt(1e9)).Cmp(entry.TotalShareDeltaWei), "checkpoint %d", entry.CheckpointTimestamp)
+
+		validatorsGwei := big.NewInt(0)
+		for _, v := range entry.Validators {
+			validatorsGwei.Add(validatorsGwei, v.DeltaGwei)
+		}
+		assert.Equal(t, 0, validatorsGwei.Cmp(entry.ValidatorBalanceDeltaGwei), "checkpoint %d", entry.CheckpointTimestamp)
+	}
+
+	_, err = buildCheckpointLedger(p.events, slashingFactors, totalShares, func(uint64) (uint64, error) {
+		return 0, errors.New("unavailable")
+	}, restakedBalance)
+	assert.Error(t, err)
+}
+
+func TestBuildCheckpointLedgerMalformedEvents(t *testing.T) {
+	blockTime := func(block uint64) (uint64, error) { return block, nil }
+	restakedBalance := func([32]byte, uint64) (uint64, bool) { return 0, false }
+
+	p := &podEvents{}
+	p.created(10, 100, 1)
+	p.at(11, "ValidatorBalanceUpdated", arg("pubkeyHash", [32]byte{0xa}), arg("balanceTimestamp", uint64(100)), arg("newValidatorBalanceGwei", big.NewInt(1)))
+	p.finalized(12, 100, 0)
+	_, err := buildCheckpointLedger(p.events, nil, nil, blockTime, restakedBalance)
+	assert.ErrorContains(t, err, "ValidatorBalanceUpdated event has no valid newValidatorBalanceGwei")
+
+	p = &podEvents{}
+	p.created(10, 100, 1)
+	p.at(12, "CheckpointFinalized", arg("checkpointTimestamp", uint64(100)))
+	_, err = buildCheckpointLedger(p.events, nil, nil, blockTime, restakedBalance)
+	assert.ErrorContains(t, err, "CheckpointFinalized event has no valid totalShareDeltaWei")
+}
+
+func TestBuildCheckpointLedgerLegacyEvents(t *testing.T) {
+	blockTime := func(block uint64) (uint64, error) { return block, nil }
+	var lookups int
+	restakedBalance := func([32]byte, uint64) (uint64, bool) {
+		lookups++
+		return 0, false
+	}
+	// before the slashing upgrade, validators were identified by index, as a uint40
+	provedLegacy := func(p *podEvents, block, timestamp, index, balanceGwei uint64) {
+		validatorIndex := new(big.Int).SetUint64(index)
+		p.at(block, "ValidatorCheckpointed", arg("checkpointTimestamp", timestamp), arg("validatorIndex", validatorIndex))
+		p.at(block, "ValidatorBalanceUpdated", arg("validatorIndex", validatorIndex), arg("balanceTimestamp", timestamp), arg("newValidatorBalanceGwei", balanceGwei))
+	}
+
+	p := &podEvents{}
+	p.at(5, "ValidatorBalanceUpdated", arg("validatorIndex", big.NewInt(7)), arg("balanceTimestamp", uint64(50)), arg("newValidatorBalanceGwei", uint64(32_000_000_000)))
+
+	p.created(10, 100, 1)
+	provedLegacy(p, 11, 100, 7, 32_100_000_000)
+	p.finalized(12, 100, 100_000_000)
+
+	// 8's previous balance is unknown, and the pod can't be asked for it by index
+	p.created(20, 200, 2)
+	provedLegacy(p, 21, 200, 7, 32_100_000_000)
+	provedLegacy(p, 21, 200, 8, 32_000_000_000)
+	p.finalized(22, 200, 0)
+
+	// a log that doesn't decode may have been some validator's proof
+	p.created(30, 300, 2)
+	provedLegacy(p, 31, 300, 7, 32_200_000_000)
+	p.events = append(p.events, loggedEvent{undecodable: true, block: 31})
+	p.finalized(32, 300, 100_000_000)
+
+	ledger, err := buildCheckpointLedger(p.events, nil, nil, blockTime, restakedBalance)
+	require.NoError(t, err)
+	require.Len(t, ledger, 3)
+	assert.Zero(t, lookups)
+
+	first := ledger[0]
+	require.Len(t, first.Validators, 1)
+	assert.Empty(t, first.Validators[0].PubkeyHash)
+	assert.Equal(t, uint64(7), *first.Validators[0].ValidatorIndex)
+	assert.Equal(t, uint64(32_000_000_000), *first.Validators[0].PreviousBalanceGwei)
+	assert.Equal(t, int64(100_000_000), first.ValidatorBalanceDeltaGwei.Int64())
+	assert.Equal(t, int64(0), first.PodBalanceDeltaGwei.Int64())
+	assert.False(t, first.Incomplete)
+
+	second := ledger[1]
+	require.Len(t, second.Validators, 2)
+	assert.Equal(t, int64(0), second.Validators[0].DeltaGwei.Int64())
+	assert.Nil(t, second.Validators[1].PreviousBalanceGwei)
+	assert.Nil(t, second.ValidatorBalanceDeltaGwei)
+	assert.Nil(t, second.PodBalanceDeltaGwei)
+
+	third := ledger[2]
+	assert.True(t, third.Incomplete)
+	require.Len(t, third.Validators, 1)
+	assert.Equal(t, int64(100_000_000), third.Validators[0].DeltaGwei.Int64())
+	assert.Nil(t, third.ValidatorBalanceDeltaGwei)
+	assert.Nil(t, third.PodBalanceDeltaGwei)
+}
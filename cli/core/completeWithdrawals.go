@@ -0,0 +1,171 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/IDelegationManager"
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/IStrategy"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type QueuedWithdrawal struct {
+	Root       common.Hash
+	Withdrawal IDelegationManager.IDelegationManagerTypesWithdrawal
+
+	// withdrawable shares per strategy, after any slashing
+	Shares []*big.Int
+
+	// shares in the native ETH strategy (wei). Completing as tokens pays these out of the pod's
+	// withdrawableRestakedExecutionLayerGwei.
+	BeaconSharesWei *big.Int
+
+	// the first block the withdrawal can be completed in
+	CompletableAtBlock uint64
+}
+
+func (w QueuedWithdrawal) IsCompletable(curBlock uint64) bool {
+	return curBlock >= w.CompletableAtBlock
+}
+
+// GetQueuedWithdrawals returns every withdrawal queued by `staker`, with its root.
+func GetQueuedWithdrawals(dm *IDelegationManager.IDelegationManager, staker common.Address) ([]QueuedWithdrawal, error) {
+	minDelay, err := dm.MinWithdrawalDelayBlocks(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MinWithdrawalDelayBlocks: %w", err)
+	}
+
+	queued, err := dm.GetQueuedWithdrawals(nil, staker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queued withdrawals: %w", err)
+	}
+	onchainRoots, err := dm.GetQueuedWithdrawalRoots(nil, staker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queued withdrawal roots: %w", err)
+	}
+	isQueued := map[common.Hash]bool{}
+	for _, root := range onchainRoots {
+		isQueued[root] = true
+	}
+
+	// Compute each root ourselves, and check it against the DelegationManager's set of queued roots
+	roots := make([]common.Hash, len(queued.Withdrawals))
+	for i, withdrawal := range queued.Withdrawals {
+		roots[i], err = CalculateWithdrawalRoot(withdrawal)
+		if err != nil {
+			return nil, err
+		}
+		if !isQueued[roots[i]] {
+			return nil, fmt.Errorf("computed withdrawal root %s is not queued in the DelegationManager", roots[i])
+		}
+	}
+
+	out := make([]QueuedWithdrawal, len(roots))
+	for i, withdrawal := range queued.Withdrawals {
+		beaconShares := big.NewInt(0)
+		for j, strategy := range withdrawal.Strategies {
+			if strategy == BeaconStrategy() {
+				beaconShares.Add(beaconShares, queued.Shares[i][j])
+			}
+		}
+
+		out[i] = QueuedWithdrawal{
+			Root:               roots[i],
+			Withdrawal:         withdrawal,
+			Shares:             queued.Shares[i],
+			BeaconSharesWei:    beaconShares,
+			CompletableAtBlock: uint64(withdrawal.StartBlock) + uint64(minDelay) + 1,
+		}
+	}
+	return out, nil
+}
+
+// WithdrawalTokens returns the tokens `completeQueuedWithdrawals` pays `withdrawal` out in: each
+// strategy's underlying token, or the zero address for native ETH.
+func WithdrawalTokens(eth bind.ContractCaller, withdrawal IDelegationManager.IDelegationManagerTypesWithdrawal) ([]common.Address, error) {
+	tokens := make([]common.Address, len(withdrawal.Strategies))
+	for i, strategy := range withdrawal.Strategies {
+		if strategy == BeaconStrategy() {
+			continue
+		}
+
+		s, err := IStrategy.NewIStrategyCaller(strategy, eth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reach strategy %s: %w", strategy, err)
+		}
+		tokens[i], err = s.UnderlyingToken(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read underlying token of strategy %s: %w", strategy, err)
+		}
+	}
+	return tokens, nil
+}
+
+// Bounds the branch-and-bound search in PackWithdrawals; past this, the best packing found so far is used.
+const maxPackingNodes = 1 << 20
+
+// PackWithdrawals chooses the subset of `amounts` with the largest sum not exceeding `capacity`, and
+// returns the chosen indices in ascending order. This is a 0/1 subset-sum, solved by branch-and-bound
+// over the amounts in descending order, starting from a first-fit-decreasing packing.
+func PackWithdrawals(amounts []*big.Int, capacity *big.Int) []int {
+	order := make([]int, len(amounts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return amounts[order[a]].Cmp(amounts[order[b]]) > 0
+	})
+
+	// suffix[i] = sum of amounts[order[i:]]
+	suffix := make([]*big.Int, len(order)+1)
+	suffix[len(order)] = big.NewInt(0)
+	for i := len(order) - 1; i >= 0; i-- {
+		suffix[i] = new(big.Int).Add(suffix[i+1], amounts[order[i]])
+	}
+
+	// first-fit decreasing as the initial best
+	best := big.NewInt(0)
+	bestSet := []int{}
+	for _, i := range order {
+		if next := new(big.Int).Add(best, amounts[i]); next.Cmp(capacity) <= 0 {
+			best = next
+			bestSet = append(bestSet, i)
+		}
+	}
+
+	nodes := 0
+	chosen := []int{}
+	var search func(pos int, sum *big.Int)
+	search = func(pos int, sum *big.Int) {
+		nodes++
+		if nodes > maxPackingNodes || best.Cmp(capacity) == 0 {
+			return
+		}
+
+		// even taking everything that's left can't beat the best
+		upper := new(big.Int).Add(sum, suffix[pos])
+		if upper.Cmp(best) <= 0 {
+			return
+		}
+		// everything that's left fits
+		if upper.Cmp(capacity) <= 0 {
+			best = upper
+			bestSet = append(append([]int{}, chosen...), order[pos:]...)
+			return
+		}
+
+		i := order[pos]
+		if with := new(big.Int).Add(sum, amounts[i]); with.Cmp(capacity) <= 0 {
+			chosen = append(chosen, i)
+			search(pos+1, with)
+			chosen = chosen[:len(chosen)-1]
+		}
+		search(pos+1, sum)
+	}
+	search(0, big.NewInt(0))
+
+	sort.Ints(bestSet)
+	return bestSet
+}
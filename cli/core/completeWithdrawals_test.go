@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/IDelegationManager"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bigs(values ...int64) []*big.Int {
+	out := make([]*big.Int, len(values))
+	for i, v := range values {
+		out[i] = big.NewInt(v)
+	}
+	return out
+}
+
+func TestPackWithdrawals(t *testing.T) {
+	tests := []struct {
+		name     string
+		amounts  []*big.Int
+		capacity int64
+		want     []int
+	}{
+		{name: "no withdrawals", amounts: bigs(), capacity: 10, want: []int{}},
+		{name: "everything fits", amounts: bigs(3, 1, 2), capacity: 10, want: []int{0, 1, 2}},
+		{name: "exactly fits", amounts: bigs(4, 6), capacity: 10, want: []int{0, 1}},
+		{name: "nothing fits", amounts: bigs(11, 12), capacity: 10, want: []int{}},
+		{name: "zero capacity", amounts: bigs(1), capacity: 0, want: []int{}},
+		{name: "largest alone", amounts: bigs(2, 9, 3), capacity: 10, want: []int{1}},
+		// first-fit decreasing takes 6 and stops at 6; 5 + 5 fills the capacity
+		{name: "better than first-fit decreasing", amounts: bigs(5, 6, 5), capacity: 10, want: []int{0, 2}},
+		{name: "skips what doesn't fit", amounts: bigs(8, 20, 7, 3), capacity: 15, want: []int{0, 2}},
+		{name: "zero amounts are free", amounts: bigs(0, 10, 0), capacity: 10, want: []int{0, 1, 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := PackWithdrawals(test.amounts, big.NewInt(test.capacity))
+			assert.Equal(t, test.want, got)
+
+			sum := big.NewInt(0)
+			for _, i := range got {
+				sum.Add(sum, test.amounts[i])
+			}
+			assert.LessOrEqual(t, sum.Cmp(big.NewInt(test.capacity)), 0)
+		})
+	}
+}
+
+// strategiesCaller answers `underlyingToken()` for the strategies in `tokens`, and fails for others.
+type strategiesCaller struct {
+	tokens map[common.Address]common.Address
+}
+
+func (c *strategiesCaller) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (c *strategiesCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	token, ok := c.tokens[*call.To]
+	if !ok {
+		return nil, errors.New("execution reverted")
+	}
+	return common.LeftPadBytes(token.Bytes(), 32), nil
+}
+
+func TestWithdrawalTokens(t *testing.T) {
+	steth := common.HexToAddress("0x01")
+	stethStrategy := common.HexToAddress("0x11")
+	reth := common.HexToAddress("0x02")
+	rethStrategy := common.HexToAddress("0x12")
+	caller := &strategiesCaller{tokens: map[common.Address]common.Address{stethStrategy: steth, rethStrategy: reth}}
+
+	tokens, err := WithdrawalTokens(caller, IDelegationManager.IDelegationManagerTypesWithdrawal{
+		Strategies: []common.Address{rethStrategy, BeaconStrategy(), stethStrategy},
+	})
+	require.NoError(t, err)
+	// native ETH is paid out as the zero address
+	assert.Equal(t, []common.Address{reth, {}, steth}, tokens)
+
+	tokens, err = WithdrawalTokens(caller, IDelegationManager.IDelegationManagerTypesWithdrawal{})
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	_, err = WithdrawalTokens(caller, IDelegationManager.IDelegationManagerTypesWithdrawal{
+		Strategies: []common.Address{BeaconStrategy(), common.HexToAddress("0x13")},
+	})
+	assert.ErrorContains(t, err, "failed to read underlying token")
+}
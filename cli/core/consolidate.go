@@ -11,6 +11,7 @@ import (
 
 	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -62,21 +63,13 @@ func LoadConsolidationRequestFromFile(path string) ([]EigenPod.IEigenPodTypesCon
 //     fee multiplied by len(requests).
 func SubmitConsolidationRequests(
 	ctx context.Context,
-	owner, eigenpodAddress string,
-	chainId *big.Int,
+	txMgr *utils.TxManager,
+	eigenpodAddress string,
 	eth *ethclient.Client,
 	requests []EigenPod.IEigenPodTypesConsolidationRequest,
 	predeployFee *big.Int,
-	noSend bool,
 	verbose bool,
 ) (*types.Transaction, error) {
-	ownerAccount, err := utils.PrepareAccount(&owner, chainId, noSend)
-	if err != nil {
-		return nil, err
-	}
-	utils.PanicOnError("failed to parse private key", err)
-	ownerAccount.TransactionOptions.Value = predeployFee
-
 	eigenPod, err := EigenPod.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
 	if err != nil {
 		return nil, err
@@ -84,7 +77,7 @@ func SubmitConsolidationRequests(
 
 	if verbose {
 		color.Green("calling EigenPod.requestConsolidation()... [%s]", func() string {
-			if ownerAccount.TransactionOptions.NoSend {
+			if txMgr.IsDryRun() {
 				return "simulated"
 			} else {
 				return "live"
@@ -92,10 +85,10 @@ func SubmitConsolidationRequests(
 		}())
 	}
 
-	return eigenPod.RequestConsolidation(
-		ownerAccount.TransactionOptions,
-		requests,
-	)
+	return txMgr.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		opts.Value = predeployFee
+		return eigenPod.RequestConsolidation(opts, requests)
+	})
 }
 
 func hexStringToBytes(s string) ([]byte, error) {
@@ -0,0 +1,220 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	lo "github.com/samber/lo"
+)
+
+type PlannedValidator struct {
+	Index            uint64
+	PublicKey        string
+	EffectiveBalance uint64 // gwei
+}
+
+type PlannedTarget struct {
+	PlannedValidator
+
+	// whether the target needs a switch request (0x01 -> 0x02) before it can receive consolidations
+	NeedsSwitch bool
+
+	Sources []PlannedValidator
+
+	// effective balance of the target once all sources are consolidated into it (gwei)
+	ResultingBalance uint64
+}
+
+type SkippedValidator struct {
+	Index  uint64
+	Reason string
+}
+
+type ConsolidationPlan struct {
+	Targets []*PlannedTarget
+	Skipped []SkippedValidator
+
+	// the requests, in submission order: switch requests first, so that every target is compounding
+	// by the time the beacon chain processes consolidations into it.
+	Requests []EigenPod.IEigenPodTypesConsolidationRequest
+
+	FeePerChunk []*utils.PredeployFeeInfo
+	TotalFee    *big.Int
+}
+
+// PlanConsolidations computes a consolidation plan for all of a pod's validators in `state`.
+//
+// 0x02 validators are used as targets, and 0x01 validators are packed into them (largest first)
+// without exceeding MAX_EFFECTIVE_BALANCE_ELECTRA. When a source doesn't fit into any target, it is
+// switched to 0x02 and becomes a target itself.
+//
+// Validators are skipped if they are slashed, exiting, not ACTIVE in the pod, or (for sources) have not
+// been active for SHARD_COMMITTEE_PERIOD.
+func PlanConsolidations(
+	ctx context.Context,
+	eth *ethclient.Client,
+	eigenpodAddress string,
+	state *spec.VersionedBeaconState,
+	batchSize uint64,
+) (*ConsolidationPlan, error) {
+	epoch, err := utils.CurrentEpoch(state)
+	if err != nil {
+		return nil, err
+	}
+
+	podValidators, err := utils.FindAllValidatorsForEigenpod(eigenpodAddress, state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find validators for eigenpod: %w", err)
+	}
+
+	onchainInfo, err := utils.FetchMultipleOnchainValidatorInfo(ctx, eth, eigenpodAddress, podValidators)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch onchain validator info: %w", err)
+	}
+
+	plan := &ConsolidationPlan{
+		Targets:  []*PlannedTarget{},
+		Skipped:  []SkippedValidator{},
+		Requests: []EigenPod.IEigenPodTypesConsolidationRequest{},
+		TotalFee: big.NewInt(0),
+	}
+
+	sources := []PlannedValidator{}
+	for _, v := range onchainInfo {
+		planned := PlannedValidator{
+			Index:            v.Index,
+			PublicKey:        common.Bytes2Hex(v.Validator.PublicKey[:]),
+			EffectiveBalance: uint64(v.Validator.EffectiveBalance),
+		}
+		isCompounding := v.Validator.WithdrawalCredentials[0] == utils.CompoundingWithdrawalPrefix
+
+		skipReason := func() string {
+			switch {
+			case v.Info.Status != utils.ValidatorStatusActive:
+				return "not ACTIVE in the pod (verify its withdrawal credentials first)"
+			case v.Validator.Slashed:
+				return "slashed"
+			case utils.IsExitingValidator(v.Validator):
+				return "exiting"
+			case !utils.IsActiveValidator(v.Validator, epoch):
+				return "not active on the beacon chain"
+			case !isCompounding && !utils.HasBeenActiveLongEnoughToExit(v.Validator, epoch):
+				return fmt.Sprintf("too young (must be active for %d epochs before it can be consolidated)", utils.SHARD_COMMITTEE_PERIOD)
+			}
+			return ""
+		}()
+		if skipReason != "" {
+			plan.Skipped = append(plan.Skipped, SkippedValidator{Index: v.Index, Reason: skipReason})
+			continue
+		}
+
+		if isCompounding {
+			plan.Targets = append(plan.Targets, &PlannedTarget{
+				PlannedValidator: planned,
+				Sources:          []PlannedValidator{},
+				ResultingBalance: planned.EffectiveBalance,
+			})
+		} else {
+			sources = append(sources, planned)
+		}
+	}
+
+	packSources(plan, sources)
+
+	// A target that was only switched, and receives no sources, gains nothing.
+	plan.Targets = lo.Filter(plan.Targets, func(target *PlannedTarget, _ int) bool {
+		if target.NeedsSwitch && len(target.Sources) == 0 {
+			plan.Skipped = append(plan.Skipped, SkippedValidator{Index: target.Index, Reason: "no other validator fits alongside it"})
+			return false
+		}
+		return len(target.Sources) > 0
+	})
+
+	for _, target := range plan.Targets {
+		if target.NeedsSwitch {
+			pubkey := common.FromHex(target.PublicKey)
+			plan.Requests = append(plan.Requests, EigenPod.IEigenPodTypesConsolidationRequest{
+				SrcPubkey:    pubkey,
+				TargetPubkey: pubkey,
+			})
+		}
+	}
+	for _, target := range plan.Targets {
+		for _, source := range target.Sources {
+			plan.Requests = append(plan.Requests, EigenPod.IEigenPodTypesConsolidationRequest{
+				SrcPubkey:    common.FromHex(source.PublicKey),
+				TargetPubkey: common.FromHex(target.PublicKey),
+			})
+		}
+	}
+
+	sort.Slice(plan.Skipped, func(i, j int) bool {
+		return plan.Skipped[i].Index < plan.Skipped[j].Index
+	})
+
+	if len(plan.Requests) > 0 {
+		plan.FeePerChunk, err = utils.EstimateConsolidationFeePerChunk(eth, utils.Chunk(plan.Requests, batchSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate consolidation fees: %w", err)
+		}
+		for _, chunk := range plan.FeePerChunk {
+			plan.TotalFee.Add(plan.TotalFee, chunk.TotalFee)
+		}
+	}
+
+	return plan, nil
+}
+
+// packSources assigns each source to the first target it fits in (first-fit decreasing), promoting
+// sources to targets when nothing has room.
+func packSources(plan *ConsolidationPlan, sources []PlannedValidator) {
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].EffectiveBalance > sources[j].EffectiveBalance
+	})
+
+	// fill the fullest targets first, so as few targets as possible are touched.
+	sort.SliceStable(plan.Targets, func(i, j int) bool {
+		return plan.Targets[i].EffectiveBalance > plan.Targets[j].EffectiveBalance
+	})
+
+	for _, source := range sources {
+		placed := false
+		for _, target := range plan.Targets {
+			if target.ResultingBalance+source.EffectiveBalance <= utils.MAX_EFFECTIVE_BALANCE_ELECTRA {
+				target.Sources = append(target.Sources, source)
+				target.ResultingBalance += source.EffectiveBalance
+				placed = true
+				break
+			}
+		}
+
+		if !placed {
+			plan.Targets = append(plan.Targets, &PlannedTarget{
+				PlannedValidator: source,
+				NeedsSwitch:      true,
+				Sources:          []PlannedValidator{},
+				ResultingBalance: source.EffectiveBalance,
+			})
+		}
+	}
+}
+
+// MarshalConsolidationRequests serializes `requests` in the format read by LoadConsolidationRequestFromFile.
+func MarshalConsolidationRequests(requests []EigenPod.IEigenPodTypesConsolidationRequest) ([]byte, error) {
+	out := make([]consolidationRequestJSON, len(requests))
+	for i, request := range requests {
+		out[i] = consolidationRequestJSON{
+			SrcPubkey:    "0x" + common.Bytes2Hex(request.SrcPubkey),
+			TargetPubkey: "0x" + common.Bytes2Hex(request.TargetPubkey),
+		}
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackSources(t *testing.T) {
+	validator := func(index uint64, balanceEth uint64) PlannedValidator {
+		return PlannedValidator{Index: index, EffectiveBalance: balanceEth * ethGwei}
+	}
+	type packedTarget struct {
+		index       uint64
+		needsSwitch bool
+		sources     []uint64
+		balanceEth  uint64
+	}
+
+	tests := []struct {
+		name    string
+		targets []PlannedValidator
+		sources []PlannedValidator
+		want    []packedTarget
+	}{
+		{
+			name:    "no sources",
+			targets: []PlannedValidator{validator(10, 32)},
+			want:    []packedTarget{{index: 10, sources: []uint64{}, balanceEth: 32}},
+		},
+		{
+			name:    "no targets",
+			sources: []PlannedValidator{validator(0, 32), validator(1, 32), validator(2, 32)},
+			want:    []packedTarget{{index: 0, needsSwitch: true, sources: []uint64{1, 2}, balanceEth: 96}},
+		},
+		{
+			name:    "fullest target first",
+			targets: []PlannedValidator{validator(10, 100), validator(11, 1000)},
+			sources: []PlannedValidator{validator(0, 32)},
+			want: []packedTarget{
+				{index: 11, sources: []uint64{0}, balanceEth: 1032},
+				{index: 10, sources: []uint64{}, balanceEth: 100},
+			},
+		},
+		{
+			name:    "up to the maximum effective balance",
+			targets: []PlannedValidator{validator(10, 1984)},
+			sources: []PlannedValidator{validator(0, 32), validator(1, 32), validator(2, 32)},
+			want: []packedTarget{
+				{index: 10, sources: []uint64{0, 1}, balanceEth: 2048},
+				{index: 2, needsSwitch: true, sources: []uint64{}, balanceEth: 32},
+			},
+		},
+		{
+			name:    "largest sources first",
+			targets: []PlannedValidator{validator(10, 1900)},
+			sources: []PlannedValidator{validator(0, 32), validator(1, 100), validator(2, 64)},
+			want: []packedTarget{
+				{index: 10, sources: []uint64{1, 0}, balanceEth: 2032},
+				{index: 2, needsSwitch: true, sources: []uint64{}, balanceEth: 64},
+			},
+		},
+		{
+			name:    "leftovers become targets",
+			targets: []PlannedValidator{validator(10, 2048)},
+			sources: []PlannedValidator{validator(0, 1000), validator(1, 1000), validator(2, 100), validator(3, 48)},
+			want: []packedTarget{
+				{index: 10, sources: []uint64{}, balanceEth: 2048},
+				{index: 0, needsSwitch: true, sources: []uint64{1, 3}, balanceEth: 2048},
+				{index: 2, needsSwitch: true, sources: []uint64{}, balanceEth: 100},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan := &ConsolidationPlan{Targets: []*PlannedTarget{}}
+			for _, target := range test.targets {
+				plan.Targets = append(plan.Targets, &PlannedTarget{
+					PlannedValidator: target,
+					Sources:          []PlannedValidator{},
+					ResultingBalance: target.EffectiveBalance,
+				})
+			}
+			packSources(plan, test.sources)
+
+			got := []packedTarget{}
+			for _, target := range plan.Targets {
+				packed := packedTarget{index: target.Index, needsSwitch: target.NeedsSwitch, sources: []uint64{}, balanceEth: target.ResultingBalance / ethGwei}
+				sum := target.EffectiveBalance
+				for _, source := range target.Sources {
+					packed.sources = append(packed.sources, source.Index)
+					sum += source.EffectiveBalance
+				}
+				assert.Equal(t, sum, target.ResultingBalance, "target #%d", target.Index)
+				got = append(got, packed)
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
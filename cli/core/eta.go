@@ -0,0 +1,269 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+type ValidatorETA struct {
+	Index uint64
+
+	ExitEpoch         uint64
+	WithdrawableEpoch uint64
+	// the first slot at/after WithdrawableEpoch where the withdrawals sweep is expected to reach the validator
+	SweepSlot uint64
+
+	ExitTime         time.Time
+	WithdrawableTime time.Time
+	SweepTime        time.Time
+}
+
+// ETAEstimator estimates when exits and consolidations complete, by replaying the Electra churn
+// functions (`compute_exit_epoch_and_update_churn`, `compute_consolidation_epoch_and_update_churn`)
+// against a beacon state.
+//
+// Each Estimate* call consumes churn like the beacon chain would, so calling it for several
+// validators models those requests being processed in that order. Requests from other stakers
+// that arrive in the meantime will push these estimates back.
+type ETAEstimator struct {
+	epoch       uint64
+	slot        uint64
+	genesisTime uint64
+
+	exitChurn            uint64
+	exitBalanceToConsume uint64
+	earliestExitEpoch    uint64
+
+	consolidationChurn            uint64
+	consolidationBalanceToConsume uint64
+	earliestConsolidationEpoch    uint64
+
+	validators []*phase0.Validator
+
+	// sweep model
+	nextWithdrawalValidatorIndex uint64
+	// sweepable[i] = number of sweepable validators with index < i
+	sweepable []uint64
+}
+
+func NewETAEstimator(state *spec.VersionedBeaconState) (*ETAEstimator, error) {
+	slot, err := state.Slot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read slot from beacon state: %w", err)
+	}
+	genesisTime, err := beacon.GetGenesisTime(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read genesis time from beacon state: %w", err)
+	}
+	validators, err := state.Validators()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validators from beacon state: %w", err)
+	}
+	balances, err := state.ValidatorBalances()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validator balances from beacon state: %w", err)
+	}
+	totalActiveBalance, err := utils.GetTotalActiveBalance(state)
+	if err != nil {
+		return nil, err
+	}
+
+	exitBalanceToConsume, err := state.ExitBalanceToConsume()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exit_balance_to_consume from beacon state: %w", err)
+	}
+	earliestExitEpoch, err := state.EarliestExitEpoch()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read earliest_exit_epoch from beacon state: %w", err)
+	}
+	consolidationBalanceToConsume, err := state.ConsolidationBalanceToConsume()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read consolidation_balance_to_consume from beacon state: %w", err)
+	}
+	earliestConsolidationEpoch, err := state.EarliestConsolidationEpoch()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read earliest_consolidation_epoch from beacon state: %w", err)
+	}
+	nextWithdrawalValidatorIndex, err := state.NextWithdrawalValidatorIndex()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read next_withdrawal_validator_index from beacon state: %w", err)
+	}
+
+	epoch := uint64(slot) / beacon.SLOTS_PER_EPOCH
+	sweepable := make([]uint64, len(validators)+1)
+	for i, v := range validators {
+		sweepable[i+1] = sweepable[i]
+		if utils.IsSweepable(v, uint64(balances[i]), epoch) {
+			sweepable[i+1]++
+		}
+	}
+
+	return &ETAEstimator{
+		epoch:       epoch,
+		slot:        uint64(slot),
+		genesisTime: genesisTime,
+
+		exitChurn:            utils.GetActivationExitChurnLimit(totalActiveBalance),
+		exitBalanceToConsume: uint64(exitBalanceToConsume),
+		earliestExitEpoch:    uint64(earliestExitEpoch),
+
+		consolidationChurn:            utils.GetConsolidationChurnLimit(totalActiveBalance),
+		consolidationBalanceToConsume: uint64(consolidationBalanceToConsume),
+		earliestConsolidationEpoch:    uint64(earliestConsolidationEpoch),
+
+		validators:                   validators,
+		nextWithdrawalValidatorIndex: uint64(nextWithdrawalValidatorIndex),
+		sweepable:                    sweepable,
+	}, nil
+}
+
+// consumeChurn implements the body shared by `compute_exit_epoch_and_update_churn` and
+// `compute_consolidation_epoch_and_update_churn`.
+func (e *ETAEstimator) consumeChurn(balance uint64, perEpochChurn uint64, balanceToConsume *uint64, earliestEpoch *uint64) uint64 {
+	epoch := max(*earliestEpoch, utils.ComputeActivationExitEpoch(e.epoch))
+
+	toConsume := *balanceToConsume
+	if *earliestEpoch < epoch {
+		toConsume = perEpochChurn
+	}
+
+	if balance > toConsume {
+		additionalEpochs := (balance-toConsume-1)/perEpochChurn + 1
+		epoch += additionalEpochs
+		toConsume += additionalEpochs * perEpochChurn
+	}
+
+	*balanceToConsume = toConsume - balance
+	*earliestEpoch = epoch
+	return epoch
+}
+
+// EstimateExit estimates when a full exit of `index`, requested now, completes.
+func (e *ETAEstimator) EstimateExit(index uint64) ValidatorETA {
+	exitEpoch := e.consumeChurn(uint64(e.validators[index].EffectiveBalance), e.exitChurn, &e.exitBalanceToConsume, &e.earliestExitEpoch)
+	return e.eta(index, exitEpoch)
+}
+
+// EstimateConsolidation estimates when a consolidation away from `source`, requested now, completes.
+// The source's balance moves to the target at WithdrawableEpoch; SweepSlot is when the sweep
+// would withdraw anything left over.
+func (e *ETAEstimator) EstimateConsolidation(source uint64) ValidatorETA {
+	if e.consolidationChurn <= utils.MIN_ACTIVATION_BALANCE {
+		return ValidatorETA{Index: source, ExitEpoch: utils.FAR_FUTURE_EPOCH, WithdrawableEpoch: utils.FAR_FUTURE_EPOCH, SweepSlot: utils.FAR_FUTURE_EPOCH}
+	}
+	exitEpoch := e.consumeChurn(uint64(e.validators[source].EffectiveBalance), e.consolidationChurn, &e.consolidationBalanceToConsume, &e.earliestConsolidationEpoch)
+	return e.eta(source, exitEpoch)
+}
+
+type QueueETA struct {
+	// the exit epoch a full exit requested now would be assigned
+	ExitEpoch uint64
+	ExitTime  time.Time
+
+	// the exit epoch a consolidation source requested now would be assigned. FAR_FUTURE_EPOCH if the beacon
+	// chain currently has no consolidation churn.
+	ConsolidationEpoch uint64
+	ConsolidationTime  time.Time
+}
+
+// Queues returns where an exit or consolidation of `balance` gwei, requested now, would land in each
+// queue. Unlike the Estimate* methods, it does not consume churn.
+func (e *ETAEstimator) Queues(balance uint64) QueueETA {
+	snapshot := *e
+	out := QueueETA{
+		ExitEpoch:          snapshot.consumeChurn(balance, snapshot.exitChurn, &snapshot.exitBalanceToConsume, &snapshot.earliestExitEpoch),
+		ConsolidationEpoch: utils.FAR_FUTURE_EPOCH,
+	}
+	if e.consolidationChurn > utils.MIN_ACTIVATION_BALANCE {
+		out.ConsolidationEpoch = snapshot.consumeChurn(balance, snapshot.consolidationChurn, &snapshot.consolidationBalanceToConsume, &snapshot.earliestConsolidationEpoch)
+	}
+	out.ExitTime = e.epochTime(out.ExitEpoch)
+	out.ConsolidationTime = e.epochTime(out.ConsolidationEpoch)
+	return out
+}
+
+// EstimateExisting estimates when a validator that is already exiting will be withdrawn.
+func (e *ETAEstimator) EstimateExisting(index uint64) ValidatorETA {
+	return e.eta(index, uint64(e.validators[index].ExitEpoch))
+}
+
+func (e *ETAEstimator) eta(index uint64, exitEpoch uint64) ValidatorETA {
+	withdrawableEpoch := exitEpoch + utils.MIN_VALIDATOR_WITHDRAWABILITY_DELAY
+	if exitEpoch == utils.FAR_FUTURE_EPOCH {
+		withdrawableEpoch = utils.FAR_FUTURE_EPOCH
+	} else if uint64(e.validators[index].ExitEpoch) == exitEpoch {
+		withdrawableEpoch = uint64(e.validators[index].WithdrawableEpoch)
+	}
+
+	eta := ValidatorETA{
+		Index:             index,
+		ExitEpoch:         exitEpoch,
+		WithdrawableEpoch: withdrawableEpoch,
+		SweepSlot:         e.SweepSlot(index, withdrawableEpoch),
+	}
+	eta.ExitTime = e.epochTime(eta.ExitEpoch)
+	eta.WithdrawableTime = e.epochTime(eta.WithdrawableEpoch)
+	eta.SweepTime = e.slotTime(eta.SweepSlot)
+	return eta
+}
+
+// sweepSlots estimates how many slots the sweep takes to advance `distance` validators from `from`.
+// Each payload withdraws from at most MAX_WITHDRAWALS_PER_PAYLOAD validators, and looks at most
+// MAX_VALIDATORS_PER_WITHDRAWALS_SWEEP validators.
+func (e *ETAEstimator) sweepSlots(from uint64, distance uint64) uint64 {
+	n := uint64(len(e.validators))
+	to := from + distance
+
+	var sweepable uint64
+	if to <= n {
+		sweepable = e.sweepable[to] - e.sweepable[from]
+	} else {
+		sweepable = e.sweepable[n] - e.sweepable[from] + e.sweepable[to-n]
+	}
+
+	return max(
+		(sweepable+utils.MAX_WITHDRAWALS_PER_PAYLOAD-1)/utils.MAX_WITHDRAWALS_PER_PAYLOAD,
+		(distance+utils.MAX_VALIDATORS_PER_WITHDRAWALS_SWEEP-1)/utils.MAX_VALIDATORS_PER_WITHDRAWALS_SWEEP,
+	)
+}
+
+// SweepSlot estimates the first slot at or after `withdrawableEpoch` where the withdrawals sweep
+// reaches `index`. This assumes the set of sweepable validators stays roughly the same, and ignores
+// the (up to 8 per payload) pending partial withdrawals processed ahead of the sweep.
+func (e *ETAEstimator) SweepSlot(index uint64, withdrawableEpoch uint64) uint64 {
+	if withdrawableEpoch == utils.FAR_FUTURE_EPOCH {
+		return utils.FAR_FUTURE_EPOCH
+	}
+
+	n := uint64(len(e.validators))
+	distance := (index + n - e.nextWithdrawalValidatorIndex%n) % n
+	slot := e.slot + e.sweepSlots(e.nextWithdrawalValidatorIndex%n, distance)
+
+	withdrawableSlot := withdrawableEpoch * beacon.SLOTS_PER_EPOCH
+	if slot >= withdrawableSlot {
+		return slot
+	}
+
+	cycle := max(1, e.sweepSlots(0, n))
+	cycles := (withdrawableSlot - slot + cycle - 1) / cycle
+	return slot + cycles*cycle
+}
+
+func (e *ETAEstimator) epochTime(epoch uint64) time.Time {
+	if epoch == utils.FAR_FUTURE_EPOCH {
+		return time.Time{}
+	}
+	return e.slotTime(epoch * beacon.SLOTS_PER_EPOCH)
+}
+
+func (e *ETAEstimator) slotTime(slot uint64) time.Time {
+	if slot == utils.FAR_FUTURE_EPOCH {
+		return time.Time{}
+	}
+	return time.Unix(int64(e.genesisTime+slot*beacon.SECONDS_PER_SLOT), 0)
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// the current epoch of the test estimators; compute_activation_exit_epoch is 105
+	etaEpoch = uint64(100)
+	// MAX_PER_EPOCH_ACTIVATION_EXIT_CHURN_LIMIT, the churn once 16M+ ETH is staked
+	exitChurnGwei = 256 * ethGwei
+)
+
+func TestConsumeChurn(t *testing.T) {
+	// expected results worked through `compute_exit_epoch_and_update_churn`
+	tests := []struct {
+		name                         string
+		earliestEpoch, toConsume     uint64
+		balance                      uint64
+		wantEpoch, wantEarliestEpoch uint64
+		wantToConsume                uint64
+	}{
+		{
+			name:          "empty queue",
+			earliestEpoch: 50, toConsume: 7 * ethGwei,
+			balance:   32 * ethGwei,
+			wantEpoch: 105, wantEarliestEpoch: 105, wantToConsume: 224 * ethGwei,
+		},
+		{
+			name:          "churn left in the earliest epoch",
+			earliestEpoch: 105, toConsume: 224 * ethGwei,
+			balance:   32 * ethGwei,
+			wantEpoch: 105, wantEarliestEpoch: 105, wantToConsume: 192 * ethGwei,
+		},
+		{
+			name:          "exactly the churn left",
+			earliestEpoch: 105, toConsume: 32 * ethGwei,
+			balance:   32 * ethGwei,
+			wantEpoch: 105, wantEarliestEpoch: 105, wantToConsume: 0,
+		},
+		{
+			name:          "spills into the next epoch",
+			earliestEpoch: 105, toConsume: 10 * ethGwei,
+			balance:   32 * ethGwei,
+			wantEpoch: 106, wantEarliestEpoch: 106, wantToConsume: 234 * ethGwei,
+		},
+		{
+			name:          "queue ahead",
+			earliestEpoch: 200, toConsume: 0,
+			balance:   2048 * ethGwei,
+			wantEpoch: 208, wantEarliestEpoch: 208, wantToConsume: 0,
+		},
+		{
+			name:          "more than an epoch of churn, empty queue",
+			earliestEpoch: 0, toConsume: 0,
+			balance:   600 * ethGwei,
+			wantEpoch: 107, wantEarliestEpoch: 107, wantToConsume: 168 * ethGwei,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := &ETAEstimator{epoch: etaEpoch}
+			earliestEpoch, toConsume := test.earliestEpoch, test.toConsume
+			epoch := e.consumeChurn(test.balance, exitChurnGwei, &toConsume, &earliestEpoch)
+			assert.Equal(t, test.wantEpoch, epoch)
+			assert.Equal(t, test.wantEarliestEpoch, earliestEpoch)
+			assert.Equal(t, test.wantToConsume, toConsume)
+		})
+	}
+}
+
+// newTestETAEstimator returns an estimator at etaEpoch for validators of `effectiveBalances`, with
+// empty queues and an exit churn of exitChurnGwei.
+func newTestETAEstimator(consolidationChurn uint64, effectiveBalances ...uint64) *ETAEstimator {
+	e := &ETAEstimator{
+		epoch:              etaEpoch,
+		slot:               etaEpoch * beacon.SLOTS_PER_EPOCH,
+		genesisTime:        1_600_000_000,
+		exitChurn:          exitChurnGwei,
+		consolidationChurn: consolidationChurn,
+		sweepable:          make([]uint64, len(effectiveBalances)+1),
+	}
+	for _, balance := range effectiveBalances {
+		e.validators = append(e.validators, &phase0.Validator{
+			EffectiveBalance:  phase0.Gwei(balance),
+			ExitEpoch:         phase0.Epoch(utils.FAR_FUTURE_EPOCH),
+			WithdrawableEpoch: phase0.Epoch(utils.FAR_FUTURE_EPOCH),
+		})
+	}
+	return e
+}
+
+func TestEstimateExitConsumesChurn(t *testing.T) {
+	e := newTestETAEstimator(0, 200*ethGwei, 100*ethGwei, 32*ethGwei)
+
+	epochs := []uint64{}
+	for i := range e.validators {
+		epochs = append(epochs, e.EstimateExit(uint64(i)).ExitEpoch)
+	}
+	// 200 fits in epoch 105, 100 spills into 106, and 32 fits in what's left of 106
+	assert.Equal(t, []uint64{105, 106, 106}, epochs)
+	assert.Equal(t, uint64(106), e.earliestExitEpoch)
+	assert.Equal(t, 2*exitChurnGwei-332*ethGwei, e.exitBalanceToConsume)
+
+	eta := e.EstimateExit(0)
+	assert.Equal(t, uint64(107), eta.ExitEpoch)
+	assert.Equal(t, 107+utils.MIN_VALIDATOR_WITHDRAWABILITY_DELAY, eta.WithdrawableEpoch)
+	assert.Equal(t, time.Unix(int64(1_600_000_000+107*beacon.SLOTS_PER_EPOCH*beacon.SECONDS_PER_SLOT), 0), eta.ExitTime)
+}
+
+func TestQueues(t *testing.T) {
+	e := newTestETAEstimator(64*ethGwei, 32*ethGwei)
+	e.earliestExitEpoch, e.exitBalanceToConsume = 110, 16*ethGwei
+
+	queues := e.Queues(32 * ethGwei)
+	assert.Equal(t, uint64(111), queues.ExitEpoch)
+	assert.Equal(t, uint64(105), queues.ConsolidationEpoch)
+	assert.Equal(t, time.Unix(int64(1_600_000_000+105*beacon.SLOTS_PER_EPOCH*beacon.SECONDS_PER_SLOT), 0), queues.ConsolidationTime)
+
+	// no churn was consumed
+	assert.Equal(t, queues, e.Queues(32*ethGwei))
+	assert.Equal(t, uint64(110), e.earliestExitEpoch)
+	assert.Equal(t, 16*ethGwei, e.exitBalanceToConsume)
+	assert.Equal(t, uint64(0), e.earliestConsolidationEpoch)
+
+	// and it agrees with EstimateExit
+	assert.Equal(t, queues.ExitEpoch, e.EstimateExit(0).ExitEpoch)
+	assert.Equal(t, queues.ConsolidationEpoch, e.EstimateConsolidation(0).ExitEpoch)
+
+	// without consolidation churn above MIN_ACTIVATION_BALANCE, consolidations are ignored
+	e = newTestETAEstimator(utils.MIN_ACTIVATION_BALANCE, 32*ethGwei)
+	queues = e.Queues(32 * ethGwei)
+	assert.Equal(t, uint64(utils.FAR_FUTURE_EPOCH), queues.ConsolidationEpoch)
+	assert.True(t, queues.ConsolidationTime.IsZero())
+	assert.Equal(t, uint64(utils.FAR_FUTURE_EPOCH), e.EstimateConsolidation(0).ExitEpoch)
+}
@@ -0,0 +1,264 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/IDelegationManager"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+type ExitPhase string
+
+const (
+	// active on the beacon chain; a full exit needs to be requested
+	ExitPhaseActive ExitPhase = "active"
+	// exit requested; waiting to become withdrawable and be swept
+	ExitPhaseExiting ExitPhase = "exiting"
+	// balance swept to the pod; a checkpoint is needed to mark the validator WITHDRAWN
+	ExitPhaseSwept ExitPhase = "swept"
+	// WITHDRAWN in the pod; its ETH is withdrawable from EigenLayer
+	ExitPhaseWithdrawn ExitPhase = "withdrawn"
+)
+
+type ExitFlowValidator struct {
+	Index uint64
+	Phase ExitPhase
+
+	// set while the validator is exiting
+	ETA *ValidatorETA `json:",omitempty"`
+}
+
+// ExitFlowState is the progress of an `exit-flow`, persisted between runs.
+type ExitFlowState struct {
+	EigenpodAddress string
+	Validators      []*ExitFlowValidator
+
+	// the block the flow was started at. The flow's EigenLayer withdrawal is the latest native ETH
+	// withdrawal the pod owner queued after it, as found onchain.
+	StartedAtBlock uint64
+	Completed      bool
+}
+
+func LoadExitFlowState(path string, eigenpodAddress string) (*ExitFlowState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &ExitFlowState{EigenpodAddress: eigenpodAddress, Validators: []*ExitFlowValidator{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exit-flow state: %w", err)
+	}
+
+	var state ExitFlowState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse exit-flow state %s: %w", path, err)
+	}
+	if !common.IsHexAddress(state.EigenpodAddress) || common.HexToAddress(state.EigenpodAddress) != common.HexToAddress(eigenpodAddress) {
+		return nil, fmt.Errorf("exit-flow state %s is for pod %s, not %s", path, state.EigenpodAddress, eigenpodAddress)
+	}
+	return &state, nil
+}
+
+func (s *ExitFlowState) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write-then-rename, so an interrupted run never leaves a truncated file behind
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write exit-flow state: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// AddValidators adds validators to the flow. Validators that are already tracked are ignored.
+func (s *ExitFlowState) AddValidators(indices []uint64) {
+	for _, index := range indices {
+		if s.Validator(index) == nil {
+			s.Validators = append(s.Validators, &ExitFlowValidator{Index: index, Phase: ExitPhaseActive})
+		}
+	}
+	sort.Slice(s.Validators, func(i, j int) bool {
+		return s.Validators[i].Index < s.Validators[j].Index
+	})
+}
+
+func (s *ExitFlowState) Validator(index uint64) *ExitFlowValidator {
+	for _, v := range s.Validators {
+		if v.Index == index {
+			return v
+		}
+	}
+	return nil
+}
+
+// InPhase returns the indices of all validators in `phase`.
+func (s *ExitFlowState) InPhase(phase ExitPhase) []uint64 {
+	out := []uint64{}
+	for _, v := range s.Validators {
+		if v.Phase == phase {
+			out = append(out, v.Index)
+		}
+	}
+	return out
+}
+
+// UpdateExitFlowPhases derives each validator's phase from the beacon state and the pod, so that
+// re-running a flow after any step (or a crash) picks up where it left off.
+func UpdateExitFlowPhases(
+	ctx context.Context,
+	eth *ethclient.Client,
+	state *spec.VersionedBeaconState,
+	flow *ExitFlowState,
+) error {
+	epoch, err := utils.CurrentEpoch(state)
+	if err != nil {
+		return err
+	}
+
+	podValidators, err := utils.GetEigenPodValidatorsByIndex(flow.EigenpodAddress, state)
+	if err != nil {
+		return fmt.Errorf("failed to fetch validators for eigenpod: %w", err)
+	}
+
+	balances, err := state.ValidatorBalances()
+	if err != nil {
+		return fmt.Errorf("failed to read validator balances from beacon state: %w", err)
+	}
+
+	tracked := []utils.ValidatorWithIndex{}
+	for _, v := range flow.Validators {
+		validator, ok := podValidators[v.Index]
+		if !ok {
+			return fmt.Errorf("validator %d is not pointed at pod %s", v.Index, flow.EigenpodAddress)
+		}
+		tracked = append(tracked, utils.ValidatorWithIndex{Validator: validator, Index: v.Index})
+	}
+
+	info, err := utils.FetchMultipleOnchainValidatorInfo(ctx, eth, flow.EigenpodAddress, tracked)
+	if err != nil {
+		return fmt.Errorf("failed to fetch onchain validator info: %w", err)
+	}
+
+	etaEstimator, err := NewETAEstimator(state)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range info {
+		fv := flow.Validator(v.Index)
+		fv.ETA = nil
+
+		switch {
+		case v.Info.Status == utils.ValidatorStatusWithdrawn:
+			fv.Phase = ExitPhaseWithdrawn
+		case !utils.IsExitingValidator(v.Validator):
+			fv.Phase = ExitPhaseActive
+		case uint64(v.Validator.WithdrawableEpoch) <= epoch && balances[v.Index] == 0:
+			fv.Phase = ExitPhaseSwept
+		default:
+			fv.Phase = ExitPhaseExiting
+			eta := etaEstimator.EstimateExisting(v.Index)
+			fv.ETA = &eta
+		}
+	}
+	return nil
+}
+
+// ExitFlowWithdrawal is the EigenLayer withdrawal of an exit flow's ETH, as found onchain.
+type ExitFlowWithdrawal struct {
+	Root common.Hash
+	// nil once the withdrawal was completed
+	Queued    *QueuedWithdrawal
+	Completed bool
+}
+
+// FindExitFlowWithdrawal returns the latest native ETH withdrawal `podOwner` queued since the flow
+// started (from its SlashingWithdrawalQueued event), and whether it has been completed (from its
+// SlashingWithdrawalCompleted event). Returns nil if no such withdrawal was queued, e.g. because
+// the queueing transaction was dropped or replaced.
+func FindExitFlowWithdrawal(
+	ctx context.Context,
+	eth *ethclient.Client,
+	delegationManager common.Address,
+	podOwner common.Address,
+	flow *ExitFlowState,
+) (*ExitFlowWithdrawal, error) {
+	dmAbi, err := IDelegationManager.IDelegationManagerMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load delegation manager abi: %w", err)
+	}
+	dm, err := IDelegationManager.NewIDelegationManager(delegationManager, eth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach delegation manager: %w", err)
+	}
+	curBlock, err := eth.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load current block number: %w", err)
+	}
+
+	// the withdrawer isn't indexed, so every withdrawal queued since the flow started is checked
+	logs, err := utils.FilterLogsChunked(ctx, eth, ethereum.FilterQuery{
+		Addresses: []common.Address{delegationManager},
+		Topics:    [][]common.Hash{{dmAbi.Events["SlashingWithdrawalQueued"].ID}},
+	}, flow.StartedAtBlock, curBlock, utils.DEFAULT_LOG_FILTER_CHUNK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch queued withdrawals: %w", err)
+	}
+
+	var found *IDelegationManager.IDelegationManagerSlashingWithdrawalQueued
+	for i := len(logs) - 1; i >= 0 && found == nil; i-- {
+		event, err := dm.ParseSlashingWithdrawalQueued(logs[i])
+		if err != nil {
+			return nil, err
+		}
+		strategies := event.Withdrawal.Strategies
+		if event.Withdrawal.Staker == podOwner && len(strategies) == 1 && strategies[0] == BeaconStrategy() {
+			found = event
+		}
+	}
+	if found == nil {
+		return nil, nil
+	}
+	withdrawal := &ExitFlowWithdrawal{Root: common.Hash(found.WithdrawalRoot)}
+
+	queued, err := GetQueuedWithdrawals(dm, podOwner)
+	if err != nil {
+		return nil, err
+	}
+	for i := range queued {
+		if queued[i].Root == withdrawal.Root {
+			withdrawal.Queued = &queued[i]
+			return withdrawal, nil
+		}
+	}
+
+	logs, err = utils.FilterLogsChunked(ctx, eth, ethereum.FilterQuery{
+		Addresses: []common.Address{delegationManager},
+		Topics:    [][]common.Hash{{dmAbi.Events["SlashingWithdrawalCompleted"].ID}},
+	}, found.Raw.BlockNumber, curBlock, utils.DEFAULT_LOG_FILTER_CHUNK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch completed withdrawals: %w", err)
+	}
+	for _, log := range logs {
+		event, err := dm.ParseSlashingWithdrawalCompleted(log)
+		if err != nil {
+			return nil, err
+		}
+		if common.Hash(event.WithdrawalRoot) == withdrawal.Root {
+			withdrawal.Completed = true
+			return withdrawal, nil
+		}
+	}
+	return nil, fmt.Errorf("withdrawal %s is neither queued nor completed", withdrawal.Root.Hex())
+}
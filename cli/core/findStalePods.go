@@ -2,10 +2,14 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/samber/lo"
@@ -31,6 +35,61 @@ func PodManagerContracts() map[uint64]string {
 	}
 }
 
+// Calls per multicall when checking withdrawal addresses for eigenpods. Small enough that the
+// multicall client doesn't split the chunk up any further.
+const MULTICALL_CHUNK_SIZE = 200
+
+// Maximum number of multicall chunks (and pod balance deviations) computed at once while scanning for
+// stale pods.
+const DEFAULT_STALE_POD_SCAN_CONCURRENCY = 8
+
+// forEachConcurrently calls `fn` with 0..n-1, from at most `concurrency` goroutines at once.
+func forEachConcurrently(n int, concurrency int, fn func(i int)) {
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for range min(concurrency, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
+
+// doManyConcurrently runs `calls` in chunks of MULTICALL_CHUNK_SIZE, several chunks at a time,
+// returning the results in the same order as `calls`.
+func doManyConcurrently[T any](mc *multicall.MulticallClient, calls []*multicall.MultiCallMetaData[T]) ([]multicall.TypedMulticall3Result[*T], error) {
+	results := make([]multicall.TypedMulticall3Result[*T], len(calls))
+	chunks := (len(calls) + MULTICALL_CHUNK_SIZE - 1) / MULTICALL_CHUNK_SIZE
+	errs := make([]error, chunks)
+
+	forEachConcurrently(chunks, DEFAULT_STALE_POD_SCAN_CONCURRENCY, func(chunk int) {
+		start := chunk * MULTICALL_CHUNK_SIZE
+		end := min(start+MULTICALL_CHUNK_SIZE, len(calls))
+
+		res, err := multicall.DoManyAllowFailures(mc, calls[start:end]...)
+		if err != nil {
+			errs[chunk] = err
+			return
+		}
+		copy(results[start:end], *res)
+	})
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
 // multiply by a fraction
 func FracMul(a *big.Int, x *big.Int, y *big.Int) *big.Int {
 	_a := new(big.Int).Mul(a, x)
@@ -81,11 +140,8 @@ func validEigenpodsOnly(candidateAddresses []common.Address, mc *multicall.Multi
 		return nil, lastError
 	}
 
-	reportedPodOwners, err := multicall.DoManyAllowFailures(
-		mc,
-		calls...,
-	)
-	if err != nil || reportedPodOwners == nil {
+	reportedPodOwners, err := doManyConcurrently(mc, calls)
+	if err != nil {
 		return nil, fmt.Errorf("failed to load podOwners: %w", err)
 	}
 
@@ -94,7 +150,7 @@ func validEigenpodsOnly(candidateAddresses []common.Address, mc *multicall.Multi
 		Response multicall.TypedMulticall3Result[*common.Address]
 	}
 
-	podOwnerPairs := lo.Filter(lo.Map(*reportedPodOwners, func(res multicall.TypedMulticall3Result[*common.Address], i int) PodOwnerResult {
+	podOwnerPairs := lo.Filter(lo.Map(reportedPodOwners, func(res multicall.TypedMulticall3Result[*common.Address], i int) PodOwnerResult {
 		return PodOwnerResult{
 			Query:    candidateAddresses[i],
 			Response: res,
@@ -121,16 +177,21 @@ func validEigenpodsOnly(candidateAddresses []common.Address, mc *multicall.Multi
 		return nil, lastError
 	}
 
-	authoritativeOwnerToPod, err := multicall.DoMany(mc, authoritativeOwnerToPodCalls...)
+	authoritativeOwnerToPod, err := doManyConcurrently(mc, authoritativeOwnerToPodCalls)
 	if err != nil {
 		return nil, err
 	}
+	for i, res := range authoritativeOwnerToPod {
+		if !res.Success {
+			return nil, fmt.Errorf("failed to load ownerToPod(%s)", podOwnerPairs[i].Response.Value.Hex())
+		}
+	}
 
 	nullAddress := common.BigToAddress(big.NewInt(0))
 
 	////// step 3: the valid eigenrestpods are the ones where authoritativeOwnerToPod[i] == candidateAddresses[i].
 	return lo.Map(lo.Filter(podOwnerPairs, func(res PodOwnerResult, i int) bool {
-		return (res.Query.Cmp(*(*authoritativeOwnerToPod)[i]) == 0) && (*authoritativeOwnerToPod)[i].Cmp(nullAddress) != 0
+		return (res.Query.Cmp(*authoritativeOwnerToPod[i].Value) == 0) && authoritativeOwnerToPod[i].Value.Cmp(nullAddress) != 0
 	}), func(v PodOwnerResult, i int) common.Address {
 		return v.Query
 	}), nil
@@ -142,23 +203,15 @@ func ComputeBalanceDeviationSync(ctx context.Context, eth *ethclient.Client, sta
 		return nil, err
 	}
 
-	allValidators, err := state.Validators()
-	utils.PanicOnError("failed to read validators", err)
-
-	allValidatorsWithIndexes := lo.Map(allValidators, func(v *phase0.Validator, i int) utils.ValidatorWithIndex {
-		return utils.ValidatorWithIndex{
-			Validator: v,
-			Index:     uint64(i),
-		}
-	})
-
-	podValidators := lo.Filter(allValidatorsWithIndexes, func(v utils.ValidatorWithIndex, u int) bool {
-		addr := executionWithdrawalAddress(v.Validator.WithdrawalCredentials)
-		return addr != nil && eigenpod.Cmp(common.HexToAddress(*addr)) == 0
-	})
+	podValidators, err := utils.FindAllValidatorsForEigenpod(eigenpod.Hex(), state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validators: %w", err)
+	}
 
 	validatorBalances, err := state.ValidatorBalances()
-	utils.PanicOnError("failed to read beacon state validator balances", err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read beacon state validator balances: %w", err)
+	}
 
 	validatorInfo, err := utils.FetchMultipleOnchainValidatorInfoWithFailures(ctx, eth, eigenpod.Hex(), podValidators)
 	if err != nil {
@@ -180,28 +233,42 @@ func ComputeBalanceDeviationSync(ctx context.Context, eth *ethclient.Client, sta
 	)
 
 	eigenPodManagerAddr, err := pod.EigenPodManager(nil)
-	utils.PanicOnError("failed to load eigenpod manager", err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load eigenpod manager: %w", err)
+	}
 
 	eigenPodManager, err := EigenPodManager.NewEigenPodManager(eigenPodManagerAddr, eth)
-	utils.PanicOnError("failed to load eigenpod manager", err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load eigenpod manager: %w", err)
+	}
 
 	delegationManagerAddress, err := eigenPodManager.DelegationManager(nil)
-	utils.PanicOnError("failed to read delegationManager", err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read delegationManager: %w", err)
+	}
 
 	delegationManager, err := DelegationManager.NewDelegationManager(delegationManagerAddress, eth)
-	utils.PanicOnError("failed to reach delegationManager", err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach delegationManager: %w", err)
+	}
 
 	podOwner, err := pod.PodOwner(nil)
-	utils.PanicOnError("failed to load pod owner", err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pod owner: %w", err)
+	}
 
 	activeShares, err := delegationManager.GetWithdrawableShares(nil, podOwner, []common.Address{
 		BeaconStrategy(),
 	})
-	utils.PanicOnError("failed to load owner shares", err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load owner shares: %w", err)
+	}
 
 	var sharesPendingWithdrawal *big.Int = new(big.Int).SetUint64(0)
 	withdrawalInfo, err := delegationManager.GetQueuedWithdrawals(nil, podOwner)
-	utils.PanicOnError("failed to load queued withdrawals", err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load queued withdrawals: %w", err)
+	}
 
 	for i, withdrawal := range withdrawalInfo.Withdrawals {
 		for j, strategy := range withdrawal.Strategies {
@@ -241,12 +308,10 @@ func ComputeBalanceDeviationSync(ctx context.Context, eth *ethclient.Client, sta
 	return delta, nil
 }
 
-func FindStaleEigenpods(ctx context.Context, eth *ethclient.Client, nodeUrl string, beacon utils.BeaconClient, chainId *big.Int, verbose bool, tolerance float64) (map[string][]utils.ValidatorWithIndex, error) {
-	beaconState, err := beacon.GetBeaconState(ctx, "head")
-	if err != nil {
-		return nil, fmt.Errorf("error downloading beacon state: %s", err.Error())
-	}
-
+// findSlashedEigenpods returns every eigenpod with at least one slashed validator in `beaconState`.
+// If `cache` is non-nil, only withdrawal addresses it
+// doesn't know yet are checked onchain, and the results are added to it.
+func findSlashedEigenpods(ctx context.Context, eth *ethclient.Client, beaconState *spec.VersionedBeaconState, chainId *big.Int, cache *PodAddressCache) ([]common.Address, error) {
 	mc, err := multicall.NewMulticallClient(ctx, eth, nil)
 	if err != nil {
 		return nil, err
@@ -282,48 +347,136 @@ func FindStaleEigenpods(ctx context.Context, eth *ethclient.Client, nodeUrl stri
 		}),
 	)
 
-	// fmt.Printf("Checking %d slashed withdrawal addresses for eigenpod status\n", len(allSlashedWithdrawalAddresses))
-	slashedEigenpods, err := validEigenpodsOnly(allSlashedWithdrawalAddresses, mc, chainId.Uint64())
+	if cache == nil {
+		pods, err := validEigenpodsOnly(allSlashedWithdrawalAddresses, mc, chainId.Uint64())
+		if err != nil {
+			return nil, err
+		}
+		return pods, nil
+	}
+
+	now := time.Now()
+	unknownAddresses := lo.Filter(allSlashedWithdrawalAddresses, func(addr common.Address, _ int) bool {
+		return cache.NeedsCheck(addr, now)
+	})
+	if len(unknownAddresses) > 0 {
+		newPods, err := validEigenpodsOnly(unknownAddresses, mc, chainId.Uint64())
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range unknownAddresses {
+			cache.Record(addr, lo.Contains(newPods, addr), now)
+		}
+	}
+
+	pods := lo.Filter(allSlashedWithdrawalAddresses, func(addr common.Address, _ int) bool {
+		return cache.Addresses[addr]
+	})
+	return pods, nil
+}
+
+type StaleEigenpod struct {
+	Pod              string
+	DeviationPercent float64
+	// slashed validators pointed at the pod
+	SlashedValidators []uint64
+	// the slashed validators `verifyStaleBalance` accepts, i.e. those that are ACTIVE in the pod
+	CorrectableValidators []uint64
+	// every validator pointed at the pod
+	Validators []utils.ValidatorWithIndex
+}
+
+type FindStalePodsOptions struct {
+	// pods whose balance deviation exceeds this percentage are stale
+	TolerancePercent float64
+	// optional; see PodAddressCache
+	Cache   *PodAddressCache
+	Verbose bool
+}
+
+// FindStaleEigenpods returns every eigenpod whose balance deviates from its shares by more than the
+// tolerance because of beacon chain slashings, most deviated first.
+func FindStaleEigenpods(ctx context.Context, eth *ethclient.Client, beacon utils.BeaconClient, chainId *big.Int, options FindStalePodsOptions) ([]StaleEigenpod, error) {
+	beaconState, err := beacon.GetBeaconState(ctx, "head")
+	if err != nil {
+		return nil, fmt.Errorf("error downloading beacon state: %s", err.Error())
+	}
+
+	slashedEigenpods, err := findSlashedEigenpods(ctx, eth, beaconState, chainId, options.Cache)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(slashedEigenpods) == 0 {
-		log.Println("No eigenpods were slashed.")
-		return map[string][]utils.ValidatorWithIndex{}, nil
+		if options.Verbose {
+			log.Println("No eigenpods were slashed.")
+		}
+		return []StaleEigenpod{}, nil
 	}
 
 	// 2. given the set of slashed eigenpods, determine which are unhealthy.
 
-	if verbose {
+	if options.Verbose {
 		log.Printf("%d EigenPods were slashed\n", len(slashedEigenpods))
 	}
 
-	unhealthyEigenpods := lo.Filter(slashedEigenpods, func(eigenpod common.Address, i int) bool {
-		deviation, err := ComputeBalanceDeviationSync(ctx, eth, beaconState, eigenpod)
-		utils.PanicOnError("failed to compute balance deviation for eigenpod", err)
+	deviations := make([]float64, len(slashedEigenpods))
+	errs := make([]error, len(slashedEigenpods))
+	forEachConcurrently(len(slashedEigenpods), DEFAULT_STALE_POD_SCAN_CONCURRENCY, func(i int) {
+		deviation, err := ComputeBalanceDeviationSync(ctx, eth, beaconState, slashedEigenpods[i])
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to compute balance deviation for eigenpod %s: %w", slashedEigenpods[i].Hex(), err)
+			return
+		}
 
-		return deviation.Cmp(big.NewFloat(tolerance)) > 0
+		// ComputeBalanceDeviationSync returns a fraction
+		deviations[i], _ = new(big.Float).Mul(deviation, big.NewFloat(100)).Float64()
 	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
 
-	if len(unhealthyEigenpods) == 0 {
-		if verbose {
-			log.Printf("All slashed eigenpods are within %f%% of their expected balance.\n", tolerance)
+	stalePods := []StaleEigenpod{}
+	for i, pod := range slashedEigenpods {
+		if deviations[i] <= options.TolerancePercent {
+			continue
 		}
-		return map[string][]utils.ValidatorWithIndex{}, nil
-	}
 
-	if verbose {
-		log.Printf("%d EigenPods were unhealthy\n", len(unhealthyEigenpods))
-	}
+		validators, err := utils.FindAllValidatorsForEigenpod(pod.Hex(), beaconState)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find validators of %s: %w", pod.Hex(), err)
+		}
+		slashed := lo.Filter(validators, func(v utils.ValidatorWithIndex, _ int) bool {
+			return v.Validator.Slashed
+		})
+		slashedWithInfo, err := utils.FetchMultipleOnchainValidatorInfo(ctx, eth, pod.Hex(), slashed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch validator info of %s: %w", pod.Hex(), err)
+		}
 
-	var entries map[string][]utils.ValidatorWithIndex = make(map[string][]utils.ValidatorWithIndex)
-	for _, val := range unhealthyEigenpods {
-		entries[val.Hex()] = lo.Filter(allValidatorsWithIndices, func(v utils.ValidatorWithIndex, _ int) bool {
-			execAddr := executionWithdrawalAddress(v.Validator.WithdrawalCredentials)
-			return execAddr != nil && common.HexToAddress(*execAddr).Cmp(val) == 0
+		stalePods = append(stalePods, StaleEigenpod{
+			Pod:              pod.Hex(),
+			DeviationPercent: deviations[i],
+			SlashedValidators: lo.Map(slashed, func(v utils.ValidatorWithIndex, _ int) uint64 {
+				return v.Index
+			}),
+			CorrectableValidators: lo.FilterMap(slashedWithInfo, func(v utils.ValidatorWithOnchainInfo, _ int) (uint64, bool) {
+				return v.Index, v.Info.Status == utils.ValidatorStatusActive
+			}),
+			Validators: validators,
 		})
 	}
 
-	return entries, nil
+	if options.Verbose {
+		if len(stalePods) == 0 {
+			log.Printf("All slashed eigenpods are within %f%% of their expected balance.\n", options.TolerancePercent)
+		} else {
+			log.Printf("%d EigenPods were unhealthy\n", len(stalePods))
+		}
+	}
+
+	sort.SliceStable(stalePods, func(i, j int) bool {
+		return stalePods[i].DeviationPercent > stalePods[j].DeviationPercent
+	})
+	return stalePods, nil
 }
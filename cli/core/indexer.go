@@ -0,0 +1,455 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/DelegationManager"
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPodManager"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/fatih/color"
+)
+
+// How many of the most recently indexed blocks are re-checked against the chain for reorgs.
+const INDEX_REORG_CHECK_DEPTH = 64
+
+// How many blocks are indexed (and committed) at a time, so an interrupted run keeps its progress.
+const INDEX_WINDOW_BLOCKS = uint64(50_000)
+
+const indexSchema = `
+CREATE TABLE IF NOT EXISTS pods (
+	address    TEXT PRIMARY KEY,
+	owner      TEXT NOT NULL,
+	last_block INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS blocks (
+	number    INTEGER PRIMARY KEY,
+	hash      TEXT NOT NULL,
+	timestamp INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS events (
+	pod          TEXT NOT NULL,
+	contract     TEXT NOT NULL,
+	address      TEXT NOT NULL,
+	name         TEXT NOT NULL,
+	block_number INTEGER NOT NULL,
+	tx_hash      TEXT NOT NULL,
+	log_index    INTEGER NOT NULL,
+	tx_from      TEXT NOT NULL,
+	args         TEXT NOT NULL,
+	PRIMARY KEY (pod, tx_hash, log_index)
+);
+CREATE INDEX IF NOT EXISTS events_by_name ON events (pod, name, block_number);
+`
+
+// EventIndex stores the EigenPod, EigenPodManager and DelegationManager events of a set of pods in
+// a SQLite database.
+type EventIndex struct {
+	db *sql.DB
+}
+
+// OpenEventIndex opens (or creates) the index at `path`. The caller must import a SQLite driver
+// registered as "sqlite".
+func OpenEventIndex(path string) (*EventIndex, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	// sqlite doesn't support concurrent writers
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(indexSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create index schema: %w", err)
+	}
+	return &EventIndex{db: db}, nil
+}
+
+func (idx *EventIndex) Close() error {
+	return idx.db.Close()
+}
+
+// Pods returns the addresses of every pod in the index.
+func (idx *EventIndex) Pods() ([]string, error) {
+	rows, err := idx.db.Query(`SELECT address FROM pods ORDER BY address`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pods := []string{}
+	for rows.Next() {
+		var pod string
+		if err := rows.Scan(&pod); err != nil {
+			return nil, err
+		}
+		pods = append(pods, pod)
+	}
+	return pods, rows.Err()
+}
+
+type IndexProgress struct {
+	Pod        string
+	FromBlock  uint64
+	ToBlock    uint64
+	EventCount int
+}
+
+// Rewind checks the most recently indexed blocks against the chain, and deletes everything indexed
+// after the latest block that is still canonical: the reorg may have started at any block after it,
+// including blocks that had no events. Returns the first rewound block, or 0 if there was no reorg.
+func (idx *EventIndex) Rewind(ctx context.Context, eth ethereum.ChainReader) (uint64, error) {
+	rows, err := idx.db.QueryContext(ctx, `SELECT number, hash FROM blocks ORDER BY number DESC LIMIT ?`, INDEX_REORG_CHECK_DEPTH)
+	if err != nil {
+		return 0, err
+	}
+	type indexedBlock struct {
+		number uint64
+		hash   string
+	}
+	recent := []indexedBlock{}
+	for rows.Next() {
+		var block indexedBlock
+		if err := rows.Scan(&block.number, &block.hash); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		recent = append(recent, block)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	forkBlock := uint64(0)
+	for i, block := range recent {
+		header, err := eth.HeaderByNumber(ctx, new(big.Int).SetUint64(block.number))
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch block %d: %w", block.number, err)
+		}
+		if header.Hash().Hex() == block.hash {
+			if i > 0 {
+				forkBlock = block.number + 1
+			}
+			break
+		}
+		if i == len(recent)-1 {
+			return 0, fmt.Errorf("none of the last %d indexed blocks are canonical anymore; delete the index to rebuild it", len(recent))
+		}
+	}
+	if forkBlock == 0 {
+		return 0, nil
+	}
+
+	tx, err := idx.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM events WHERE block_number >= ?`, forkBlock); err != nil {
+		return 0, fmt.Errorf("failed to rewind events to block %d: %w", forkBlock, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM blocks WHERE number >= ?`, forkBlock); err != nil {
+		return 0, fmt.Errorf("failed to rewind blocks to block %d: %w", forkBlock, err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE pods SET last_block = ? WHERE last_block >= ?`, int64(forkBlock)-1, forkBlock); err != nil {
+		return 0, fmt.Errorf("failed to rewind pods to block %d: %w", forkBlock, err)
+	}
+	return forkBlock, tx.Commit()
+}
+
+// contracts whose events are indexed for a single pod
+type podContracts struct {
+	pod               common.Address
+	owner             common.Address
+	eigenPodManager   common.Address
+	delegationManager common.Address
+}
+
+func getPodContracts(eth *ethclient.Client, podAddress string) (*podContracts, error) {
+	pod, err := EigenPod.NewEigenPod(common.HexToAddress(podAddress), eth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach eigenpod: %w", err)
+	}
+	owner, err := pod.PodOwner(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pod owner: %w", err)
+	}
+	eigenPodManagerAddress, err := pod.EigenPodManager(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch eigenpod manager: %w", err)
+	}
+	eigenPodManager, err := EigenPodManager.NewEigenPodManager(eigenPodManagerAddress, eth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach eigenpod manager: %w", err)
+	}
+	delegationManagerAddress, err := eigenPodManager.DelegationManager(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch delegation manager: %w", err)
+	}
+
+	return &podContracts{
+		pod:               common.HexToAddress(podAddress),
+		owner:             owner,
+		eigenPodManager:   eigenPodManagerAddress,
+		delegationManager: delegationManagerAddress,
+	}, nil
+}
+
+func eventIDs(abiFn func() (*abi.ABI, error), names ...string) ([]common.Hash, error) {
+	parsed, err := abiFn()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]common.Hash, len(names))
+	for i, name := range names {
+		event, ok := parsed.Events[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown event %s", name)
+		}
+		ids[i] = event.ID
+	}
+	return ids, nil
+}
+
+// queries returns the log filters covering the pod's events. EigenPodManager and DelegationManager
+// events are narrowed down by their indexed pod/owner topic where they have one; events without one
+// are fetched by signature and filtered by `isRelevant`.
+func (c *podContracts) queries() ([]ethereum.FilterQuery, error) {
+	epmUnindexed, err := eventIDs(EigenPodManager.EigenPodManagerMetaData.GetAbi, "BeaconChainSlashingFactorDecreased")
+	if err != nil {
+		return nil, err
+	}
+	dmUnindexed, err := eventIDs(DelegationManager.DelegationManagerMetaData.GetAbi, "SlashingWithdrawalQueued", "SlashingWithdrawalCompleted", "DepositScalingFactorUpdated")
+	if err != nil {
+		return nil, err
+	}
+	// withdrawals queued or completed before the slashing upgrade
+	dmLegacyUnindexed, err := eventIDs(utils.LegacyDelegationManagerABI, "WithdrawalQueued", "WithdrawalCompleted")
+	if err != nil {
+		return nil, err
+	}
+	dmUnindexed = append(dmUnindexed, dmLegacyUnindexed...)
+
+	tracked := []common.Hash{common.BytesToHash(c.pod.Bytes()), common.BytesToHash(c.owner.Bytes())}
+	return []ethereum.FilterQuery{
+		{Addresses: []common.Address{c.pod}},
+		{Addresses: []common.Address{c.eigenPodManager}, Topics: [][]common.Hash{nil, tracked}},
+		{Addresses: []common.Address{c.eigenPodManager}, Topics: [][]common.Hash{nil, nil, tracked}},
+		{Addresses: []common.Address{c.eigenPodManager}, Topics: [][]common.Hash{epmUnindexed}},
+		{Addresses: []common.Address{c.delegationManager}, Topics: [][]common.Hash{nil, tracked}},
+		{Addresses: []common.Address{c.delegationManager}, Topics: [][]common.Hash{dmUnindexed}},
+	}, nil
+}
+
+// isRelevant reports whether `event` concerns the pod: it was emitted by the pod, references the pod
+// or its owner, or completes one of the owner's withdrawals.
+func (c *podContracts) isRelevant(event utils.DecodedEvent, withdrawalRoots map[string]bool) bool {
+	if event.Address == c.pod {
+		return true
+	}
+	if root, ok := event.ArgBytes32("withdrawalRoot"); ok && withdrawalRoots[common.Hash(root).Hex()] {
+		return true
+	}
+	for _, arg := range event.Args {
+		if addr, ok := arg.Value.(common.Address); ok && (addr == c.pod || addr == c.owner) {
+			return true
+		}
+	}
+	if withdrawal := event.Arg("withdrawal"); withdrawal != nil {
+		staker := reflect.ValueOf(withdrawal).FieldByName("Staker")
+		if staker.IsValid() && staker.Interface() == c.owner {
+			return true
+		}
+	}
+	return false
+}
+
+// Index adds `podAddress` to the index (if it isn't already), and indexes its events from where the
+// previous run stopped (or `fromBlock`, for a new pod) up to `toBlock`.
+func (idx *EventIndex) Index(ctx context.Context, eth *ethclient.Client, podAddress string, fromBlock, toBlock uint64, verbose bool) (*IndexProgress, error) {
+	podAddress = common.HexToAddress(podAddress).Hex()
+	contracts, err := getPodContracts(eth, podAddress)
+	if err != nil {
+		return nil, err
+	}
+	queries, err := contracts.queries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build log filters: %w", err)
+	}
+
+	var lastBlock int64
+	err = idx.db.QueryRowContext(ctx, `SELECT last_block FROM pods WHERE address = ?`, podAddress).Scan(&lastBlock)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if fromBlock > 0 {
+			lastBlock = int64(fromBlock) - 1
+		} else {
+			lastBlock = -1
+		}
+		if _, err := idx.db.ExecContext(ctx, `INSERT INTO pods (address, owner, last_block) VALUES (?, ?, ?)`, podAddress, contracts.owner.Hex(), lastBlock); err != nil {
+			return nil, fmt.Errorf("failed to add pod: %w", err)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to read pod cursor: %w", err)
+	}
+
+	withdrawalRoots, err := idx.withdrawalRoots(ctx, podAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &IndexProgress{Pod: podAddress, FromBlock: uint64(lastBlock + 1), ToBlock: toBlock}
+	senders := map[common.Hash]common.Address{}
+	for start := uint64(lastBlock + 1); start <= toBlock; start += INDEX_WINDOW_BLOCKS {
+		end := min(start+INDEX_WINDOW_BLOCKS-1, toBlock)
+
+		logs := []types.Log{}
+		for _, query := range queries {
+			found, err := utils.FilterLogsChunked(ctx, eth, query, start, end, utils.DEFAULT_LOG_FILTER_CHUNK)
+			if err != nil {
+				return nil, err
+			}
+			logs = append(logs, found...)
+		}
+		slices.SortFunc(logs, func(a, b types.Log) int {
+			if a.BlockNumber != b.BlockNumber {
+				return cmp.Compare(a.BlockNumber, b.BlockNumber)
+			}
+			return cmp.Compare(a.Index, b.Index)
+		})
+		logs = slices.CompactFunc(logs, func(a, b types.Log) bool {
+			return a.TxHash == b.TxHash && a.Index == b.Index
+		})
+
+		count, err := idx.storeWindow(ctx, eth, contracts, logs, end, withdrawalRoots, senders)
+		if err != nil {
+			return nil, fmt.Errorf("failed to index blocks %d-%d: %w", start, end, err)
+		}
+		progress.EventCount += count
+		if verbose {
+			color.Green("%s: indexed blocks %d-%d (%d events)", podAddress, start, end, count)
+		}
+	}
+	return progress, nil
+}
+
+// withdrawalRoots returns the roots of the withdrawals queued by the pod's owner so far, so that
+// their SlashingWithdrawalCompleted (or, before the slashing upgrade, WithdrawalCompleted) events
+// can be matched.
+func (idx *EventIndex) withdrawalRoots(ctx context.Context, podAddress string) (map[string]bool, error) {
+	rows, err := idx.db.QueryContext(ctx, `SELECT json_extract(args, '$.withdrawalRoot') FROM events WHERE pod = ? AND name IN ('SlashingWithdrawalQueued', 'WithdrawalQueued')`, podAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read withdrawal roots: %w", err)
+	}
+	defer rows.Close()
+
+	roots := map[string]bool{}
+	for rows.Next() {
+		var root string
+		if err := rows.Scan(&root); err != nil {
+			return nil, err
+		}
+		roots[common.HexToHash(root).Hex()] = true
+	}
+	return roots, rows.Err()
+}
+
+// storeWindow decodes and stores the relevant `logs`, and advances the pod's cursor to `end`, in a
+// single transaction.
+func (idx *EventIndex) storeWindow(
+	ctx context.Context,
+	eth *ethclient.Client,
+	contracts *podContracts,
+	logs []types.Log,
+	end uint64,
+	withdrawalRoots map[string]bool,
+	senders map[common.Hash]common.Address,
+) (int, error) {
+	tx, err := idx.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	podAddress := contracts.pod.Hex()
+	count := 0
+	for i := range logs {
+		log := &logs[i]
+		event, ok := utils.DecodeLog(log)
+		if !ok || !contracts.isRelevant(event, withdrawalRoots) {
+			continue
+		}
+		if root, ok := event.ArgBytes32("withdrawalRoot"); ok && (event.Name == "SlashingWithdrawalQueued" || event.Name == "WithdrawalQueued") {
+			withdrawalRoots[common.Hash(root).Hex()] = true
+		}
+
+		args, err := event.ArgsJSON()
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode %s: %w", event.Name, err)
+		}
+
+		from, ok := senders[log.TxHash]
+		if !ok {
+			txn, _, err := eth.TransactionByHash(ctx, log.TxHash)
+			if err != nil {
+				return 0, fmt.Errorf("failed to fetch tx %s: %w", log.TxHash, err)
+			}
+			from, err = eth.TransactionSender(ctx, txn, log.BlockHash, log.TxIndex)
+			if err != nil {
+				return 0, fmt.Errorf("failed to recover sender of %s: %w", log.TxHash, err)
+			}
+			senders[log.TxHash] = from
+		}
+
+		if err := idx.storeBlock(ctx, tx, eth, log.BlockNumber); err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO events (pod, contract, address, name, block_number, tx_hash, log_index, tx_from, args) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			podAddress, event.Contract, event.Address.Hex(), event.Name, log.BlockNumber, log.TxHash.Hex(), log.Index, from.Hex(), string(args),
+		); err != nil {
+			return 0, fmt.Errorf("failed to store %s: %w", event.Name, err)
+		}
+		count++
+	}
+
+	if err := idx.storeBlock(ctx, tx, eth, end); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE pods SET last_block = ? WHERE address = ?`, end, podAddress); err != nil {
+		return 0, fmt.Errorf("failed to update pod cursor: %w", err)
+	}
+	return count, tx.Commit()
+}
+
+// storeBlock records the hash (for reorg detection) and timestamp of block `number`.
+func (idx *EventIndex) storeBlock(ctx context.Context, tx *sql.Tx, eth *ethclient.Client, number uint64) error {
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM blocks WHERE number = ?)`, number).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	header, err := eth.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return fmt.Errorf("failed to fetch block %d: %w", number, err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO blocks (number, hash, timestamp) VALUES (?, ?, ?)`, number, header.Hash().Hex(), header.Time)
+	return err
+}
@@ -0,0 +1,182 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type ValidatorVerification struct {
+	Pod string
+	// identifies the validator; proofs from before the slashing upgrade identify validators by index
+	// instead, leaving PubkeyHash empty
+	PubkeyHash     string
+	ValidatorIndex *uint64 `json:",omitempty"`
+
+	BlockNumber uint64
+	// unix timestamp of the block the credential proof was included in
+	Timestamp uint64
+	TxHash    string
+	Submitter string
+}
+
+type CheckpointSummary struct {
+	Pod                 string
+	CheckpointTimestamp uint64
+	ValidatorCount      string
+
+	StartedBlock uint64
+	StartedTx    string
+	StartedBy    string
+
+	// 0 if the checkpoint hasn't been finalized yet
+	FinalizedBlock uint64
+	FinalizedAt    uint64
+	// empty if the checkpoint hasn't been finalized yet
+	TotalShareDeltaWei string
+
+	// senders of the txs that submitted this checkpoint's proofs
+	ProofSubmitters []string
+}
+
+type ProofSubmitterSummary struct {
+	Pod       string
+	Submitter string
+
+	CredentialProofTxs int
+	CheckpointProofTxs int
+
+	FirstBlock uint64
+	LastBlock  uint64
+}
+
+// podFilter returns a `pod IN (...)` condition (and its arguments) on `column`, matching every pod
+// if `pods` is empty.
+func podFilter(column string, pods []string) (string, []interface{}) {
+	if len(pods) == 0 {
+		return "1 = 1", nil
+	}
+	args := make([]interface{}, len(pods))
+	for i, pod := range pods {
+		args[i] = common.HexToAddress(pod).Hex()
+	}
+	return fmt.Sprintf("%s IN (%s)", column, strings.TrimSuffix(strings.Repeat("?, ", len(pods)), ", ")), args
+}
+
+// ValidatorVerifications returns when each validator of `pods` (or every indexed pod, if empty) had
+// its withdrawal credentials verified, and who submitted the proof.
+func (idx *EventIndex) ValidatorVerifications(ctx context.Context, pods []string) ([]ValidatorVerification, error) {
+	filter, args := podFilter("e.pod", pods)
+	rows, err := idx.db.QueryContext(ctx, `
+		SELECT
+			e.pod,
+			COALESCE(json_extract(e.args, '$.pubkeyHash'), ''),
+			CAST(json_extract(e.args, '$.validatorIndex') AS INTEGER),
+			e.block_number, b.timestamp, e.tx_hash, e.tx_from
+		FROM events e JOIN blocks b ON b.number = e.block_number
+		WHERE e.name = 'ValidatorRestaked' AND `+filter+`
+		ORDER BY e.pod, e.block_number, e.log_index`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query verifications: %w", err)
+	}
+	defer rows.Close()
+
+	out := []ValidatorVerification{}
+	for rows.Next() {
+		var v ValidatorVerification
+		var validatorIndex sql.NullInt64
+		if err := rows.Scan(&v.Pod, &v.PubkeyHash, &validatorIndex, &v.BlockNumber, &v.Timestamp, &v.TxHash, &v.Submitter); err != nil {
+			return nil, err
+		}
+		if validatorIndex.Valid {
+			index := uint64(validatorIndex.Int64)
+			v.ValidatorIndex = &index
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+// Checkpoints returns every checkpoint started by `pods` (or every indexed pod, if empty), with its
+// share delta once finalized and the senders of its proofs.
+func (idx *EventIndex) Checkpoints(ctx context.Context, pods []string) ([]CheckpointSummary, error) {
+	filter, args := podFilter("c.pod", pods)
+	rows, err := idx.db.QueryContext(ctx, `
+		SELECT
+			c.pod,
+			json_extract(c.args, '$.checkpointTimestamp'),
+			json_extract(c.args, '$.validatorCount'),
+			c.block_number, c.tx_hash, c.tx_from,
+			COALESCE(f.block_number, 0),
+			COALESCE(b.timestamp, 0),
+			COALESCE(json_extract(f.args, '$.totalShareDeltaWei'), ''),
+			COALESCE((
+				SELECT group_concat(DISTINCT p.tx_from) FROM events p
+				WHERE p.pod = c.pod
+					AND p.name IN ('ValidatorCheckpointed', 'ValidatorWithdrawn')
+					AND json_extract(p.args, '$.checkpointTimestamp') = json_extract(c.args, '$.checkpointTimestamp')
+			), '')
+		FROM events c
+		LEFT JOIN events f ON f.pod = c.pod AND f.name = 'CheckpointFinalized'
+			AND json_extract(f.args, '$.checkpointTimestamp') = json_extract(c.args, '$.checkpointTimestamp')
+		LEFT JOIN blocks b ON b.number = f.block_number
+		WHERE c.name = 'CheckpointCreated' AND `+filter+`
+		ORDER BY c.pod, c.block_number`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	out := []CheckpointSummary{}
+	for rows.Next() {
+		var c CheckpointSummary
+		var submitters string
+		if err := rows.Scan(
+			&c.Pod, &c.CheckpointTimestamp, &c.ValidatorCount,
+			&c.StartedBlock, &c.StartedTx, &c.StartedBy,
+			&c.FinalizedBlock, &c.FinalizedAt, &c.TotalShareDeltaWei,
+			&submitters,
+		); err != nil {
+			return nil, err
+		}
+		c.ProofSubmitters = []string{}
+		if submitters != "" {
+			c.ProofSubmitters = strings.Split(submitters, ",")
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// ProofSubmitters returns, per pod, every address that submitted credential or checkpoint proofs,
+// with how many transactions of each kind it sent.
+func (idx *EventIndex) ProofSubmitters(ctx context.Context, pods []string) ([]ProofSubmitterSummary, error) {
+	filter, args := podFilter("pod", pods)
+	rows, err := idx.db.QueryContext(ctx, `
+		SELECT
+			pod, tx_from,
+			COUNT(DISTINCT CASE WHEN name = 'ValidatorRestaked' THEN tx_hash END),
+			COUNT(DISTINCT CASE WHEN name IN ('ValidatorCheckpointed', 'ValidatorWithdrawn') THEN tx_hash END),
+			MIN(block_number), MAX(block_number)
+		FROM events
+		WHERE name IN ('ValidatorRestaked', 'ValidatorCheckpointed', 'ValidatorWithdrawn') AND `+filter+`
+		GROUP BY pod, tx_from
+		ORDER BY pod, MIN(block_number)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query proof submitters: %w", err)
+	}
+	defer rows.Close()
+
+	out := []ProofSubmitterSummary{}
+	for rows.Next() {
+		var s ProofSubmitterSummary
+		if err := rows.Scan(&s.Pod, &s.Submitter, &s.CredentialProofTxs, &s.CheckpointProofTxs, &s.FirstBlock, &s.LastBlock); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func openTestIndex(t *testing.T) *EventIndex {
+	idx, err := OpenEventIndex(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { idx.Close() })
+	return idx
+}
+
+func (idx *EventIndex) insertTestEvent(t *testing.T, pod common.Address, name string, block uint64, tx string, logIndex int, from common.Address, args string) {
+	_, err := idx.db.Exec(
+		`INSERT INTO events (pod, contract, address, name, block_number, tx_hash, log_index, tx_from, args) VALUES (?, 'EigenPod', ?, ?, ?, ?, ?, ?, ?)`,
+		pod.Hex(), pod.Hex(), name, block, tx, logIndex, from.Hex(), args,
+	)
+	require.NoError(t, err)
+}
+
+func (idx *EventIndex) insertTestBlock(t *testing.T, number uint64, hash string) {
+	_, err := idx.db.Exec(`INSERT INTO blocks (number, hash, timestamp) VALUES (?, ?, ?)`, number, hash, 1000+number)
+	require.NoError(t, err)
+}
+
+func TestEventIndexRewind(t *testing.T) {
+	ctx := context.Background()
+	backend := simulated.NewBackend(types.GenesisAlloc{})
+	t.Cleanup(func() { backend.Close() })
+	for range 5 {
+		backend.Commit()
+	}
+	client := backend.Client()
+	canonical := func(number uint64) string {
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		require.NoError(t, err)
+		return header.Hash().Hex()
+	}
+
+	idx := openTestIndex(t)
+	_, err := idx.db.Exec(`INSERT INTO pods (address, owner, last_block) VALUES (?, ?, 5)`, testPod.Hex(), otherAddr.Hex())
+	require.NoError(t, err)
+	for number := uint64(1); number <= 3; number++ {
+		idx.insertTestBlock(t, number, canonical(number))
+	}
+
+	forkBlock, err := idx.Rewind(ctx, client)
+	require.NoError(t, err)
+	assert.Zero(t, forkBlock)
+
+	// blocks 4 and 5 were reorged out; block 4 had no events
+	idx.insertTestBlock(t, 4, common.Hash{0x4}.Hex())
+	idx.insertTestBlock(t, 5, common.Hash{0x5}.Hex())
+	idx.insertTestEvent(t, testPod, "ValidatorRestaked", 2, "0x02", 0, otherAddr, `{}`)
+	idx.insertTestEvent(t, testPod, "ValidatorRestaked", 5, "0x05", 0, otherAddr, `{}`)
+
+	forkBlock, err = idx.Rewind(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), forkBlock)
+
+	var lastBlock, events, blocks int
+	require.NoError(t, idx.db.QueryRow(`SELECT last_block FROM pods`).Scan(&lastBlock))
+	require.NoError(t, idx.db.QueryRow(`SELECT COUNT(*) FROM events`).Scan(&events))
+	require.NoError(t, idx.db.QueryRow(`SELECT COUNT(*) FROM blocks`).Scan(&blocks))
+	assert.Equal(t, 3, lastBlock)
+	assert.Equal(t, 1, events)
+	assert.Equal(t, 3, blocks)
+
+	// none of the indexed blocks are canonical
+	_, err = idx.db.Exec(`UPDATE blocks SET hash = ?`, common.Hash{}.Hex())
+	require.NoError(t, err)
+	_, err = idx.Rewind(ctx, client)
+	assert.ErrorContains(t, err, "delete the index")
+}
+
+func TestEventIndexQueries(t *testing.T) {
+	ctx := context.Background()
+	idx := openTestIndex(t)
+	submitter := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	pubkeyHash := common.Hash{0xa}.Hex()
+	for number := uint64(1); number <= 30; number++ {
+		idx.insertTestBlock(t, number, common.BigToHash(new(big.Int).SetUint64(number)).Hex())
+	}
+
+	// a validator verified before the slashing upgrade, by index, and one after, by pubkey hash
+	idx.insertTestEvent(t, testPod, "ValidatorRestaked", 1, "0x01", 0, otherAddr, `{"validatorIndex":"7"}`)
+	idx.insertTestEvent(t, testPod, "ValidatorRestaked", 2, "0x02", 0, submitter, `{"pubkeyHash":"`+pubkeyHash+`"}`)
+
+	// a checkpoint proved before the upgrade by two submitters, and an unfinalized one
+	idx.insertTestEvent(t, testPod, "CheckpointCreated", 10, "0x10", 0, otherAddr, `{"checkpointTimestamp":100,"validatorCount":"2"}`)
+	idx.insertTestEvent(t, testPod, "ValidatorCheckpointed", 11, "0x11", 0, otherAddr, `{"checkpointTimestamp":100,"validatorIndex":"7"}`)
+	idx.insertTestEvent(t, testPod, "ValidatorCheckpointed", 11, "0x12", 1, submitter, `{"checkpointTimestamp":100,"validatorIndex":"8"}`)
+	idx.insertTestEvent(t, testPod, "ValidatorWithdrawn", 11, "0x12", 2, submitter, `{"checkpointTimestamp":100,"validatorIndex":"8"}`)
+	idx.insertTestEvent(t, testPod, "CheckpointFinalized", 12, "0x12", 3, submitter, `{"checkpointTimestamp":100,"totalShareDeltaWei":"-5"}`)
+	idx.insertTestEvent(t, testPod, "CheckpointCreated", 20, "0x20", 0, otherAddr, `{"checkpointTimestamp":200,"validatorCount":"1"}`)
+
+	// another pod's checkpoint at the same timestamp
+	idx.insertTestEvent(t, otherAddr, "CheckpointCreated", 10, "0x10", 1, otherAddr, `{"checkpointTimestamp":100,"validatorCount":"1"}`)
+
+	verifications, err := idx.ValidatorVerifications(ctx, []string{testPod.Hex()})
+	require.NoError(t, err)
+	require.Len(t, verifications, 2)
+	assert.Empty(t, verifications[0].PubkeyHash)
+	require.NotNil(t, verifications[0].ValidatorIndex)
+	assert.Equal(t, uint64(7), *verifications[0].ValidatorIndex)
+	assert.Equal(t, uint64(1001), verifications[0].Timestamp)
+	assert.Equal(t, pubkeyHash, verifications[1].PubkeyHash)
+	assert.Nil(t, verifications[1].ValidatorIndex)
+	assert.Equal(t, submitter.Hex(), verifications[1].Submitter)
+
+	checkpoints, err := idx.Checkpoints(ctx, []string{testPod.Hex()})
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 2)
+	assert.Equal(t, uint64(100), checkpoints[0].CheckpointTimestamp)
+	assert.Equal(t, "2", checkpoints[0].ValidatorCount)
+	assert.Equal(t, uint64(12), checkpoints[0].FinalizedBlock)
+	assert.Equal(t, uint64(1012), checkpoints[0].FinalizedAt)
+	assert.Equal(t, "-5", checkpoints[0].TotalShareDeltaWei)
+	assert.ElementsMatch(t, []string{otherAddr.Hex(), submitter.Hex()}, checkpoints[0].ProofSubmitters)
+	assert.Zero(t, checkpoints[1].FinalizedBlock)
+	assert.Empty(t, checkpoints[1].TotalShareDeltaWei)
+	assert.Equal(t, []string{}, checkpoints[1].ProofSubmitters)
+
+	all, err := idx.Checkpoints(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	submitters, err := idx.ProofSubmitters(ctx, []string{testPod.Hex()})
+	require.NoError(t, err)
+	require.Len(t, submitters, 2)
+	assert.Equal(t, ProofSubmitterSummary{
+		Pod: testPod.Hex(), Submitter: otherAddr.Hex(),
+		CredentialProofTxs: 1, CheckpointProofTxs: 1,
+		FirstBlock: 1, LastBlock: 11,
+	}, submitters[0])
+	assert.Equal(t, ProofSubmitterSummary{
+		Pod: testPod.Hex(), Submitter: submitter.Hex(),
+		CredentialProofTxs: 1, CheckpointProofTxs: 1,
+		FirstBlock: 2, LastBlock: 11,
+	}, submitters[1])
+}
+
+func TestIsRelevantLegacyWithdrawals(t *testing.T) {
+	legacy, err := utils.LegacyDelegationManagerABI()
+	require.NoError(t, err)
+	contracts := &podContracts{pod: testPod, owner: otherAddr}
+	root := [32]byte{0x1}
+
+	data, err := legacy.Events["WithdrawalQueued"].Inputs.Pack(root, struct {
+		Staker      common.Address
+		DelegatedTo common.Address
+		Withdrawer  common.Address
+		Nonce       *big.Int
+		StartBlock  uint32
+		Strategies  []common.Address
+		Shares      []*big.Int
+	}{Staker: otherAddr, Nonce: big.NewInt(0), Strategies: []common.Address{}, Shares: []*big.Int{}})
+	require.NoError(t, err)
+	queued, ok := utils.DecodeLog(&types.Log{Topics: []common.Hash{legacy.Events["WithdrawalQueued"].ID}, Data: data})
+	require.True(t, ok)
+	assert.True(t, contracts.isRelevant(queued, map[string]bool{}))
+
+	data, err = legacy.Events["WithdrawalCompleted"].Inputs.Pack(root)
+	require.NoError(t, err)
+	completed, ok := utils.DecodeLog(&types.Log{Topics: []common.Hash{legacy.Events["WithdrawalCompleted"].ID}, Data: data})
+	require.True(t, ok)
+	assert.False(t, contracts.isRelevant(completed, map[string]bool{}))
+	assert.True(t, contracts.isRelevant(completed, map[string]bool{common.Hash(root).Hex(): true}))
+}
@@ -0,0 +1,124 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Addresses that weren't eigenpods are checked again once they were last checked this long ago.
+const POD_CACHE_NOT_POD_MAX_AGE = 24 * time.Hour
+
+// PodAddressCache remembers which withdrawal addresses were found to be eigenpods, so that repeated
+// scans for stale pods only check withdrawal addresses they haven't seen before.
+//
+// Addresses that weren't eigenpods are remembered too, but only for POD_CACHE_NOT_POD_MAX_AGE, as an
+// eigenpod may be deployed at one of them later (e.g. one whose validators were deposited before
+// the pod was created).
+type PodAddressCache struct {
+	path string
+
+	ChainId uint64
+	// withdrawal address => whether it is an eigenpod
+	Addresses map[common.Address]bool
+	// withdrawal address => unix time it was last found not to be an eigenpod
+	CheckedAt map[common.Address]int64
+}
+
+// LoadPodAddressCache reads the cache at `path`, or returns an empty cache if there is no file there
+// yet.
+func LoadPodAddressCache(path string, chainId uint64) (*PodAddressCache, error) {
+	cache := &PodAddressCache{
+		path:      path,
+		ChainId:   chainId,
+		Addresses: map[common.Address]bool{},
+		CheckedAt: map[common.Address]int64{},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pod cache: %w", err)
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("failed to parse pod cache %s: %w", path, err)
+	}
+	if cache.ChainId != chainId {
+		return nil, fmt.Errorf("pod cache %s is for chain %d, not %d", path, cache.ChainId, chainId)
+	}
+	if cache.Addresses == nil {
+		cache.Addresses = map[common.Address]bool{}
+	}
+	if cache.CheckedAt == nil {
+		cache.CheckedAt = map[common.Address]int64{}
+	}
+	return cache, nil
+}
+
+// NeedsCheck returns whether `addr` must be checked for being an eigenpod as of `now`: it isn't
+// cached, or was found not to be an eigenpod more than POD_CACHE_NOT_POD_MAX_AGE ago.
+func (c *PodAddressCache) NeedsCheck(addr common.Address, now time.Time) bool {
+	isPod, known := c.Addresses[addr]
+	if !known {
+		return true
+	}
+	if isPod {
+		return false
+	}
+	return now.Sub(time.Unix(c.CheckedAt[addr], 0)) > POD_CACHE_NOT_POD_MAX_AGE
+}
+
+// Record caches whether `addr` was found to be an eigenpod at `now`.
+func (c *PodAddressCache) Record(addr common.Address, isPod bool, now time.Time) {
+	c.Addresses[addr] = isPod
+	if isPod {
+		delete(c.CheckedAt, addr)
+	} else {
+		c.CheckedAt[addr] = now.Unix()
+	}
+}
+
+// Save writes the cache back to the file it was loaded from.
+func (c *PodAddressCache) Save() error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so an interrupted save doesn't corrupt the cache
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save pod cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save pod cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save pod cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to save pod cache: %w", err)
+	}
+	return nil
+}
+
+// Pods returns how many of the cached addresses are eigenpods.
+func (c *PodAddressCache) Pods() int {
+	n := 0
+	for _, isPod := range c.Addresses {
+		if isPod {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPodAddressCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pods.json")
+	cache, err := LoadPodAddressCache(path, 1)
+	require.NoError(t, err)
+
+	pod := common.HexToAddress("0x01")
+	notPod := common.HexToAddress("0x02")
+	now := time.Unix(1_700_000_000, 0)
+
+	assert.True(t, cache.NeedsCheck(pod, now))
+	cache.Record(pod, true, now)
+	cache.Record(notPod, false, now)
+	require.NoError(t, cache.Save())
+
+	cache, err = LoadPodAddressCache(path, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cache.Pods())
+
+	later := now.Add(POD_CACHE_NOT_POD_MAX_AGE)
+	assert.False(t, cache.NeedsCheck(pod, later))
+	assert.False(t, cache.NeedsCheck(notPod, later))
+
+	// negatives expire, as a pod may have been deployed since
+	later = later.Add(time.Second)
+	assert.False(t, cache.NeedsCheck(pod, later))
+	assert.True(t, cache.NeedsCheck(notPod, later))
+
+	cache.Record(notPod, true, later)
+	assert.False(t, cache.NeedsCheck(notPod, later.Add(2*POD_CACHE_NOT_POD_MAX_AGE)))
+	assert.Equal(t, 2, cache.Pods())
+
+	_, err = LoadPodAddressCache(path, 17000)
+	assert.ErrorContains(t, err, "is for chain 1")
+}
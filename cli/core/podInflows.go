@@ -0,0 +1,330 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/fatih/color"
+)
+
+// How many beacon blocks are downloaded at once when walking a range of slots.
+const DEFAULT_BLOCK_FETCH_WORKERS = 8
+
+type PodWithdrawal struct {
+	Slot           uint64
+	BlockNumber    uint64
+	ValidatorIndex uint64
+	AmountGwei     uint64
+
+	// whether the validator was withdrawable (i.e. this withdrew its whole balance) at `Slot`
+	FullExit bool
+}
+
+// PodInflows explains the change in the pod's ETH balance between two timestamps:
+//
+//	PodBalanceDeltaWei = (PartialWithdrawalsGwei + FullExitsGwei) * 1 gwei + NonBeaconWei - OutflowsWei
+type PodInflows struct {
+	FromTimestamp uint64
+	ToTimestamp   uint64
+
+	// execution blocks at FromTimestamp and ToTimestamp. Withdrawals in (FromBlock, ToBlock] are counted.
+	FromBlock uint64
+	ToBlock   uint64
+
+	Withdrawals            []PodWithdrawal
+	PartialWithdrawalsGwei uint64
+	FullExitsGwei          uint64
+
+	PodBalanceDeltaWei *big.Int
+	// ETH paid out of the pod to complete withdrawals (RestakedBeaconChainETHWithdrawn)
+	OutflowsWei *big.Int
+	// everything else: ETH sent to the pod directly (e.g. execution layer rewards)
+	NonBeaconWei *big.Int
+}
+
+// GetPodInflows walks every beacon block after `fromTimestamp` up to `toTimestamp`, and sums the
+// withdrawals paid to the pod. If `fromTimestamp` is 0, the pod's last checkpoint is used; if
+// `toTimestamp` is 0, its active checkpoint (or the head of the chain) is used.
+func GetPodInflows(ctx context.Context, eth *ethclient.Client, beaconClient utils.BeaconClient, eigenpodAddress string, fromTimestamp, toTimestamp uint64, verbose bool) (*PodInflows, error) {
+	podAddress := common.HexToAddress(eigenpodAddress)
+	pod, err := EigenPod.NewEigenPod(podAddress, eth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach eigenpod: %w", err)
+	}
+
+	head, err := beaconClient.GetBlock(ctx, "head")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch head beacon block: %w", err)
+	}
+	if head == nil {
+		return nil, fmt.Errorf("beacon node returned no head block")
+	}
+	headSlot, headTimestamp, _, err := blockTiming(head)
+	if err != nil {
+		return nil, err
+	}
+	genesisTime := headTimestamp - headSlot*beacon.SECONDS_PER_SLOT
+
+	if fromTimestamp == 0 {
+		fromTimestamp, err = pod.LastCheckpointTimestamp(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch last checkpoint timestamp: %w", err)
+		}
+		if fromTimestamp == 0 {
+			return nil, fmt.Errorf("pod has never completed a checkpoint; pass a start timestamp")
+		}
+	}
+	if toTimestamp == 0 {
+		toTimestamp, err = pod.CurrentCheckpointTimestamp(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch current checkpoint timestamp: %w", err)
+		}
+		if toTimestamp == 0 {
+			toTimestamp = headTimestamp
+		}
+	}
+	if fromTimestamp < genesisTime || toTimestamp <= fromTimestamp || toTimestamp > headTimestamp {
+		return nil, fmt.Errorf("invalid range: timestamps must be increasing and between genesis (%d) and head (%d)", genesisTime, headTimestamp)
+	}
+
+	fromSlot := (fromTimestamp - genesisTime) / beacon.SECONDS_PER_SLOT
+	toSlot := (toTimestamp - genesisTime) / beacon.SECONDS_PER_SLOT
+
+	fromBeaconBlock, err := beaconClient.GetBlock(ctx, fmt.Sprintf("%d", fromSlot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch beacon block at slot %d: %w", fromSlot, err)
+	}
+	if fromBeaconBlock == nil {
+		return nil, fmt.Errorf("no beacon block at slot %d (timestamp %d)", fromSlot, fromTimestamp)
+	}
+	_, _, fromBlock, err := blockTiming(fromBeaconBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawals, lastBlock, err := getPodWithdrawals(ctx, beaconClient, podAddress, fromSlot+1, toSlot, verbose)
+	if err != nil {
+		return nil, err
+	}
+
+	inflows := &PodInflows{
+		FromTimestamp: fromTimestamp,
+		ToTimestamp:   toTimestamp,
+		FromBlock:     fromBlock,
+		ToBlock:       max(fromBlock, lastBlock),
+		Withdrawals:   []PodWithdrawal{},
+	}
+
+	// a withdrawal is a full exit if the validator was already withdrawable
+	withdrawableEpochs := map[uint64]uint64{}
+	for _, withdrawal := range withdrawals {
+		if _, ok := withdrawableEpochs[withdrawal.ValidatorIndex]; ok {
+			continue
+		}
+		validator, err := beaconClient.GetValidator(ctx, withdrawal.ValidatorIndex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch validator %d: %w", withdrawal.ValidatorIndex, err)
+		}
+		withdrawableEpochs[withdrawal.ValidatorIndex] = uint64(validator.Validator.WithdrawableEpoch)
+	}
+	inflows.addWithdrawals(withdrawals, withdrawableEpochs)
+
+	balanceBefore, err := eth.BalanceAt(ctx, podAddress, new(big.Int).SetUint64(inflows.FromBlock))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pod balance at block %d (this requires an archive node for older blocks): %w", inflows.FromBlock, err)
+	}
+	balanceAfter, err := eth.BalanceAt(ctx, podAddress, new(big.Int).SetUint64(inflows.ToBlock))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pod balance at block %d: %w", inflows.ToBlock, err)
+	}
+
+	outflowsWei := big.NewInt(0)
+	if inflows.ToBlock > inflows.FromBlock {
+		logs, err := utils.FilterLogsChunked(ctx, eth, ethereum.FilterQuery{Addresses: []common.Address{podAddress}}, inflows.FromBlock+1, inflows.ToBlock, utils.DEFAULT_LOG_FILTER_CHUNK)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch eigenpod events: %w", err)
+		}
+		for i := range logs {
+			event, ok := utils.DecodeLog(&logs[i])
+			if !ok || event.Name != "RestakedBeaconChainETHWithdrawn" {
+				continue
+			}
+			amount, ok := event.ArgBig("amount")
+			if !ok {
+				return nil, fmt.Errorf("RestakedBeaconChainETHWithdrawn event in tx %s has no valid amount", logs[i].TxHash)
+			}
+			outflowsWei.Add(outflowsWei, amount)
+		}
+	}
+
+	inflows.settle(balanceBefore, balanceAfter, outflowsWei)
+	return inflows, nil
+}
+
+// addWithdrawals records `withdrawals`, as full exits if they were paid once the validator was
+// withdrawable (`withdrawableEpochs`, by validator index), or else as partial withdrawals.
+func (inflows *PodInflows) addWithdrawals(withdrawals []PodWithdrawal, withdrawableEpochs map[uint64]uint64) {
+	for _, withdrawal := range withdrawals {
+		withdrawal.FullExit = withdrawal.Slot/beacon.SLOTS_PER_EPOCH >= withdrawableEpochs[withdrawal.ValidatorIndex]
+		if withdrawal.FullExit {
+			inflows.FullExitsGwei += withdrawal.AmountGwei
+		} else {
+			inflows.PartialWithdrawalsGwei += withdrawal.AmountGwei
+		}
+		inflows.Withdrawals = append(inflows.Withdrawals, withdrawal)
+	}
+}
+
+// settle sets the pod's balance change over the range, and attributes what the withdrawals and
+// outflows don't explain to NonBeaconWei.
+func (inflows *PodInflows) settle(balanceBefore, balanceAfter, outflowsWei *big.Int) {
+	inflows.PodBalanceDeltaWei = new(big.Int).Sub(balanceAfter, balanceBefore)
+	inflows.OutflowsWei = outflowsWei
+
+	withdrawnWei := utils.IGweiToWei(new(big.Int).SetUint64(inflows.PartialWithdrawalsGwei + inflows.FullExitsGwei))
+	inflows.NonBeaconWei = new(big.Int).Sub(inflows.PodBalanceDeltaWei, withdrawnWei)
+	inflows.NonBeaconWei.Add(inflows.NonBeaconWei, inflows.OutflowsWei)
+}
+
+// blockTiming returns the slot, execution timestamp and execution block number of `block`.
+func blockTiming(block *spec.VersionedSignedBeaconBlock) (slot uint64, timestamp uint64, blockNumber uint64, err error) {
+	beaconSlot, err := block.Slot()
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to read block slot: %w", err)
+	}
+	payload, err := block.ExecutionPayload()
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to read execution payload at slot %d: %w", beaconSlot, err)
+	}
+	timestamp, err = payload.Timestamp()
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to read execution timestamp at slot %d: %w", beaconSlot, err)
+	}
+	blockNumber, err = payload.BlockNumber()
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to read execution block number at slot %d: %w", beaconSlot, err)
+	}
+	return uint64(beaconSlot), timestamp, blockNumber, nil
+}
+
+// getPodWithdrawals walks the beacon blocks in [fromSlot, toSlot] and returns the withdrawals they
+// paid to `podAddress`, in order, and the execution block number of the last block (0 if every slot
+// was missed). Blocks are fetched by a pool of workers, which only keep the pod's withdrawals, so
+// that long ranges don't hold every block in memory.
+func getPodWithdrawals(ctx context.Context, beaconClient utils.BeaconClient, podAddress common.Address, fromSlot, toSlot uint64, verbose bool) ([]PodWithdrawal, uint64, error) {
+	if toSlot < fromSlot {
+		return nil, 0, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu          sync.Mutex
+		withdrawals []PodWithdrawal
+		lastSlot    uint64
+		lastBlock   uint64
+		firstErr    error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	slots := make(chan uint64)
+	var wg sync.WaitGroup
+	for range DEFAULT_BLOCK_FETCH_WORKERS {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for slot := range slots {
+				block, err := beaconClient.GetBlock(ctx, fmt.Sprintf("%d", slot))
+				if err != nil {
+					fail(fmt.Errorf("failed to fetch beacon block at slot %d: %w", slot, err))
+					continue
+				}
+				if block == nil {
+					// missed slot
+					continue
+				}
+				blockNumber, paid, err := podWithdrawalsIn(block, podAddress)
+				if err != nil {
+					fail(err)
+					continue
+				}
+
+				mu.Lock()
+				withdrawals = append(withdrawals, paid...)
+				if slot >= lastSlot {
+					lastSlot, lastBlock = slot, blockNumber
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for slot := fromSlot; slot <= toSlot; slot++ {
+		if verbose && (slot-fromSlot)%1000 == 0 {
+			color.Cyan("fetching beacon blocks %d/%d...", slot-fromSlot, toSlot-fromSlot+1)
+		}
+		select {
+		case slots <- slot:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(slots)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, 0, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	sort.SliceStable(withdrawals, func(i, j int) bool {
+		return withdrawals[i].Slot < withdrawals[j].Slot
+	})
+	return withdrawals, lastBlock, nil
+}
+
+// podWithdrawalsIn returns the execution block number of `block`, and the withdrawals it paid to
+// `podAddress`.
+func podWithdrawalsIn(block *spec.VersionedSignedBeaconBlock, podAddress common.Address) (uint64, []PodWithdrawal, error) {
+	slot, _, blockNumber, err := blockTiming(block)
+	if err != nil {
+		return 0, nil, err
+	}
+	withdrawals, err := block.Withdrawals()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read withdrawals at slot %d: %w", slot, err)
+	}
+
+	paid := []PodWithdrawal{}
+	for _, withdrawal := range withdrawals {
+		if common.Address(withdrawal.Address) != podAddress {
+			continue
+		}
+		paid = append(paid, PodWithdrawal{
+			Slot:           slot,
+			BlockNumber:    blockNumber,
+			ValidatorIndex: uint64(withdrawal.ValidatorIndex),
+			AmountGwei:     uint64(withdrawal.Amount),
+		})
+	}
+	return blockNumber, paid, nil
+}
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testPod   = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	otherAddr = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+)
+
+// blocksBeaconClient serves `blocks` by slot; other slots are missed.
+type blocksBeaconClient struct {
+	utils.BeaconClient
+	blocks  map[uint64]*spec.VersionedSignedBeaconBlock
+	failing uint64
+}
+
+func (c *blocksBeaconClient) GetBlock(ctx context.Context, blockId string) (*spec.VersionedSignedBeaconBlock, error) {
+	slot, err := strconv.ParseUint(blockId, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if c.failing != 0 && slot == c.failing {
+		return nil, errors.New("unavailable")
+	}
+	return c.blocks[slot], nil
+}
+
+func newWithdrawalsBlock(slot uint64, withdrawals ...*capella.Withdrawal) *spec.VersionedSignedBeaconBlock {
+	return &spec.VersionedSignedBeaconBlock{
+		Version: spec.DataVersionDeneb,
+		Deneb: &deneb.SignedBeaconBlock{
+			Message: &deneb.BeaconBlock{
+				Slot: phase0.Slot(slot),
+				Body: &deneb.BeaconBlockBody{
+					ExecutionPayload: &deneb.ExecutionPayload{
+						BlockNumber: 1000 + slot,
+						Timestamp:   slot * beacon.SECONDS_PER_SLOT,
+						Withdrawals: withdrawals,
+					},
+				},
+			},
+		},
+	}
+}
+
+func newWithdrawal(to common.Address, validatorIndex uint64, amountGwei uint64) *capella.Withdrawal {
+	return &capella.Withdrawal{
+		ValidatorIndex: phase0.ValidatorIndex(validatorIndex),
+		Address:        bellatrix.ExecutionAddress(to),
+		Amount:         phase0.Gwei(amountGwei),
+	}
+}
+
+func TestGetPodWithdrawals(t *testing.T) {
+	client := &blocksBeaconClient{blocks: map[uint64]*spec.VersionedSignedBeaconBlock{
+		10: newWithdrawalsBlock(10, newWithdrawal(testPod, 1, 100), newWithdrawal(otherAddr, 2, 200)),
+		11: newWithdrawalsBlock(11),
+		// 12 was missed
+		13: newWithdrawalsBlock(13, newWithdrawal(testPod, 1, 300), newWithdrawal(testPod, 3, 400)),
+		// outside of the range
+		20: newWithdrawalsBlock(20, newWithdrawal(testPod, 1, 500)),
+	}}
+	ctx := context.Background()
+
+	withdrawals, lastBlock, err := getPodWithdrawals(ctx, client, testPod, 10, 14, false)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1013), lastBlock)
+	assert.Equal(t, []PodWithdrawal{
+		{Slot: 10, BlockNumber: 1010, ValidatorIndex: 1, AmountGwei: 100},
+		{Slot: 13, BlockNumber: 1013, ValidatorIndex: 1, AmountGwei: 300},
+		{Slot: 13, BlockNumber: 1013, ValidatorIndex: 3, AmountGwei: 400},
+	}, withdrawals)
+
+	// only missed slots
+	withdrawals, lastBlock, err = getPodWithdrawals(ctx, client, testPod, 14, 19, false)
+	require.NoError(t, err)
+	assert.Empty(t, withdrawals)
+	assert.Equal(t, uint64(0), lastBlock)
+
+	// empty range
+	withdrawals, lastBlock, err = getPodWithdrawals(ctx, client, testPod, 11, 10, false)
+	require.NoError(t, err)
+	assert.Empty(t, withdrawals)
+	assert.Equal(t, uint64(0), lastBlock)
+
+	client.failing = 12
+	_, _, err = getPodWithdrawals(ctx, client, testPod, 10, 20, false)
+	assert.ErrorContains(t, err, "slot 12")
+}
+
+func TestPodInflowsAccounting(t *testing.T) {
+	withdrawableEpoch := uint64(3)
+	withdrawals := []PodWithdrawal{
+		{Slot: 10, ValidatorIndex: 1, AmountGwei: 1_000_000},
+		// the last slot before validator 2 is withdrawable
+		{Slot: withdrawableEpoch*beacon.SLOTS_PER_EPOCH - 1, ValidatorIndex: 2, AmountGwei: 2_000_000},
+		{Slot: withdrawableEpoch * beacon.SLOTS_PER_EPOCH, ValidatorIndex: 2, AmountGwei: 32_000_000_000},
+	}
+	withdrawableEpochs := map[uint64]uint64{1: utils.FAR_FUTURE_EPOCH, 2: withdrawableEpoch}
+
+	tests := []struct {
+		name          string
+		before, after *big.Int
+		outflows      *big.Int
+		nonBeacon     *big.Int
+	}{
+		{
+			name:      "only withdrawals",
+			before:    gweiToWei(5),
+			after:     gweiToWei(32_003_000_005),
+			outflows:  big.NewInt(0),
+			nonBeacon: big.NewInt(0),
+		},
+		{
+			name:      "with rewards sent to the pod",
+			before:    big.NewInt(0),
+			after:     new(big.Int).Add(gweiToWei(32_003_000_000), big.NewInt(17)),
+			outflows:  big.NewInt(0),
+			nonBeacon: big.NewInt(17),
+		},
+		{
+			name:      "with completed withdrawals",
+			before:    big.NewInt(params.Ether),
+			after:     gweiToWei(1_003_000_000),
+			outflows:  gweiToWei(32_000_000_000),
+			nonBeacon: big.NewInt(0),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inflows := &PodInflows{Withdrawals: []PodWithdrawal{}}
+			inflows.addWithdrawals(withdrawals, withdrawableEpochs)
+			inflows.settle(test.before, test.after, test.outflows)
+
+			assert.Equal(t, uint64(3_000_000), inflows.PartialWithdrawalsGwei)
+			assert.Equal(t, uint64(32_000_000_000), inflows.FullExitsGwei)
+			assert.Equal(t, []bool{false, false, true}, []bool{
+				inflows.Withdrawals[0].FullExit,
+				inflows.Withdrawals[1].FullExit,
+				inflows.Withdrawals[2].FullExit,
+			})
+			assert.Equal(t, 0, test.nonBeacon.Cmp(inflows.NonBeaconWei), "NonBeaconWei = %s", inflows.NonBeaconWei)
+
+			// PodBalanceDeltaWei = (PartialWithdrawalsGwei + FullExitsGwei) * 1 gwei + NonBeaconWei - OutflowsWei
+			withdrawnWei := gweiToWei(inflows.PartialWithdrawalsGwei + inflows.FullExitsGwei)
+			explained := new(big.Int).Add(withdrawnWei, inflows.NonBeaconWei)
+			explained.Sub(explained, inflows.OutflowsWei)
+			assert.Equal(t, 0, explained.Cmp(inflows.PodBalanceDeltaWei))
+			assert.Equal(t, 0, new(big.Int).Sub(test.after, test.before).Cmp(inflows.PodBalanceDeltaWei))
+		})
+	}
+}
+
+func gweiToWei(gwei uint64) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(gwei), big.NewInt(params.GWei))
+}
@@ -0,0 +1,316 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type PreflightIssue struct {
+	// index into the list of requests that was checked
+	Request int
+	Message string
+}
+
+// PreflightReport lists the problems found with a set of predeploy requests before they were sent.
+//
+// Errors are requests the beacon chain will drop given the current state; the predeploy fee
+// for them would be lost. Warnings are requests that will be processed, but possibly not how
+// the user expects.
+type PreflightReport struct {
+	Errors   []PreflightIssue
+	Warnings []PreflightIssue
+}
+
+func (r *PreflightReport) addError(request int, format string, a ...any) {
+	r.Errors = append(r.Errors, PreflightIssue{Request: request, Message: fmt.Sprintf(format, a...)})
+}
+
+func (r *PreflightReport) addWarning(request int, format string, a ...any) {
+	r.Warnings = append(r.Warnings, PreflightIssue{Request: request, Message: fmt.Sprintf(format, a...)})
+}
+
+// podValidatorsByPubkey maps every validator pointed at `eigenpodAddress` by its pubkey.
+func podValidatorsByPubkey(eigenpodAddress string, state *spec.VersionedBeaconState) (map[phase0.BLSPubKey]utils.ValidatorWithIndex, error) {
+	podValidators, err := utils.FindAllValidatorsForEigenpod(eigenpodAddress, state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find validators for eigenpod: %w", err)
+	}
+
+	out := make(map[phase0.BLSPubKey]utils.ValidatorWithIndex, len(podValidators))
+	for _, v := range podValidators {
+		out[v.Validator.PublicKey] = v
+	}
+	return out, nil
+}
+
+// ValidateConsolidationRequests checks `requests` against the EIP-7251 processing rules
+// (`process_consolidation_request`) in `state`.
+func ValidateConsolidationRequests(
+	eigenpodAddress string,
+	state *spec.VersionedBeaconState,
+	requests []EigenPod.IEigenPodTypesConsolidationRequest,
+) (*PreflightReport, error) {
+	report := &PreflightReport{}
+
+	epoch, err := utils.CurrentEpoch(state)
+	if err != nil {
+		return nil, err
+	}
+
+	podValidators, err := podValidatorsByPubkey(eigenpodAddress, state)
+	if err != nil {
+		return nil, err
+	}
+
+	pendingBalancesToWithdraw, err := utils.GetPendingBalancesToWithdraw(state)
+	if err != nil {
+		return nil, err
+	}
+
+	pendingConsolidations, err := state.PendingConsolidations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending consolidations from beacon state: %w", err)
+	}
+
+	totalActiveBalance, err := utils.GetTotalActiveBalance(state)
+	if err != nil {
+		return nil, err
+	}
+	consolidationChurn := utils.GetConsolidationChurnLimit(totalActiveBalance)
+
+	pod := common.HexToAddress(eigenpodAddress)
+	lookup := func(pubkey []byte) (utils.ValidatorWithIndex, bool) {
+		if len(pubkey) != len(phase0.BLSPubKey{}) {
+			return utils.ValidatorWithIndex{}, false
+		}
+		v, ok := podValidators[phase0.BLSPubKey(pubkey)]
+		return v, ok
+	}
+
+	// how many times each validator is used as a consolidation source
+	sourceCounts := map[uint64]int{}
+	for _, request := range requests {
+		if source, ok := lookup(request.SrcPubkey); ok && !bytes.Equal(request.SrcPubkey, request.TargetPubkey) {
+			sourceCounts[source.Index]++
+		}
+	}
+
+	// validators which are switched to 0x02 by an earlier request
+	switching := map[uint64]bool{}
+
+	numConsolidations := uint64(0)
+	targetBalances := map[uint64]uint64{}
+	for i, request := range requests {
+		source, ok := lookup(request.SrcPubkey)
+		if !ok {
+			report.addError(i, "source 0x%s is not a validator pointed at pod %s", common.Bytes2Hex(request.SrcPubkey), pod)
+			continue
+		}
+
+		// Switch requests (source == target)
+		if bytes.Equal(request.SrcPubkey, request.TargetPubkey) {
+			numErrors := len(report.Errors)
+			switch {
+			case source.Validator.WithdrawalCredentials[0] == utils.CompoundingWithdrawalPrefix:
+				report.addError(i, "validator #%d already has 0x02 credentials", source.Index)
+			case source.Validator.WithdrawalCredentials[0] != utils.ETH1WithdrawalPrefix:
+				report.addError(i, "validator #%d does not have 0x01 credentials", source.Index)
+			case !utils.IsActiveValidator(source.Validator, epoch):
+				report.addError(i, "validator #%d is not active on the beacon chain", source.Index)
+			case utils.IsExitingValidator(source.Validator):
+				report.addError(i, "validator #%d is exiting", source.Index)
+			}
+			if len(report.Errors) == numErrors {
+				switching[source.Index] = true
+			}
+			continue
+		}
+
+		numConsolidations++
+
+		target, ok := lookup(request.TargetPubkey)
+		if !ok {
+			report.addError(i, "target 0x%s is not a validator pointed at pod %s", common.Bytes2Hex(request.TargetPubkey), pod)
+			continue
+		}
+
+		switch {
+		case source.Validator.WithdrawalCredentials[0] != utils.ETH1WithdrawalPrefix && source.Validator.WithdrawalCredentials[0] != utils.CompoundingWithdrawalPrefix:
+			report.addError(i, "source #%d does not have execution (0x01 or 0x02) credentials", source.Index)
+		case target.Validator.WithdrawalCredentials[0] != utils.CompoundingWithdrawalPrefix && !switching[target.Index]:
+			report.addError(i, "target #%d does not have 0x02 credentials. Switch it first with `consolidate switch`", target.Index)
+		case !utils.IsActiveValidator(source.Validator, epoch):
+			report.addError(i, "source #%d is not active on the beacon chain", source.Index)
+		case !utils.IsActiveValidator(target.Validator, epoch):
+			report.addError(i, "target #%d is not active on the beacon chain", target.Index)
+		case utils.IsExitingValidator(source.Validator):
+			report.addError(i, "source #%d is exiting", source.Index)
+		case utils.IsExitingValidator(target.Validator):
+			report.addError(i, "target #%d is exiting", target.Index)
+		case !utils.HasBeenActiveLongEnoughToExit(source.Validator, epoch):
+			report.addError(i, "source #%d has been active for less than %d epochs (activated at epoch %d, currently %d)", source.Index, utils.SHARD_COMMITTEE_PERIOD, source.Validator.ActivationEpoch, epoch)
+		case pendingBalancesToWithdraw[source.Index] > 0:
+			report.addError(i, "source #%d has %d gwei of pending partial withdrawals", source.Index, pendingBalancesToWithdraw[source.Index])
+		case sourceCounts[source.Index] > 1:
+			report.addError(i, "source #%d is consolidated more than once", source.Index)
+		case sourceCounts[target.Index] > 0:
+			report.addError(i, "target #%d is also being consolidated away", target.Index)
+		}
+
+		if _, seen := targetBalances[target.Index]; !seen {
+			targetBalances[target.Index] = uint64(target.Validator.EffectiveBalance)
+		}
+		targetBalances[target.Index] += uint64(source.Validator.EffectiveBalance)
+		if targetBalances[target.Index] > utils.MAX_EFFECTIVE_BALANCE_ELECTRA {
+			report.addWarning(i, "target #%d would have %d gwei effective balance, over the %d gwei maximum. The excess will be withdrawn to the pod", target.Index, targetBalances[target.Index], utils.MAX_EFFECTIVE_BALANCE_ELECTRA)
+		}
+	}
+
+	if numConsolidations > 0 {
+		if consolidationChurn <= utils.MIN_ACTIVATION_BALANCE {
+			report.addError(-1, "the consolidation churn limit (%d gwei) is not above MIN_ACTIVATION_BALANCE, so the beacon chain is currently ignoring all consolidations", consolidationChurn)
+		}
+
+		remainingQueue := utils.PENDING_CONSOLIDATIONS_LIMIT - uint64(len(pendingConsolidations))
+		if remainingQueue == 0 {
+			report.addError(-1, "the pending consolidations queue is full, so the beacon chain is currently ignoring all consolidations")
+		} else if numConsolidations > remainingQueue {
+			report.addWarning(-1, "only %d slot(s) remain in the pending consolidations queue; some of your %d consolidation(s) may be dropped", remainingQueue, numConsolidations)
+		}
+	}
+
+	return report, nil
+}
+
+type WithdrawableAmount struct {
+	Validator uint64
+
+	// the amount requested, or 0 for a full exit (gwei)
+	Requested uint64
+
+	// the amount that will actually be withdrawn once the request is processed (gwei)
+	Withdrawable uint64
+}
+
+type WithdrawalPreflightReport struct {
+	PreflightReport
+
+	// one entry per request that the beacon chain will process, in request order
+	Withdrawals []WithdrawableAmount
+}
+
+// ValidateWithdrawalRequests checks `requests` against the EIP-7002 processing rules
+// (`process_withdrawal_request`) in `state`, and computes how much each request will withdraw.
+func ValidateWithdrawalRequests(
+	eigenpodAddress string,
+	state *spec.VersionedBeaconState,
+	requests []EigenPod.IEigenPodTypesWithdrawalRequest,
+) (*WithdrawalPreflightReport, error) {
+	report := &WithdrawalPreflightReport{}
+
+	epoch, err := utils.CurrentEpoch(state)
+	if err != nil {
+		return nil, err
+	}
+
+	podValidators, err := podValidatorsByPubkey(eigenpodAddress, state)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := state.ValidatorBalances()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validator balances from beacon state: %w", err)
+	}
+
+	pendingBalancesToWithdraw, err := utils.GetPendingBalancesToWithdraw(state)
+	if err != nil {
+		return nil, err
+	}
+
+	pendingPartialWithdrawals, err := state.PendingPartialWithdrawals()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending partial withdrawals from beacon state: %w", err)
+	}
+
+	pod := common.HexToAddress(eigenpodAddress)
+	exiting := map[uint64]bool{}
+	numPartials := uint64(0)
+	for i, request := range requests {
+		var validator utils.ValidatorWithIndex
+		ok := len(request.Pubkey) == len(phase0.BLSPubKey{})
+		if ok {
+			validator, ok = podValidators[phase0.BLSPubKey(request.Pubkey)]
+		}
+		if !ok {
+			report.addError(i, "0x%s is not a validator pointed at pod %s", common.Bytes2Hex(request.Pubkey), pod)
+			continue
+		}
+
+		v := validator.Validator
+		balance := uint64(balances[validator.Index])
+		isFullExit := request.AmountGwei == 0
+
+		numErrors := len(report.Errors)
+		switch {
+		case !utils.IsActiveValidator(v, epoch):
+			report.addError(i, "validator #%d is not active on the beacon chain", validator.Index)
+		case utils.IsExitingValidator(v) || exiting[validator.Index]:
+			report.addError(i, "validator #%d is already exiting", validator.Index)
+		case !utils.HasBeenActiveLongEnoughToExit(v, epoch):
+			report.addError(i, "validator #%d has been active for less than %d epochs (activated at epoch %d, currently %d)", validator.Index, utils.SHARD_COMMITTEE_PERIOD, v.ActivationEpoch, epoch)
+		case isFullExit && pendingBalancesToWithdraw[validator.Index] > 0:
+			report.addError(i, "validator #%d has %d gwei of pending partial withdrawals, which must be processed before it can fully exit", validator.Index, pendingBalancesToWithdraw[validator.Index])
+		case !isFullExit && v.WithdrawalCredentials[0] != utils.CompoundingWithdrawalPrefix:
+			report.addError(i, "validator #%d does not have 0x02 credentials; partial withdrawals are only supported for compounding validators", validator.Index)
+		case !isFullExit && uint64(v.EffectiveBalance) < utils.MIN_ACTIVATION_BALANCE:
+			report.addError(i, "validator #%d has an effective balance below %d gwei", validator.Index, utils.MIN_ACTIVATION_BALANCE)
+		case !isFullExit && balance <= utils.MIN_ACTIVATION_BALANCE+pendingBalancesToWithdraw[validator.Index]:
+			report.addError(i, "validator #%d has no balance above %d gwei left to withdraw (balance: %d gwei, pending partial withdrawals: %d gwei)", validator.Index, utils.MIN_ACTIVATION_BALANCE, balance, pendingBalancesToWithdraw[validator.Index])
+		}
+		if len(report.Errors) != numErrors {
+			continue
+		}
+
+		if isFullExit {
+			exiting[validator.Index] = true
+			report.Withdrawals = append(report.Withdrawals, WithdrawableAmount{
+				Validator:    validator.Index,
+				Withdrawable: balance,
+			})
+			continue
+		}
+
+		numPartials++
+		excess := balance - utils.MIN_ACTIVATION_BALANCE - pendingBalancesToWithdraw[validator.Index]
+		withdrawable := min(excess, request.AmountGwei)
+		if withdrawable < request.AmountGwei {
+			report.addWarning(i, "validator #%d can only withdraw %d of the %d gwei requested without dropping below %d gwei", validator.Index, withdrawable, request.AmountGwei, utils.MIN_ACTIVATION_BALANCE)
+		}
+
+		// later requests for the same validator see this one as pending
+		pendingBalancesToWithdraw[validator.Index] += withdrawable
+		report.Withdrawals = append(report.Withdrawals, WithdrawableAmount{
+			Validator:    validator.Index,
+			Requested:    request.AmountGwei,
+			Withdrawable: withdrawable,
+		})
+	}
+
+	if numPartials > 0 {
+		remainingQueue := utils.PENDING_PARTIAL_WITHDRAWALS_LIMIT - uint64(len(pendingPartialWithdrawals))
+		if remainingQueue == 0 {
+			report.addError(-1, "the pending partial withdrawals queue is full, so the beacon chain is currently ignoring all partial withdrawals")
+		} else if numPartials > remainingQueue {
+			report.addWarning(-1, "only %d slot(s) remain in the pending partial withdrawals queue; some of your %d partial withdrawal(s) may be dropped", remainingQueue, numPartials)
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,335 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/electra"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	preflightEpoch = uint64(1000)
+	ethGwei        = uint64(1_000_000_000)
+)
+
+// newPreflightState returns an Electra state with these validators, all active since epoch 0:
+//
+//	0: the pod's, 0x01, 32 ETH
+//	1: the pod's, 0x02, 40 ETH (32 ETH effective)
+//	2: the pod's, 0x02, 32 ETH
+//	3: the pod's, 0x01, 32 ETH
+//	4: another address's, 0x02, 32 ETH
+//	5: another address's, with enough stake for the consolidation churn to be above 32 ETH
+func newPreflightState() *electra.BeaconState {
+	state := &electra.BeaconState{
+		GenesisValidatorsRoot: phase0.Root{0xe1},
+		Slot:                  phase0.Slot(preflightEpoch * beacon.SLOTS_PER_EPOCH),
+	}
+	add := func(address common.Address, prefix byte, balanceGwei, effectiveBalanceGwei uint64) {
+		credentials := make([]byte, 32)
+		credentials[0] = prefix
+		copy(credentials[12:], address[:])
+		state.Validators = append(state.Validators, &phase0.Validator{
+			PublicKey:             preflightPubkey(uint64(len(state.Validators))),
+			WithdrawalCredentials: credentials,
+			EffectiveBalance:      phase0.Gwei(effectiveBalanceGwei),
+			ExitEpoch:             phase0.Epoch(utils.FAR_FUTURE_EPOCH),
+			WithdrawableEpoch:     phase0.Epoch(utils.FAR_FUTURE_EPOCH),
+		})
+		state.Balances = append(state.Balances, phase0.Gwei(balanceGwei))
+	}
+	add(testPod, utils.ETH1WithdrawalPrefix, 32*ethGwei, 32*ethGwei)
+	add(testPod, utils.CompoundingWithdrawalPrefix, 40*ethGwei, 32*ethGwei)
+	add(testPod, utils.CompoundingWithdrawalPrefix, 32*ethGwei, 32*ethGwei)
+	add(testPod, utils.ETH1WithdrawalPrefix, 32*ethGwei, 32*ethGwei)
+	add(otherAddr, utils.CompoundingWithdrawalPrefix, 32*ethGwei, 32*ethGwei)
+	add(otherAddr, utils.CompoundingWithdrawalPrefix, 20_000_000*ethGwei, 20_000_000*ethGwei)
+	return state
+}
+
+func preflightPubkey(index uint64) phase0.BLSPubKey {
+	return phase0.BLSPubKey{0xb0, byte(index)}
+}
+
+func consolidation(source, target uint64) EigenPod.IEigenPodTypesConsolidationRequest {
+	sourcePubkey, targetPubkey := preflightPubkey(source), preflightPubkey(target)
+	return EigenPod.IEigenPodTypesConsolidationRequest{SrcPubkey: sourcePubkey[:], TargetPubkey: targetPubkey[:]}
+}
+
+func withdrawal(validator, amountGwei uint64) EigenPod.IEigenPodTypesWithdrawalRequest {
+	pubkey := preflightPubkey(validator)
+	return EigenPod.IEigenPodTypesWithdrawalRequest{Pubkey: pubkey[:], AmountGwei: amountGwei}
+}
+
+// validatePreflight runs `validate` on `state` with a fresh withdrawal address index, as states of
+// different test cases share a slot.
+func validatePreflight[T any](t *testing.T, state *electra.BeaconState, validate func(string, *spec.VersionedBeaconState) (T, error)) T {
+	previous := utils.SharedWithdrawalAddressIndex()
+	utils.UseWithdrawalAddressIndex(utils.NewWithdrawalAddressIndex())
+	t.Cleanup(func() { utils.UseWithdrawalAddressIndex(previous) })
+
+	report, err := validate(testPod.Hex(), &spec.VersionedBeaconState{Version: spec.DataVersionElectra, Electra: state})
+	require.NoError(t, err)
+	return report
+}
+
+// assertIssues checks that `issues` are those of `want`: the request each is about, and part of its
+// message.
+func assertIssues(t *testing.T, want []PreflightIssue, issues []PreflightIssue) {
+	t.Helper()
+	require.Len(t, issues, len(want), "%v", issues)
+	for i := range want {
+		assert.Equal(t, want[i].Request, issues[i].Request, "%v", issues[i])
+		assert.Contains(t, issues[i].Message, want[i].Message)
+	}
+}
+
+func TestValidateConsolidationRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(state *electra.BeaconState)
+		requests []EigenPod.IEigenPodTypesConsolidationRequest
+		errors   []PreflightIssue
+		warnings []PreflightIssue
+	}{
+		{
+			name:     "switch to 0x02",
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(0, 0), consolidation(3, 3)},
+		},
+		{
+			name:     "switch a 0x02 validator",
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(1, 1)},
+			errors:   []PreflightIssue{{0, "validator #1 already has 0x02 credentials"}},
+		},
+		{
+			name:     "switch an exiting validator",
+			modify:   func(state *electra.BeaconState) { state.Validators[0].ExitEpoch = phase0.Epoch(preflightEpoch + 10) },
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(0, 0)},
+			errors:   []PreflightIssue{{0, "validator #0 is exiting"}},
+		},
+		{
+			name:     "consolidate into a 0x02 validator",
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(0, 2), consolidation(3, 1)},
+		},
+		{
+			name:     "consolidate into a 0x01 validator",
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(0, 3)},
+			errors:   []PreflightIssue{{0, "target #3 does not have 0x02 credentials"}},
+		},
+		{
+			name:     "consolidate into a validator switched by an earlier request",
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(3, 3), consolidation(0, 3)},
+		},
+		{
+			name:     "another address's validators",
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(4, 2), consolidation(0, 4)},
+			errors: []PreflightIssue{
+				{0, "is not a validator pointed at pod"},
+				{1, "target 0xb004"},
+			},
+		},
+		{
+			name: "a source activated recently",
+			modify: func(state *electra.BeaconState) {
+				state.Validators[0].ActivationEpoch = phase0.Epoch(preflightEpoch - 10)
+			},
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(0, 2)},
+			errors:   []PreflightIssue{{0, "source #0 has been active for less than 256 epochs"}},
+		},
+		{
+			name: "an inactive target",
+			modify: func(state *electra.BeaconState) {
+				state.Validators[2].ActivationEpoch = phase0.Epoch(utils.FAR_FUTURE_EPOCH)
+			},
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(0, 2)},
+			errors:   []PreflightIssue{{0, "target #2 is not active"}},
+		},
+		{
+			name: "a source with pending partial withdrawals",
+			modify: func(state *electra.BeaconState) {
+				state.PendingPartialWithdrawals = []*electra.PendingPartialWithdrawal{{ValidatorIndex: 1, Amount: phase0.Gwei(ethGwei)}}
+			},
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(1, 2)},
+			errors:   []PreflightIssue{{0, "source #1 has 1000000000 gwei of pending partial withdrawals"}},
+		},
+		{
+			name:     "a source consolidated twice",
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(0, 2), consolidation(0, 1)},
+			errors:   []PreflightIssue{{0, "source #0 is consolidated more than once"}, {1, "source #0 is consolidated more than once"}},
+		},
+		{
+			name:     "a target consolidated away",
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(0, 2), consolidation(2, 1)},
+			errors:   []PreflightIssue{{0, "target #2 is also being consolidated away"}},
+		},
+		{
+			name: "over the maximum effective balance",
+			modify: func(state *electra.BeaconState) {
+				state.Validators[0].EffectiveBalance = phase0.Gwei(1000 * ethGwei)
+				state.Validators[2].EffectiveBalance = phase0.Gwei(1000 * ethGwei)
+				state.Validators[3].EffectiveBalance = phase0.Gwei(100 * ethGwei)
+			},
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(0, 2), consolidation(3, 2)},
+			warnings: []PreflightIssue{{1, "target #2 would have 2100000000000 gwei effective balance"}},
+		},
+		{
+			name:     "consolidation churn too low",
+			modify:   func(state *electra.BeaconState) { state.Validators[5].ExitEpoch = phase0.Epoch(preflightEpoch) },
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(0, 0), consolidation(0, 2)},
+			errors:   []PreflightIssue{{-1, "consolidation churn limit (0 gwei) is not above MIN_ACTIVATION_BALANCE"}},
+		},
+		{
+			name:     "only switches with the consolidation churn too low",
+			modify:   func(state *electra.BeaconState) { state.Validators[5].ExitEpoch = phase0.Epoch(preflightEpoch) },
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(0, 0)},
+		},
+		{
+			name: "pending consolidations queue almost full",
+			modify: func(state *electra.BeaconState) {
+				state.PendingConsolidations = make([]*electra.PendingConsolidation, utils.PENDING_CONSOLIDATIONS_LIMIT-1)
+			},
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(0, 2), consolidation(3, 1)},
+			warnings: []PreflightIssue{{-1, "only 1 slot(s) remain in the pending consolidations queue"}},
+		},
+		{
+			name: "pending consolidations queue full",
+			modify: func(state *electra.BeaconState) {
+				state.PendingConsolidations = make([]*electra.PendingConsolidation, utils.PENDING_CONSOLIDATIONS_LIMIT)
+			},
+			requests: []EigenPod.IEigenPodTypesConsolidationRequest{consolidation(0, 2)},
+			errors:   []PreflightIssue{{-1, "the pending consolidations queue is full"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newPreflightState()
+			if test.modify != nil {
+				test.modify(state)
+			}
+			report := validatePreflight(t, state, func(pod string, state *spec.VersionedBeaconState) (*PreflightReport, error) {
+				return ValidateConsolidationRequests(pod, state, test.requests)
+			})
+			assertIssues(t, test.errors, report.Errors)
+			assertIssues(t, test.warnings, report.Warnings)
+		})
+	}
+}
+
+func TestValidateWithdrawalRequests(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(state *electra.BeaconState)
+		requests    []EigenPod.IEigenPodTypesWithdrawalRequest
+		errors      []PreflightIssue
+		warnings    []PreflightIssue
+		withdrawals []WithdrawableAmount
+	}{
+		{
+			name:        "full exits",
+			requests:    []EigenPod.IEigenPodTypesWithdrawalRequest{withdrawal(0, 0), withdrawal(1, 0)},
+			withdrawals: []WithdrawableAmount{{Validator: 0, Withdrawable: 32 * ethGwei}, {Validator: 1, Withdrawable: 40 * ethGwei}},
+		},
+		{
+			name:        "a partial withdrawal",
+			requests:    []EigenPod.IEigenPodTypesWithdrawalRequest{withdrawal(1, 5*ethGwei)},
+			withdrawals: []WithdrawableAmount{{Validator: 1, Requested: 5 * ethGwei, Withdrawable: 5 * ethGwei}},
+		},
+		{
+			name:        "a partial withdrawal above the excess balance",
+			requests:    []EigenPod.IEigenPodTypesWithdrawalRequest{withdrawal(1, 10*ethGwei)},
+			warnings:    []PreflightIssue{{0, "validator #1 can only withdraw 8000000000 of the 10000000000 gwei requested"}},
+			withdrawals: []WithdrawableAmount{{Validator: 1, Requested: 10 * ethGwei, Withdrawable: 8 * ethGwei}},
+		},
+		{
+			name:     "partial withdrawals adding up above the excess balance",
+			requests: []EigenPod.IEigenPodTypesWithdrawalRequest{withdrawal(1, 5*ethGwei), withdrawal(1, 5*ethGwei)},
+			warnings: []PreflightIssue{{1, "validator #1 can only withdraw 3000000000 of the 5000000000 gwei requested"}},
+			withdrawals: []WithdrawableAmount{
+				{Validator: 1, Requested: 5 * ethGwei, Withdrawable: 5 * ethGwei},
+				{Validator: 1, Requested: 5 * ethGwei, Withdrawable: 3 * ethGwei},
+			},
+		},
+		{
+			name: "a partial withdrawal with pending partial withdrawals",
+			modify: func(state *electra.BeaconState) {
+				state.PendingPartialWithdrawals = []*electra.PendingPartialWithdrawal{{ValidatorIndex: 1, Amount: phase0.Gwei(6 * ethGwei)}}
+			},
+			requests:    []EigenPod.IEigenPodTypesWithdrawalRequest{withdrawal(1, 5*ethGwei)},
+			warnings:    []PreflightIssue{{0, "validator #1 can only withdraw 2000000000 of the 5000000000 gwei requested"}},
+			withdrawals: []WithdrawableAmount{{Validator: 1, Requested: 5 * ethGwei, Withdrawable: 2 * ethGwei}},
+		},
+		{
+			name: "a full exit with pending partial withdrawals",
+			modify: func(state *electra.BeaconState) {
+				state.PendingPartialWithdrawals = []*electra.PendingPartialWithdrawal{{ValidatorIndex: 1, Amount: phase0.Gwei(ethGwei)}}
+			},
+			requests: []EigenPod.IEigenPodTypesWithdrawalRequest{withdrawal(1, 0)},
+			errors:   []PreflightIssue{{0, "validator #1 has 1000000000 gwei of pending partial withdrawals"}},
+		},
+		{
+			name:     "a partial withdrawal from a 0x01 validator",
+			requests: []EigenPod.IEigenPodTypesWithdrawalRequest{withdrawal(0, ethGwei)},
+			errors:   []PreflightIssue{{0, "validator #0 does not have 0x02 credentials"}},
+		},
+		{
+			name:     "a partial withdrawal without excess balance",
+			requests: []EigenPod.IEigenPodTypesWithdrawalRequest{withdrawal(2, ethGwei)},
+			errors:   []PreflightIssue{{0, "validator #2 has no balance above 32000000000 gwei left to withdraw"}},
+		},
+		{
+			name:        "a partial withdrawal after a full exit",
+			requests:    []EigenPod.IEigenPodTypesWithdrawalRequest{withdrawal(1, 0), withdrawal(1, ethGwei)},
+			errors:      []PreflightIssue{{1, "validator #1 is already exiting"}},
+			withdrawals: []WithdrawableAmount{{Validator: 1, Withdrawable: 40 * ethGwei}},
+		},
+		{
+			name:     "an exiting validator",
+			modify:   func(state *electra.BeaconState) { state.Validators[0].ExitEpoch = phase0.Epoch(preflightEpoch + 10) },
+			requests: []EigenPod.IEigenPodTypesWithdrawalRequest{withdrawal(0, 0)},
+			errors:   []PreflightIssue{{0, "validator #0 is already exiting"}},
+		},
+		{
+			name: "an inactive validator",
+			modify: func(state *electra.BeaconState) {
+				state.Validators[0].ActivationEpoch = phase0.Epoch(utils.FAR_FUTURE_EPOCH)
+			},
+			requests: []EigenPod.IEigenPodTypesWithdrawalRequest{withdrawal(0, 0)},
+			errors:   []PreflightIssue{{0, "validator #0 is not active"}},
+		},
+		{
+			name: "a validator activated recently",
+			modify: func(state *electra.BeaconState) {
+				state.Validators[1].ActivationEpoch = phase0.Epoch(preflightEpoch - 255)
+			},
+			requests: []EigenPod.IEigenPodTypesWithdrawalRequest{withdrawal(1, ethGwei)},
+			errors:   []PreflightIssue{{0, "validator #1 has been active for less than 256 epochs"}},
+		},
+		{
+			name:        "another address's validator",
+			requests:    []EigenPod.IEigenPodTypesWithdrawalRequest{withdrawal(4, 0), {Pubkey: []byte{0x1}}, withdrawal(0, 0)},
+			errors:      []PreflightIssue{{0, "is not a validator pointed at pod"}, {1, "0x01 is not a validator pointed at pod"}},
+			withdrawals: []WithdrawableAmount{{Validator: 0, Withdrawable: 32 * ethGwei}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newPreflightState()
+			if test.modify != nil {
+				test.modify(state)
+			}
+			report := validatePreflight(t, state, func(pod string, state *spec.VersionedBeaconState) (*WithdrawalPreflightReport, error) {
+				return ValidateWithdrawalRequests(pod, state, test.requests)
+			})
+			assertIssues(t, test.errors, report.Errors)
+			assertIssues(t, test.warnings, report.Warnings)
+			assert.Equal(t, test.withdrawals, report.Withdrawals)
+		})
+	}
+}
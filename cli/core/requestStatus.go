@@ -0,0 +1,410 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/electra"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+type RequestStatus string
+
+const (
+	// not yet seen by the beacon chain, or accepted and waiting in one of its queues
+	RequestStatusPending RequestStatus = "pending"
+	// applied by the beacon chain
+	RequestStatusProcessed RequestStatus = "processed"
+	// ignored by the beacon chain. The predeploy fee was spent, but nothing happened.
+	RequestStatusDropped RequestStatus = "dropped"
+	// the current beacon state doesn't tell whether the request was applied
+	RequestStatusUnknown RequestStatus = "unknown"
+)
+
+const (
+	RequestKindSwitch            = "switch"
+	RequestKindConsolidation     = "consolidation"
+	RequestKindFullExit          = "full-exit"
+	RequestKindPartialWithdrawal = "partial-withdrawal"
+)
+
+type TrackedRequest struct {
+	Kind        string
+	TxHash      common.Hash
+	BlockNumber uint64
+
+	// nil if the pubkey hash in the event doesn't match any validator pointed at the pod
+	Validator *uint64
+	// consolidation target; nil for other kinds
+	Target *uint64
+
+	AmountGwei uint64
+
+	Status RequestStatus
+	Detail string
+}
+
+// GetRequestStatuses finds the withdrawal and consolidation requests `eigenpodAddress` sent between
+// `fromBlock` and `toBlock` (from the pod's events), and classifies each against `state` (and, for
+// consolidations that already completed, the balances `beaconClient` serves around their completion).
+func GetRequestStatuses(
+	ctx context.Context,
+	eth *ethclient.Client,
+	beaconClient utils.BeaconClient,
+	eigenpodAddress string,
+	state *spec.VersionedBeaconState,
+	fromBlock uint64,
+	toBlock uint64,
+) ([]TrackedRequest, error) {
+	podAbi, err := EigenPod.EigenPodMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load eigenpod abi: %w", err)
+	}
+	eigenPod, err := EigenPod.NewEigenPodFilterer(common.HexToAddress(eigenpodAddress), eth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach eigenpod: %w", err)
+	}
+
+	topics := []common.Hash{}
+	for _, name := range []string{"SwitchToCompoundingRequested", "ConsolidationRequested", "ExitRequested", "WithdrawalRequested"} {
+		topics = append(topics, podAbi.Events[name].ID)
+	}
+	logs, err := utils.FilterLogsChunked(ctx, eth, ethereum.FilterQuery{
+		Addresses: []common.Address{common.HexToAddress(eigenpodAddress)},
+		Topics:    [][]common.Hash{topics},
+	}, fromBlock, toBlock, utils.DEFAULT_LOG_FILTER_CHUNK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch eigenpod request events: %w", err)
+	}
+
+	podValidators, err := utils.FindAllValidatorsForEigenpod(eigenpodAddress, state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find validators for eigenpod: %w", err)
+	}
+	byPubkeyHash := map[[32]byte]uint64{}
+	for _, v := range podValidators {
+		byPubkeyHash[pubkeyHash(v.Validator.PublicKey)] = v.Index
+	}
+	lookup := func(hash [32]byte) *uint64 {
+		if index, ok := byPubkeyHash[hash]; ok {
+			return &index
+		}
+		return nil
+	}
+
+	requests := []TrackedRequest{}
+	for _, log := range logs {
+		request := TrackedRequest{
+			TxHash:      log.TxHash,
+			BlockNumber: log.BlockNumber,
+		}
+
+		switch log.Topics[0] {
+		case podAbi.Events["SwitchToCompoundingRequested"].ID:
+			event, err := eigenPod.ParseSwitchToCompoundingRequested(log)
+			if err != nil {
+				return nil, err
+			}
+			request.Kind = RequestKindSwitch
+			request.Validator = lookup(event.ValidatorPubkeyHash)
+		case podAbi.Events["ConsolidationRequested"].ID:
+			event, err := eigenPod.ParseConsolidationRequested(log)
+			if err != nil {
+				return nil, err
+			}
+			request.Kind = RequestKindConsolidation
+			request.Validator = lookup(event.SourcePubkeyHash)
+			request.Target = lookup(event.TargetPubkeyHash)
+		case podAbi.Events["ExitRequested"].ID:
+			event, err := eigenPod.ParseExitRequested(log)
+			if err != nil {
+				return nil, err
+			}
+			request.Kind = RequestKindFullExit
+			request.Validator = lookup(event.ValidatorPubkeyHash)
+		case podAbi.Events["WithdrawalRequested"].ID:
+			event, err := eigenPod.ParseWithdrawalRequested(log)
+			if err != nil {
+				return nil, err
+			}
+			// full exits are tracked through ExitRequested
+			if event.WithdrawalAmountGwei == 0 {
+				continue
+			}
+			request.Kind = RequestKindPartialWithdrawal
+			request.Validator = lookup(event.ValidatorPubkeyHash)
+			request.AmountGwei = event.WithdrawalAmountGwei
+		default:
+			continue
+		}
+
+		requests = append(requests, request)
+	}
+
+	if err := classifyRequests(ctx, eth, beaconClient, state, requests); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// classifyRequests sets the status of each request from the beacon chain's queues and validator fields.
+// Execution requests are applied by the beacon block that includes them, so a request can only account
+// for queue entries and exit epochs that were possible from its block's epoch on.
+func classifyRequests(
+	ctx context.Context,
+	eth *ethclient.Client,
+	beaconClient utils.BeaconClient,
+	state *spec.VersionedBeaconState,
+	requests []TrackedRequest,
+) error {
+	epoch, err := utils.CurrentEpoch(state)
+	if err != nil {
+		return err
+	}
+	genesisTime, err := beacon.GetGenesisTime(state)
+	if err != nil {
+		return fmt.Errorf("failed to read genesis time from beacon state: %w", err)
+	}
+	latestExecutionBlock, err := beacon.GetLatestExecutionBlockNumber(state)
+	if err != nil {
+		return fmt.Errorf("failed to read latest execution block from beacon state: %w", err)
+	}
+	validators, err := state.Validators()
+	if err != nil {
+		return fmt.Errorf("failed to read validators from beacon state: %w", err)
+	}
+	pendingConsolidations, err := state.PendingConsolidations()
+	if err != nil {
+		return fmt.Errorf("failed to read pending consolidations from beacon state: %w", err)
+	}
+	pendingPartialWithdrawals, err := state.PendingPartialWithdrawals()
+	if err != nil {
+		return fmt.Errorf("failed to read pending partial withdrawals from beacon state: %w", err)
+	}
+
+	blockEpochs := map[uint64]uint64{}
+	epochOfBlock := func(blockNumber uint64) (uint64, error) {
+		if e, ok := blockEpochs[blockNumber]; ok {
+			return e, nil
+		}
+		header, err := eth.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch block %d: %w", blockNumber, err)
+		}
+		blockEpochs[blockNumber] = (header.Time - genesisTime) / beacon.SECONDS_PER_EPOCH
+		return blockEpochs[blockNumber], nil
+	}
+
+	// each pending partial withdrawal accounts for at most one request (see matchPartialWithdrawal)
+	matchedPartialWithdrawals := make([]bool, len(pendingPartialWithdrawals))
+
+	for i := range requests {
+		request := &requests[i]
+
+		if request.BlockNumber > latestExecutionBlock {
+			request.Status = RequestStatusPending
+			request.Detail = "not yet included in the beacon chain"
+			continue
+		}
+		if request.Validator == nil || (request.Kind == RequestKindConsolidation && request.Target == nil) {
+			request.Status = RequestStatusDropped
+			request.Detail = "validator is not pointed at this pod"
+			continue
+		}
+
+		requestEpoch, err := epochOfBlock(request.BlockNumber)
+		if err != nil {
+			return err
+		}
+		// the earliest exit epoch the request could have set
+		earliestExitEpoch := utils.ComputeActivationExitEpoch(requestEpoch)
+
+		v := validators[*request.Validator]
+		isExiting := utils.IsExitingValidator(v)
+
+		switch request.Kind {
+		case RequestKindSwitch:
+			if v.WithdrawalCredentials[0] == utils.CompoundingWithdrawalPrefix {
+				request.Status = RequestStatusProcessed
+				request.Detail = "validator has 0x02 credentials"
+			} else {
+				request.Status = RequestStatusDropped
+				request.Detail = "validator still has 0x01 credentials"
+			}
+
+		case RequestKindConsolidation:
+			classifyConsolidation(ctx, beaconClient, request, v, epoch, earliestExitEpoch, pendingConsolidations)
+
+		case RequestKindFullExit:
+			consolidation := findPendingConsolidation(pendingConsolidations, *request.Validator)
+			switch {
+			case !isExiting:
+				request.Status = RequestStatusDropped
+				request.Detail = "validator has no exit epoch"
+			case uint64(v.ExitEpoch) < earliestExitEpoch:
+				request.Status = RequestStatusDropped
+				request.Detail = fmt.Sprintf("validator was already exiting (exit epoch %d)", v.ExitEpoch)
+			case consolidation != nil:
+				request.Status = RequestStatusDropped
+				request.Detail = fmt.Sprintf("validator is exiting through a consolidation into #%d", consolidation.TargetIndex)
+			default:
+				request.Status = RequestStatusProcessed
+				request.Detail = fmt.Sprintf("exit epoch %d, withdrawable epoch %d", v.ExitEpoch, v.WithdrawableEpoch)
+			}
+
+		case RequestKindPartialWithdrawal:
+			if pw := matchPartialWithdrawal(pendingPartialWithdrawals, matchedPartialWithdrawals, request, earliestExitEpoch); pw != nil {
+				request.Status = RequestStatusPending
+				request.Detail = fmt.Sprintf("%d gwei in pending_partial_withdrawals, withdrawable at epoch %d", pw.Amount, pw.WithdrawableEpoch)
+				continue
+			}
+
+			// An accepted partial withdrawal stays queued until its withdrawable epoch, so if it's
+			// already gone the beacon chain never queued it. Once that epoch may have passed, the
+			// current state no longer tells.
+			if epoch < earliestExitEpoch+utils.MIN_VALIDATOR_WITHDRAWABILITY_DELAY {
+				request.Status = RequestStatusDropped
+				request.Detail = "not in pending_partial_withdrawals"
+			} else {
+				request.Status = RequestStatusUnknown
+				request.Detail = "not in pending_partial_withdrawals, which it would have left by now if it was queued"
+			}
+		}
+	}
+	return nil
+}
+
+func findPendingConsolidation(pendingConsolidations []*electra.PendingConsolidation, source uint64) *electra.PendingConsolidation {
+	for _, pc := range pendingConsolidations {
+		if uint64(pc.SourceIndex) == source {
+			return pc
+		}
+	}
+	return nil
+}
+
+// matchPartialWithdrawal returns the entry of pending_partial_withdrawals that `request` queued, if
+// any: the first one not matched to an earlier request that is for the request's validator, for at
+// most its amount (the beacon chain caps it to the validator's excess balance), and withdrawable no
+// earlier than the request allows. Requests must be matched in the order they were sent, which is the
+// order the queue is in.
+func matchPartialWithdrawal(
+	pendingPartialWithdrawals []*electra.PendingPartialWithdrawal,
+	matched []bool,
+	request *TrackedRequest,
+	earliestExitEpoch uint64,
+) *electra.PendingPartialWithdrawal {
+	for i, pw := range pendingPartialWithdrawals {
+		if matched[i] ||
+			uint64(pw.ValidatorIndex) != *request.Validator ||
+			uint64(pw.Amount) > request.AmountGwei ||
+			uint64(pw.WithdrawableEpoch) < earliestExitEpoch+utils.MIN_VALIDATOR_WITHDRAWABILITY_DELAY {
+			continue
+		}
+		matched[i] = true
+		return pw
+	}
+	return nil
+}
+
+// classifyConsolidation sets the status of a consolidation `request` from `source` (the validator as of
+// the current `epoch`). A source can only be consolidated once, and its exit epoch is set when its
+// consolidation is queued, so:
+//   - queued into the request's target: pending
+//   - queued into another target, not exiting, or exiting since before the request: dropped
+//   - exiting since the request but not queued: either it was consolidated into the target once it
+//     was withdrawable, or it exited some other way. This is told apart by the source's and target's
+//     balances around the source's withdrawable epoch, or reported as unknown.
+func classifyConsolidation(
+	ctx context.Context,
+	beaconClient utils.BeaconClient,
+	request *TrackedRequest,
+	source *phase0.Validator,
+	epoch uint64,
+	earliestExitEpoch uint64,
+	pendingConsolidations []*electra.PendingConsolidation,
+) {
+	target := *request.Target
+	pending := findPendingConsolidation(pendingConsolidations, *request.Validator)
+
+	switch {
+	case pending != nil && uint64(pending.TargetIndex) == target:
+		request.Status = RequestStatusPending
+		request.Detail = fmt.Sprintf("in pending_consolidations; balance moves to #%d once the source is withdrawable (epoch %d)", target, source.WithdrawableEpoch)
+		return
+	case pending != nil:
+		request.Status = RequestStatusDropped
+		request.Detail = fmt.Sprintf("source is consolidating into #%d instead", pending.TargetIndex)
+		return
+	case !utils.IsExitingValidator(source):
+		request.Status = RequestStatusDropped
+		request.Detail = "source has no exit epoch"
+		return
+	case uint64(source.ExitEpoch) < earliestExitEpoch:
+		request.Status = RequestStatusDropped
+		request.Detail = fmt.Sprintf("source was already exiting (exit epoch %d)", source.ExitEpoch)
+		return
+	case epoch < uint64(source.WithdrawableEpoch):
+		// a queued consolidation stays in the queue until its source is withdrawable
+		request.Status = RequestStatusDropped
+		request.Detail = fmt.Sprintf("source is exiting (exit epoch %d), but not into #%d", source.ExitEpoch, target)
+		return
+	}
+
+	// Pending consolidations are applied by the epoch transition into the source's withdrawable
+	// epoch, unless entries ahead of them in the queue aren't withdrawable yet.
+	withdrawableSlot := uint64(source.WithdrawableEpoch) * beacon.SLOTS_PER_EPOCH
+	moved, err := consolidatedBalance(ctx, beaconClient, *request.Validator, target, withdrawableSlot)
+	switch {
+	case err != nil:
+		request.Status = RequestStatusUnknown
+		request.Detail = fmt.Sprintf("source exited; failed to check its balance at epoch %d: %v", source.WithdrawableEpoch, err)
+	case moved > 0:
+		request.Status = RequestStatusProcessed
+		request.Detail = fmt.Sprintf("%d gwei moved to #%d at epoch %d", moved, target, source.WithdrawableEpoch)
+	default:
+		request.Status = RequestStatusUnknown
+		request.Detail = fmt.Sprintf("source exited, but its balance didn't move to #%d at its withdrawable epoch (%d)", target, source.WithdrawableEpoch)
+	}
+}
+
+// consolidatedBalance returns how much balance moved from `source` to `target` at `slot`, or 0 if the
+// target didn't gain (about) what the source lost. Rewards and penalties are applied in the same
+// epoch transition, so up to an increment of difference is tolerated.
+func consolidatedBalance(ctx context.Context, beaconClient utils.BeaconClient, source, target, slot uint64) (uint64, error) {
+	ids := []string{strconv.FormatUint(source, 10), strconv.FormatUint(target, 10)}
+	before, err := beaconClient.GetBalances(ctx, strconv.FormatUint(slot-1, 10), ids)
+	if err != nil {
+		return 0, err
+	}
+	after, err := beaconClient.GetBalances(ctx, strconv.FormatUint(slot, 10), ids)
+	if err != nil {
+		return 0, err
+	}
+	sourceIndex, targetIndex := phase0.ValidatorIndex(source), phase0.ValidatorIndex(target)
+	if after[sourceIndex] >= before[sourceIndex] || after[targetIndex] <= before[targetIndex] {
+		return 0, nil
+	}
+
+	lost := uint64(before[sourceIndex] - after[sourceIndex])
+	gained := uint64(after[targetIndex] - before[targetIndex])
+	if gained+utils.EFFECTIVE_BALANCE_INCREMENT < lost {
+		return 0, nil
+	}
+	return min(lost, gained), nil
+}
+
+// pubkeyHash computes the hash EigenPods use to identify validators: sha256(pubkey ++ bytes16(0))
+func pubkeyHash(pubkey phase0.BLSPubKey) [32]byte {
+	return sha256.Sum256(append(pubkey[:], make([]byte, 16)...))
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -56,21 +57,13 @@ func LoadWithdrawalRequestFromFile(path string) ([]EigenPod.IEigenPodTypesWithdr
 //     fee multiplied by len(requests).
 func SubmitWithdrawalRequests(
 	ctx context.Context,
-	owner, eigenpodAddress string,
-	chainId *big.Int,
+	txMgr *utils.TxManager,
+	eigenpodAddress string,
 	eth *ethclient.Client,
 	requests []EigenPod.IEigenPodTypesWithdrawalRequest,
 	predeployFee *big.Int,
-	noSend bool,
 	verbose bool,
 ) (*types.Transaction, error) {
-	ownerAccount, err := utils.PrepareAccount(&owner, chainId, noSend)
-	if err != nil {
-		return nil, err
-	}
-	utils.PanicOnError("failed to parse private key", err)
-	ownerAccount.TransactionOptions.Value = predeployFee
-
 	eigenPod, err := EigenPod.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
 	if err != nil {
 		return nil, err
@@ -78,7 +71,7 @@ func SubmitWithdrawalRequests(
 
 	if verbose {
 		color.Green("calling EigenPod.requestWithdrawal()... [%s]", func() string {
-			if ownerAccount.TransactionOptions.NoSend {
+			if txMgr.IsDryRun() {
 				return "simulated"
 			} else {
 				return "live"
@@ -86,8 +79,8 @@ func SubmitWithdrawalRequests(
 		}())
 	}
 
-	return eigenPod.RequestWithdrawal(
-		ownerAccount.TransactionOptions,
-		requests,
-	)
+	return txMgr.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		opts.Value = predeployFee
+		return eigenPod.RequestWithdrawal(opts, requests)
+	})
 }
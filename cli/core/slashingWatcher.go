@@ -0,0 +1,297 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/electra"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/fatih/color"
+)
+
+// How often (in finalized epochs) pods with slashed validators are re-checked when --check-interval
+// isn't given (~1 day). Slashed validators keep losing balance until they are withdrawable, most of
+// it at the midpoint of their withdrawability delay.
+const DEFAULT_SLASHING_CHECK_INTERVAL_EPOCHS = uint64(225)
+
+// How many finalized epochs a slashing seen in the event stream is waited on to be included. One
+// that is never included (e.g. it was dropped from the op pool) would otherwise be re-queried on
+// every head; if it is included later, the periodic check still picks it up.
+const PENDING_SLASHING_EXPIRY_EPOCHS = uint64(8)
+
+// SlashedValidatorIndices returns the validators slashed by a proposer_slashing or
+// attester_slashing event.
+func SlashedValidatorIndices(event utils.BeaconEvent) ([]uint64, error) {
+	decoded, err := event.Decode()
+	if err != nil {
+		return nil, err
+	}
+
+	switch slashing := decoded.(type) {
+	case *phase0.ProposerSlashing:
+		return []uint64{uint64(slashing.SignedHeader1.Message.ProposerIndex)}, nil
+	case *electra.AttesterSlashing:
+		// validators that signed both conflicting attestations
+		signed := map[uint64]bool{}
+		for _, index := range slashing.Attestation1.AttestingIndices {
+			signed[index] = true
+		}
+		indices := []uint64{}
+		for _, index := range slashing.Attestation2.AttestingIndices {
+			if signed[index] {
+				indices = append(indices, index)
+			}
+		}
+		return indices, nil
+	default:
+		return nil, fmt.Errorf("%s is not a slashing event", event.Topic)
+	}
+}
+
+type SlashingWatcherOptions struct {
+	// correct a pod once its balance deviation exceeds this percentage
+	TolerancePercent float64
+	// re-check pods with slashed validators every this many finalized epochs
+	CheckIntervalEpochs uint64
+	CheckpointBatchSize uint64
+	Verbose             bool
+}
+
+// SlashingWatcher follows the beacon node's event stream for slashings of validators pointed at
+// `pods`. Once a slashing is included on chain, and periodically afterwards, it computes the pod's
+// balance deviation and, if it exceeds the tolerance, corrects the pod with verifyStaleBalance and
+// completes the resulting checkpoint.
+type SlashingWatcher struct {
+	eth          *ethclient.Client
+	beaconClient utils.BeaconClient
+	chainId      *big.Int
+	// nil to only report deviations
+	txMgr   *utils.TxManager
+	pods    []common.Address
+	options SlashingWatcherOptions
+
+	// pod each of our validators is pointed at, as of the last check
+	podOf map[uint64]common.Address
+	// validators in a slashing event that hasn't been included on chain yet
+	pending map[uint64]pendingSlashing
+	// finalized_checkpoint events seen so far
+	finalizedEpochs uint64
+}
+
+type pendingSlashing struct {
+	pod common.Address
+	// finalizedEpochs when the slashing was seen
+	seenAt uint64
+}
+
+func NewSlashingWatcher(eth *ethclient.Client, beaconClient utils.BeaconClient, chainId *big.Int, txMgr *utils.TxManager, pods []string, options SlashingWatcherOptions) *SlashingWatcher {
+	podAddresses := make([]common.Address, len(pods))
+	for i, pod := range pods {
+		podAddresses[i] = common.HexToAddress(pod)
+	}
+	if options.CheckIntervalEpochs == 0 {
+		options.CheckIntervalEpochs = DEFAULT_SLASHING_CHECK_INTERVAL_EPOCHS
+	}
+
+	return &SlashingWatcher{
+		eth:          eth,
+		beaconClient: beaconClient,
+		chainId:      chainId,
+		txMgr:        txMgr,
+		pods:         podAddresses,
+		options:      options,
+		podOf:        map[uint64]common.Address{},
+		pending:      map[uint64]pendingSlashing{},
+	}
+}
+
+// Run checks every pod once, then watches for slashings until `ctx` is cancelled.
+func (w *SlashingWatcher) Run(ctx context.Context) error {
+	events, err := w.beaconClient.SubscribeEvents(ctx,
+		utils.EventTopicProposerSlashing,
+		utils.EventTopicAttesterSlashing,
+		utils.EventTopicHead,
+		utils.EventTopicFinalizedCheckpoint,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to beacon events: %w", err)
+	}
+
+	if err := w.check(ctx, w.pods); err != nil {
+		return err
+	}
+
+	epochsSinceCheck := uint64(0)
+	for event := range events {
+		switch event.Topic {
+		case utils.EventTopicProposerSlashing, utils.EventTopicAttesterSlashing:
+			indices, err := SlashedValidatorIndices(event)
+			if err != nil {
+				color.Yellow("ignoring malformed %s event: %v", event.Topic, err)
+				continue
+			}
+			for _, index := range indices {
+				if pod, ok := w.podOf[index]; ok {
+					color.Red("validator %d (pod %s) is being slashed (%s); waiting for the slashing to be included.", index, pod.Hex(), event.Topic)
+					w.pending[index] = pendingSlashing{pod: pod, seenAt: w.finalizedEpochs}
+				}
+			}
+		case utils.EventTopicHead:
+			if len(w.pending) == 0 {
+				continue
+			}
+			podsToCheck, err := w.includedSlashings(ctx)
+			if err != nil {
+				color.Yellow("failed to check pending slashings: %v", err)
+				continue
+			}
+			if len(podsToCheck) > 0 {
+				if err := w.check(ctx, podsToCheck); err != nil {
+					color.Red("failed to check pods: %v", err)
+				}
+			}
+		case utils.EventTopicFinalizedCheckpoint:
+			w.finalizedEpochs++
+			w.expirePending()
+
+			epochsSinceCheck++
+			if epochsSinceCheck < w.options.CheckIntervalEpochs {
+				continue
+			}
+			epochsSinceCheck = 0
+			if err := w.check(ctx, w.pods); err != nil {
+				color.Red("failed to check pods: %v", err)
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// includedSlashings drops pending validators whose slashing has been included on chain, and returns
+// their pods.
+func (w *SlashingWatcher) includedSlashings(ctx context.Context) ([]common.Address, error) {
+	pods := []common.Address{}
+	for index, pending := range w.pending {
+		pod := pending.pod
+		validator, err := w.beaconClient.GetValidator(ctx, index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch validator %d: %w", index, err)
+		}
+		if !validator.Validator.Slashed {
+			continue
+		}
+
+		color.Red("validator %d (pod %s) was slashed.", index, pod.Hex())
+		delete(w.pending, index)
+		if !slices.Contains(pods, pod) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// expirePending stops waiting on slashings that weren't included within PENDING_SLASHING_EXPIRY_EPOCHS.
+func (w *SlashingWatcher) expirePending() {
+	for index, pending := range w.pending {
+		if w.finalizedEpochs-pending.seenAt < PENDING_SLASHING_EXPIRY_EPOCHS {
+			continue
+		}
+		color.Yellow("validator %d (pod %s): the slashing wasn't included within %d epochs; no longer waiting for it.", index, pending.pod.Hex(), PENDING_SLASHING_EXPIRY_EPOCHS)
+		delete(w.pending, index)
+	}
+}
+
+// check refreshes the validators of `pods` from the head state (picking up any slashings the event
+// stream missed), and corrects each pod with slashed validators whose deviation is too large. A pod
+// that can't be checked is logged and skipped, so that it doesn't hold up the others.
+func (w *SlashingWatcher) check(ctx context.Context, pods []common.Address) error {
+	state, err := utils.GetBeaconHeadState(ctx, w.beaconClient)
+	if err != nil {
+		return fmt.Errorf("failed to fetch beacon head state: %w", err)
+	}
+
+	for _, pod := range pods {
+		if err := w.checkPod(ctx, state, pod); err != nil {
+			color.Red("failed to check %s: %v", pod.Hex(), err)
+		}
+	}
+	return nil
+}
+
+func (w *SlashingWatcher) checkPod(ctx context.Context, state *spec.VersionedBeaconState, pod common.Address) error {
+	validators, err := utils.FindAllValidatorsForEigenpod(pod.Hex(), state)
+	if err != nil {
+		return fmt.Errorf("failed to find validators: %w", err)
+	}
+	withInfo, err := utils.FetchMultipleOnchainValidatorInfo(ctx, w.eth, pod.Hex(), validators)
+	if err != nil {
+		return fmt.Errorf("failed to fetch validator info: %w", err)
+	}
+
+	slashed := []uint64{}
+	for _, validator := range withInfo {
+		w.podOf[validator.Index] = pod
+		if validator.Validator.Slashed && validator.Info.Status == utils.ValidatorStatusActive {
+			slashed = append(slashed, validator.Index)
+		}
+	}
+	if len(slashed) == 0 {
+		return nil
+	}
+	slices.Sort(slashed)
+
+	deviation, err := ComputeBalanceDeviationSync(ctx, w.eth, state, pod)
+	if err != nil {
+		return fmt.Errorf("failed to compute balance deviation: %w", err)
+	}
+	// ComputeBalanceDeviationSync returns a fraction
+	deviationPercent, _ := new(big.Float).Mul(deviation, big.NewFloat(100)).Float64()
+
+	if deviationPercent <= w.options.TolerancePercent {
+		if w.options.Verbose {
+			color.Green("pod %s has %d slashed validator(s), balance deviation %.4f%% (tolerance: %.2f%%)", pod.Hex(), len(slashed), deviationPercent, w.options.TolerancePercent)
+		}
+		return nil
+	}
+
+	color.Red("pod %s has %d slashed validator(s), balance deviation %.4f%% exceeds tolerance (%.2f%%)", pod.Hex(), len(slashed), deviationPercent, w.options.TolerancePercent)
+	if w.txMgr == nil {
+		color.Yellow("no --sender given; not correcting %s", pod.Hex())
+		return nil
+	}
+
+	if err := w.correct(ctx, pod, slashed); err != nil {
+		return fmt.Errorf("failed to correct: %w", err)
+	}
+	return nil
+}
+
+// correct runs verifyStaleBalance with the lowest of the pod's slashed validators (`slashed` is
+// sorted), then completes the checkpoint it starts.
+func (w *SlashingWatcher) correct(ctx context.Context, pod common.Address, slashed []uint64) error {
+	index := slashed[0]
+
+	correction, err := CorrectStaleBalance(ctx, w.eth, w.beaconClient, w.chainId, w.txMgr, pod.Hex(), index, w.options.CheckpointBatchSize, nil /* confirm */, w.options.Verbose)
+	if err != nil {
+		return err
+	}
+	for _, txn := range correction.CompletedCheckpointTxs {
+		color.Green("completed outstanding checkpoint: %s", txn.Hash().Hex())
+	}
+	color.Green("verifyStaleBalance (validator %d): %s", index, correction.VerifyStaleBalanceTx.Hash().Hex())
+
+	txns, err := CompleteCheckpoint(ctx, w.eth, w.beaconClient, w.chainId, w.txMgr, pod.Hex(), w.options.CheckpointBatchSize, w.options.Verbose)
+	if err != nil {
+		return fmt.Errorf("verifyStaleBalance started a checkpoint, but completing it failed (run `checkpoint` to retry): %w", err)
+	}
+	for _, txn := range txns {
+		color.Green("completed checkpoint: %s", txn.Hash().Hex())
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/electra"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slashingBeaconClient streams `events`, and serves validators that are slashed if in `slashed`.
+type slashingBeaconClient struct {
+	utils.BeaconClient
+	events chan utils.BeaconEvent
+
+	mu      sync.Mutex
+	slashed map[uint64]bool
+	queried []uint64
+}
+
+func (c *slashingBeaconClient) SubscribeEvents(ctx context.Context, topics ...string) (<-chan utils.BeaconEvent, error) {
+	return c.events, nil
+}
+
+func (c *slashingBeaconClient) GetBeaconState(ctx context.Context, stateId string) (*spec.VersionedBeaconState, error) {
+	return &spec.VersionedBeaconState{Version: spec.DataVersionElectra}, nil
+}
+
+func (c *slashingBeaconClient) GetValidator(ctx context.Context, index uint64) (*v1.Validator, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queried = append(c.queried, index)
+	return &v1.Validator{
+		Index:     phase0.ValidatorIndex(index),
+		Validator: &phase0.Validator{Slashed: c.slashed[index]},
+	}, nil
+}
+
+func beaconEvent(t *testing.T, topic string, data interface{}) utils.BeaconEvent {
+	encoded, err := json.Marshal(data)
+	require.NoError(t, err)
+	return utils.BeaconEvent{Topic: topic, Data: encoded}
+}
+
+func proposerSlashing(t *testing.T, proposer uint64) utils.BeaconEvent {
+	header := func(root byte) *phase0.SignedBeaconBlockHeader {
+		return &phase0.SignedBeaconBlockHeader{Message: &phase0.BeaconBlockHeader{
+			ProposerIndex: phase0.ValidatorIndex(proposer),
+			BodyRoot:      phase0.Root{root},
+		}}
+	}
+	return beaconEvent(t, utils.EventTopicProposerSlashing, &phase0.ProposerSlashing{SignedHeader1: header(1), SignedHeader2: header(2)})
+}
+
+func attesterSlashing(t *testing.T, attesters1 []uint64, attesters2 []uint64) utils.BeaconEvent {
+	attestation := func(indices []uint64, root byte) *electra.IndexedAttestation {
+		return &electra.IndexedAttestation{
+			AttestingIndices: indices,
+			Data: &phase0.AttestationData{
+				BeaconBlockRoot: phase0.Root{root},
+				Source:          &phase0.Checkpoint{},
+				Target:          &phase0.Checkpoint{},
+			},
+		}
+	}
+	return beaconEvent(t, utils.EventTopicAttesterSlashing, &electra.AttesterSlashing{
+		Attestation1: attestation(attesters1, 1),
+		Attestation2: attestation(attesters2, 2),
+	})
+}
+
+func TestSlashedValidatorIndices(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   utils.BeaconEvent
+		want    []uint64
+		wantErr bool
+	}{
+		{
+			name:  "proposer slashing",
+			event: proposerSlashing(t, 42),
+			want:  []uint64{42},
+		},
+		{
+			name:  "attesters of both attestations",
+			event: attesterSlashing(t, []uint64{1, 3, 5, 7}, []uint64{3, 4, 7}),
+			want:  []uint64{3, 7},
+		},
+		{
+			name:  "disjoint attestations",
+			event: attesterSlashing(t, []uint64{1, 2}, []uint64{3, 4}),
+			want:  []uint64{},
+		},
+		{
+			name:    "not a slashing",
+			event:   beaconEvent(t, utils.EventTopicFinalizedCheckpoint, &v1.FinalizedCheckpointEvent{}),
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			event:   utils.BeaconEvent{Topic: utils.EventTopicProposerSlashing, Data: json.RawMessage(`{"signed_header_1":1}`)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indices, err := SlashedValidatorIndices(tt.event)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, indices)
+		})
+	}
+}
+
+func TestSlashingWatcherPendingSlashings(t *testing.T) {
+	client := &slashingBeaconClient{events: make(chan utils.BeaconEvent), slashed: map[uint64]bool{}}
+	// no pods to check; the validators they were found to have are set up directly
+	w := NewSlashingWatcher(nil, client, nil, nil, nil, SlashingWatcherOptions{})
+	w.podOf[7] = testPod
+	w.podOf[9] = testPod
+
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+
+	head := utils.BeaconEvent{Topic: utils.EventTopicHead}
+	finalized := utils.BeaconEvent{Topic: utils.EventTopicFinalizedCheckpoint}
+
+	// 8 isn't one of the pods' validators
+	client.events <- attesterSlashing(t, []uint64{5, 7, 8}, []uint64{7, 8, 9})
+	client.events <- proposerSlashing(t, 9)
+	client.events <- head
+	for range PENDING_SLASHING_EXPIRY_EPOCHS {
+		client.events <- finalized
+	}
+	// neither slashing was included in time, so neither is queried again
+	client.events <- head
+	close(client.events)
+	require.NoError(t, <-done)
+
+	assert.Empty(t, w.pending)
+	assert.ElementsMatch(t, []uint64{7, 9}, client.queried)
+}
+
+func TestSlashingWatcherIncludedSlashings(t *testing.T) {
+	client := &slashingBeaconClient{slashed: map[uint64]bool{7: true}}
+	w := NewSlashingWatcher(nil, client, nil, nil, nil, SlashingWatcherOptions{})
+	w.pending[7] = pendingSlashing{pod: testPod}
+	w.pending[9] = pendingSlashing{pod: otherAddr}
+
+	pods, err := w.includedSlashings(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []common.Address{testPod}, pods)
+	assert.Contains(t, w.pending, uint64(9))
+	assert.NotContains(t, w.pending, uint64(7))
+
+	// still pending until included, or expired
+	w.finalizedEpochs = PENDING_SLASHING_EXPIRY_EPOCHS - 1
+	w.expirePending()
+	assert.Contains(t, w.pending, uint64(9))
+	w.finalizedEpochs++
+	w.expirePending()
+	assert.Empty(t, w.pending)
+}
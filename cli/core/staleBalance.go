@@ -0,0 +1,134 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
+	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/fatih/color"
+)
+
+type StaleBalanceCorrection struct {
+	// verifyCheckpointProofs txs completing the checkpoint that was active before the correction
+	CompletedCheckpointTxs []*types.Transaction
+	// the verifyStaleBalance tx, which starts a new checkpoint
+	VerifyStaleBalanceTx *types.Transaction
+}
+
+// another fun cast brought to you by golang!
+func proofCast(proof []eigenpodproofs.Bytes32) [][32]byte {
+	res := make([][32]byte, len(proof))
+	for i, elt := range proof {
+		res[i] = elt
+	}
+	return res
+}
+
+// CorrectStaleBalance calls `EigenPod.verifyStaleBalance()` for the slashed validator
+// `slashedValidatorIndex`, completing the pod's active checkpoint first if there is one. If
+// `confirm` is non-nil, it is called before each step that sends transactions.
+//
+// verifyStaleBalance starts a new checkpoint, which must be completed afterwards.
+func CorrectStaleBalance(
+	ctx context.Context,
+	eth *ethclient.Client,
+	beaconClient utils.BeaconClient,
+	chainId *big.Int,
+	txMgr *utils.TxManager,
+	eigenpodAddress string,
+	slashedValidatorIndex uint64,
+	checkpointBatchSize uint64,
+	confirm func(prompt string),
+	verbose bool,
+) (*StaleBalanceCorrection, error) {
+	validator, err := beaconClient.GetValidator(ctx, slashedValidatorIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch validator state: %w", err)
+	}
+	if !validator.Validator.Slashed {
+		return nil, fmt.Errorf("validator %d was not slashed", slashedValidatorIndex)
+	}
+
+	eigenpod, err := EigenPod.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach eigenpod: %w", err)
+	}
+
+	currentCheckpointTimestamp, err := eigenpod.CurrentCheckpointTimestamp(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch any existing checkpoint info: %w", err)
+	}
+
+	correction := &StaleBalanceCorrection{}
+	if currentCheckpointTimestamp > 0 {
+		if confirm != nil {
+			confirm(fmt.Sprintf("This eigenpod has an outstanding checkpoint (since %d). You must complete it before continuing. This will invoke `EigenPod.verifyCheckpointProofs()`, which will end the checkpoint. This may be expensive.", currentCheckpointTimestamp))
+		}
+
+		txns, err := CompleteCheckpoint(ctx, eth, beaconClient, chainId, txMgr, eigenpodAddress, checkpointBatchSize, verbose)
+		if err != nil {
+			return nil, fmt.Errorf("failed to complete existing checkpoint: %w", err)
+		}
+		correction.CompletedCheckpointTxs = txns
+	}
+
+	proof, oracleBeaconTimestamp, err := GenerateValidatorProof(ctx, eigenpodAddress, eth, chainId, beaconClient, new(big.Int).SetUint64(slashedValidatorIndex), verbose)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate credential proof for slashed validator: %w", err)
+	}
+
+	if confirm != nil {
+		confirm("This will invoke `EigenPod.verifyStaleBalance()` on the given eigenpod, which will start a checkpoint. Once started, this checkpoint must be completed.")
+	}
+
+	if verbose {
+		color.Black("Calling EigenPod.verifyStaleBalance() to force checkpoint this pod.")
+	}
+
+	txn, err := txMgr.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return eigenpod.VerifyStaleBalance(
+			opts,
+			oracleBeaconTimestamp,
+			EigenPod.BeaconChainProofsStateRootProof{
+				Proof:           proof.StateRootProof.Proof.ToByteSlice(),
+				BeaconStateRoot: proof.StateRootProof.BeaconStateRoot,
+			},
+			EigenPod.BeaconChainProofsValidatorProof{
+				ValidatorFields: proofCast(proof.ValidatorFields[0]),
+				Proof:           proof.ValidatorFieldsProofs[0].ToByteSlice(),
+			},
+		)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call verifyStaleBalance(): %w", err)
+	}
+
+	if _, err := txMgr.WaitAll(ctx); err != nil {
+		return nil, fmt.Errorf("failed waiting for verifyStaleBalance(): %w", err)
+	}
+	correction.VerifyStaleBalanceTx = txn
+	return correction, nil
+}
+
+// CompleteCheckpoint generates and submits the proofs for the pod's active checkpoint, waiting for
+// every batch to be mined.
+func CompleteCheckpoint(ctx context.Context, eth *ethclient.Client, beaconClient utils.BeaconClient, chainId *big.Int, txMgr *utils.TxManager, eigenpodAddress string, batchSize uint64, verbose bool) ([]*types.Transaction, error) {
+	proofs, err := GenerateCheckpointProof(ctx, eigenpodAddress, eth, chainId, beaconClient, verbose)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate checkpoint proofs: %w", err)
+	}
+
+	// SubmitCheckpointProof waits for every batch to be mined before returning.
+	txns, err := SubmitCheckpointProof(ctx, txMgr, eigenpodAddress, proofs, eth, batchSize, true /* noPrompt */, verbose)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit checkpoint proofs: %w", err)
+	}
+	return txns, nil
+}
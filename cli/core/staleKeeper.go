@@ -0,0 +1,387 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
+	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/fatih/color"
+)
+
+type StalePodAction string
+
+const (
+	StalePodSubmitted   StalePodAction = "submitted"
+	StalePodWouldSubmit StalePodAction = "would-submit"
+	StalePodSkipped     StalePodAction = "skipped"
+	StalePodFailed      StalePodAction = "failed"
+)
+
+// StalePodReport describes what the keeper did with a single pod whose balance deviation exceeded
+// the tolerance, or why the pod couldn't be inspected.
+type StalePodReport struct {
+	Pod              string
+	DeviationPercent float64
+	// slashed validators pointed at the pod
+	SlashedValidators []uint64
+
+	Action StalePodAction
+	// why the pod was skipped, or why submitting failed
+	Reason string `json:",omitempty"`
+
+	// the slashed validator whose credentials were proven to verifyStaleBalance
+	ProvenValidator *uint64 `json:",omitempty"`
+	EstimatedGas    uint64  `json:",omitempty"`
+	// upper bound: EstimatedGas * max fee per gas
+	EstimatedCostWei *big.Int `json:",omitempty"`
+
+	Tx      string   `json:",omitempty"`
+	CostWei *big.Int `json:",omitempty"`
+}
+
+// StalePodKeeperRound is the outcome of one scan of the network for stale pods.
+type StalePodKeeperRound struct {
+	BlockNumber uint64
+	// timestamp of the beacon block root the proofs were generated against
+	BeaconTimestamp uint64
+	BaseFeeWei      *big.Int
+
+	// number of pods with at least one slashed validator
+	SlashedPods int
+	// pods whose deviation exceeds the tolerance, most deviated first, followed by those that
+	// couldn't be inspected (marked failed)
+	StalePods []StalePodReport
+
+	// gas spent this round
+	SpentWei *big.Int
+}
+
+type StalePodKeeperOptions struct {
+	// correct a pod once its balance deviation exceeds this percentage
+	TolerancePercent float64
+	// total ETH (in wei) the keeper may spend on gas across all rounds; nil for no limit
+	MaxSpendWei *big.Int
+	// don't submit anything while the base fee is above this (in wei); nil for no limit
+	MaxBaseFeeWei *big.Int
+	// optional; saved after every scan. See PodAddressCache.
+	Cache   *PodAddressCache
+	Verbose bool
+}
+
+// StalePodKeeper scans every eigenpod with slashed validators, and calls verifyStaleBalance on
+// those whose balance deviation exceeds the tolerance, most deviated first. verifyStaleBalance is
+// permissionless: it starts a checkpoint on the pod, which is left for the pod owner to complete.
+//
+// If the tx manager is a dry run, nothing is sent, and the report contains gas estimates instead.
+type StalePodKeeper struct {
+	eth          *ethclient.Client
+	beaconClient utils.BeaconClient
+	chainId      *big.Int
+	txMgr        *utils.TxManager
+	proofs       *eigenpodproofs.EigenPodProofs
+	options      StalePodKeeperOptions
+
+	// gas spent across all rounds
+	spentWei *big.Int
+}
+
+func NewStalePodKeeper(eth *ethclient.Client, beaconClient utils.BeaconClient, chainId *big.Int, txMgr *utils.TxManager, options StalePodKeeperOptions) (*StalePodKeeper, error) {
+	proofs, err := eigenpodproofs.NewEigenPodProofs(chainId.Uint64(), 300 /* oracleStateCacheExpirySeconds - 5min */)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize provider: %w", err)
+	}
+
+	return &StalePodKeeper{
+		eth:          eth,
+		beaconClient: beaconClient,
+		chainId:      chainId,
+		txMgr:        txMgr,
+		proofs:       proofs,
+		options:      options,
+		spentWei:     big.NewInt(0),
+	}, nil
+}
+
+// SpentWei returns the gas spent across all rounds so far.
+func (k *StalePodKeeper) SpentWei() *big.Int {
+	return new(big.Int).Set(k.spentWei)
+}
+
+// BudgetExhausted returns whether the keeper has spent its entire gas budget.
+func (k *StalePodKeeper) BudgetExhausted() bool {
+	return k.options.MaxSpendWei != nil && k.spentWei.Cmp(k.options.MaxSpendWei) >= 0
+}
+
+// RunRound scans for stale pods once and corrects as many as the gas budget allows.
+//
+// A single beacon state is used for the whole round: the one whose root EIP-4788 exposes for the
+// latest execution block, so every proof can be checked against it.
+func (k *StalePodKeeper) RunRound(ctx context.Context) (*StalePodKeeperRound, error) {
+	latestBlock, err := k.eth.BlockByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load latest block: %w", err)
+	}
+	beaconRoot := latestBlock.BeaconRoot()
+	if beaconRoot == nil {
+		return nil, errors.New("latest block has no parent beacon block root")
+	}
+
+	header, err := k.beaconClient.GetBeaconHeader(ctx, beaconRoot.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch beacon header: %w", err)
+	}
+	// a new state every round, so caching it would only churn the cache
+	state, err := k.beaconClient.GetBeaconState(utils.ContextWithoutStateCache(ctx), strconv.FormatUint(uint64(header.Header.Message.Slot), 10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch beacon state: %w", err)
+	}
+
+	round := &StalePodKeeperRound{
+		BlockNumber:     latestBlock.NumberU64(),
+		BeaconTimestamp: latestBlock.Time(),
+		BaseFeeWei:      latestBlock.BaseFee(),
+		StalePods:       []StalePodReport{},
+		SpentWei:        big.NewInt(0),
+	}
+
+	pods, err := findSlashedEigenpods(ctx, k.eth, state, k.chainId, k.options.Cache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find slashed eigenpods: %w", err)
+	}
+	if k.options.Cache != nil {
+		if err := k.options.Cache.Save(); err != nil {
+			return nil, err
+		}
+	}
+	round.SlashedPods = len(pods)
+	if k.options.Verbose {
+		color.Blue("block %d: %d eigenpod(s) have slashed validators", round.BlockNumber, len(pods))
+	}
+
+	// eligible slashed validators of each stale pod
+	eligible := map[string][]uint64{}
+	for _, pod := range pods {
+		report, eligibleValidators, err := k.inspect(ctx, state, pod, latestBlock.Time())
+		if err != nil {
+			// one pod's RPC failure shouldn't keep the rest from being corrected
+			color.Red("failed to inspect %s: %v", pod.Hex(), err)
+			round.StalePods = append(round.StalePods, StalePodReport{
+				Pod:               pod.Hex(),
+				SlashedValidators: []uint64{},
+				Action:            StalePodFailed,
+				Reason:            err.Error(),
+			})
+			continue
+		}
+		if report != nil {
+			round.StalePods = append(round.StalePods, *report)
+			eligible[report.Pod] = eligibleValidators
+		}
+	}
+
+	sort.SliceStable(round.StalePods, func(i, j int) bool {
+		return round.StalePods[i].DeviationPercent > round.StalePods[j].DeviationPercent
+	})
+
+	baseFeeTooHigh := k.options.MaxBaseFeeWei != nil && latestBlock.BaseFee() != nil && latestBlock.BaseFee().Cmp(k.options.MaxBaseFeeWei) > 0
+	for i := range round.StalePods {
+		report := &round.StalePods[i]
+		if report.Action == StalePodSkipped || report.Action == StalePodFailed {
+			continue
+		}
+		if baseFeeTooHigh {
+			report.Action = StalePodSkipped
+			report.Reason = fmt.Sprintf("base fee (%s wei) is above the maximum (%s wei)", latestBlock.BaseFee(), k.options.MaxBaseFeeWei)
+			continue
+		}
+
+		err := k.submit(ctx, state, header, latestBlock.Time(), report, eligible[report.Pod])
+		// a reverted transaction still costs gas
+		if report.CostWei != nil {
+			round.SpentWei.Add(round.SpentWei, report.CostWei)
+		}
+		if err != nil {
+			report.Action = StalePodFailed
+			report.Reason = err.Error()
+			color.Red("failed to correct %s: %v", report.Pod, err)
+		}
+	}
+	return round, nil
+}
+
+// inspect returns a report for `pod` if its deviation exceeds the tolerance (nil otherwise), along
+// with its slashed validators that verifyStaleBalance would accept. The report is already marked
+// skipped if the pod can't be corrected right now.
+func (k *StalePodKeeper) inspect(ctx context.Context, state *spec.VersionedBeaconState, pod common.Address, beaconTimestamp uint64) (*StalePodReport, []uint64, error) {
+	deviation, err := ComputeBalanceDeviationSync(ctx, k.eth, state, pod)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compute balance deviation of %s: %w", pod.Hex(), err)
+	}
+	// ComputeBalanceDeviationSync returns a fraction
+	deviationPercent, _ := new(big.Float).Mul(deviation, big.NewFloat(100)).Float64()
+	if deviationPercent <= k.options.TolerancePercent {
+		if k.options.Verbose {
+			color.Green("pod %s: balance deviation %.4f%% is within tolerance", pod.Hex(), deviationPercent)
+		}
+		return nil, nil, nil
+	}
+
+	validators, err := utils.FindAllValidatorsForEigenpod(pod.Hex(), state)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find validators of %s: %w", pod.Hex(), err)
+	}
+	slashed := []utils.ValidatorWithIndex{}
+	for _, v := range validators {
+		if v.Validator.Slashed {
+			slashed = append(slashed, v)
+		}
+	}
+
+	report := &StalePodReport{
+		Pod:               pod.Hex(),
+		DeviationPercent:  deviationPercent,
+		SlashedValidators: make([]uint64, len(slashed)),
+	}
+	for i, v := range slashed {
+		report.SlashedValidators[i] = v.Index
+	}
+
+	eigenPod, err := EigenPod.NewEigenPod(pod, k.eth)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to reach eigenpod %s: %w", pod.Hex(), err)
+	}
+	currentCheckpointTimestamp, err := eigenPod.CurrentCheckpointTimestamp(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch checkpoint of %s: %w", pod.Hex(), err)
+	}
+	var withInfo []utils.ValidatorWithOnchainInfo
+	if currentCheckpointTimestamp == 0 {
+		withInfo, err = utils.FetchMultipleOnchainValidatorInfo(ctx, k.eth, pod.Hex(), slashed)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch validator info of %s: %w", pod.Hex(), err)
+		}
+	}
+	return report, eligibleStaleValidators(report, currentCheckpointTimestamp, withInfo, beaconTimestamp), nil
+}
+
+// eligibleStaleValidators returns the `slashed` validators verifyStaleBalance would accept a proof
+// of as of `beaconTimestamp`, marking `report` skipped if there are none or a checkpoint is active.
+func eligibleStaleValidators(report *StalePodReport, currentCheckpointTimestamp uint64, slashed []utils.ValidatorWithOnchainInfo, beaconTimestamp uint64) []uint64 {
+	if currentCheckpointTimestamp > 0 {
+		// verifyStaleBalance can't start a checkpoint while another is active; once the active one
+		// completes, the pod's balance is up to date anyway.
+		report.Action = StalePodSkipped
+		report.Reason = fmt.Sprintf("checkpoint active since %d", currentCheckpointTimestamp)
+		return nil
+	}
+
+	eligible := []uint64{}
+	for _, v := range slashed {
+		// verifyStaleBalance requires an ACTIVE validator that hasn't been checkpointed since the
+		// proven state.
+		if v.Info.Status == utils.ValidatorStatusActive && v.Info.LastCheckpointedAt < beaconTimestamp {
+			eligible = append(eligible, v.Index)
+		}
+	}
+	if len(eligible) == 0 {
+		report.Action = StalePodSkipped
+		report.Reason = "no slashed validator is active in the pod"
+	}
+	return eligible
+}
+
+// withinBudget returns whether the report's estimated cost fits in what's left of MaxSpendWei, and
+// marks the report skipped if it doesn't.
+func (k *StalePodKeeper) withinBudget(report *StalePodReport) bool {
+	if k.options.MaxSpendWei == nil {
+		return true
+	}
+	remaining := new(big.Int).Sub(k.options.MaxSpendWei, k.spentWei)
+	if report.EstimatedCostWei.Cmp(remaining) > 0 {
+		report.Action = StalePodSkipped
+		report.Reason = fmt.Sprintf("estimated cost exceeds the remaining gas budget (%s wei)", remaining)
+		return false
+	}
+	return true
+}
+
+// submit proves one of `eligible` to verifyStaleBalance, respecting the gas budget. In a dry run,
+// the call is only estimated.
+func (k *StalePodKeeper) submit(ctx context.Context, state *spec.VersionedBeaconState, header *v1.BeaconBlockHeader, beaconTimestamp uint64, report *StalePodReport, eligible []uint64) error {
+	index := eligible[0]
+	proof, err := GenerateValidatorProofAtState(ctx, k.proofs, report.Pod, state, k.eth, k.chainId, header, beaconTimestamp, new(big.Int).SetUint64(index), k.options.Verbose)
+	if err != nil {
+		return fmt.Errorf("failed to generate credential proof for validator %d: %w", index, err)
+	}
+	report.ProvenValidator = &index
+
+	eigenPod, err := EigenPod.NewEigenPod(common.HexToAddress(report.Pod), k.eth)
+	if err != nil {
+		return fmt.Errorf("failed to reach eigenpod: %w", err)
+	}
+	build := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return eigenPod.VerifyStaleBalance(
+			opts,
+			beaconTimestamp,
+			EigenPod.BeaconChainProofsStateRootProof{
+				Proof:           proof.StateRootProof.Proof.ToByteSlice(),
+				BeaconStateRoot: proof.StateRootProof.BeaconStateRoot,
+			},
+			EigenPod.BeaconChainProofsValidatorProof{
+				ValidatorFields: proofCast(proof.ValidatorFields[0]),
+				Proof:           proof.ValidatorFieldsProofs[0].ToByteSlice(),
+			},
+		)
+	}
+
+	gas, feeCap, err := k.txMgr.Estimate(ctx, build)
+	if err != nil {
+		return fmt.Errorf("failed to estimate verifyStaleBalance(): %w", err)
+	}
+	report.EstimatedGas = gas
+	report.EstimatedCostWei = new(big.Int).Mul(new(big.Int).SetUint64(gas), feeCap)
+
+	if !k.withinBudget(report) {
+		return nil
+	}
+
+	if k.txMgr.IsDryRun() {
+		report.Action = StalePodWouldSubmit
+		return nil
+	}
+
+	txn, err := k.txMgr.Send(ctx, build)
+	if err != nil {
+		return fmt.Errorf("failed to call verifyStaleBalance(): %w", err)
+	}
+	report.Tx = txn.Hash().Hex()
+
+	receipts, err := k.txMgr.WaitAll(ctx)
+	for _, receipt := range receipts {
+		if receipt == nil {
+			continue
+		}
+		cost := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+		report.CostWei = cost
+		k.spentWei.Add(k.spentWei, cost)
+	}
+	if err != nil {
+		return fmt.Errorf("failed waiting for verifyStaleBalance(): %w", err)
+	}
+
+	report.Action = StalePodSubmitted
+	color.Green("verifyStaleBalance on %s (validator %d, deviation %.2f%%): %s", report.Pod, index, report.DeviationPercent, report.Tx)
+	return nil
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEligibleStaleValidators(t *testing.T) {
+	const beaconTimestamp = uint64(1000)
+	slashed := func(index uint64, status uint8, lastCheckpointedAt uint64) utils.ValidatorWithOnchainInfo {
+		return utils.ValidatorWithOnchainInfo{
+			Index: index,
+			Info:  EigenPod.IEigenPodTypesValidatorInfo{Status: status, LastCheckpointedAt: lastCheckpointedAt},
+		}
+	}
+
+	tests := []struct {
+		name                       string
+		currentCheckpointTimestamp uint64
+		slashed                    []utils.ValidatorWithOnchainInfo
+
+		want       []uint64
+		wantReason string
+	}{
+		{
+			name: "checkpointed before the proven state",
+			slashed: []utils.ValidatorWithOnchainInfo{
+				slashed(1, utils.ValidatorStatusActive, beaconTimestamp-1),
+				slashed(2, utils.ValidatorStatusActive, 0),
+			},
+			want: []uint64{1, 2},
+		},
+		{
+			name: "checkpointed at or after the proven state",
+			slashed: []utils.ValidatorWithOnchainInfo{
+				slashed(1, utils.ValidatorStatusActive, beaconTimestamp),
+				slashed(2, utils.ValidatorStatusActive, beaconTimestamp+12),
+				slashed(3, utils.ValidatorStatusActive, beaconTimestamp-12),
+			},
+			want: []uint64{3},
+		},
+		{
+			name: "not active in the pod",
+			slashed: []utils.ValidatorWithOnchainInfo{
+				slashed(1, utils.ValidatorStatusInactive, 0),
+				slashed(2, utils.ValidatorStatusWithdrawn, 0),
+			},
+			want:       []uint64{},
+			wantReason: "no slashed validator is active in the pod",
+		},
+		{
+			name:                       "checkpoint active",
+			currentCheckpointTimestamp: 900,
+			wantReason:                 "checkpoint active since 900",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &StalePodReport{}
+			eligible := eligibleStaleValidators(report, tt.currentCheckpointTimestamp, tt.slashed, beaconTimestamp)
+			assert.Equal(t, tt.want, eligible)
+			if tt.wantReason == "" {
+				assert.Empty(t, report.Action)
+				return
+			}
+			assert.Equal(t, StalePodSkipped, report.Action)
+			assert.Equal(t, tt.wantReason, report.Reason)
+		})
+	}
+}
+
+func TestStalePodKeeperBudget(t *testing.T) {
+	keeper := &StalePodKeeper{options: StalePodKeeperOptions{MaxSpendWei: big.NewInt(100)}, spentWei: big.NewInt(0)}
+	assert.False(t, keeper.BudgetExhausted())
+
+	// the whole budget may be spent at once
+	report := &StalePodReport{EstimatedCostWei: big.NewInt(100)}
+	assert.True(t, keeper.withinBudget(report))
+	assert.Empty(t, report.Action)
+
+	keeper.spentWei.SetInt64(60)
+	report = &StalePodReport{EstimatedCostWei: big.NewInt(41)}
+	assert.False(t, keeper.withinBudget(report))
+	assert.Equal(t, StalePodSkipped, report.Action)
+	assert.Equal(t, "estimated cost exceeds the remaining gas budget (40 wei)", report.Reason)
+	assert.True(t, keeper.withinBudget(&StalePodReport{EstimatedCostWei: big.NewInt(40)}))
+
+	// a reverted transaction can overspend
+	keeper.spentWei.SetInt64(120)
+	assert.True(t, keeper.BudgetExhausted())
+	assert.False(t, keeper.withinBudget(&StalePodReport{EstimatedCostWei: big.NewInt(0)}))
+	assert.Equal(t, big.NewInt(120), keeper.SpentWei())
+
+	unlimited := &StalePodKeeper{spentWei: big.NewInt(1e18)}
+	assert.False(t, unlimited.BudgetExhausted())
+	assert.True(t, unlimited.withinBudget(&StalePodReport{EstimatedCostWei: big.NewInt(1e18)}))
+}
@@ -45,16 +45,28 @@ type EigenpodStatus struct {
 	// Whether the checkpoint would need to be started with the `--force` flag.
 	// This would be due to the pod not having any uncheckpointed native ETH
 	MustForceCheckpoint bool
+
+	// Where a 32 ETH exit or consolidation requested now would land in the beacon chain's queues, as
+	// of the head beacon state (even while a checkpoint is active). nil for a light status, which
+	// does not load the beacon state.
+	ExitQueue *QueueETA
+
+	// When the pod's exiting validators become withdrawable and are swept, keyed by validator index
+	ExitingValidators map[string]ValidatorETA
 }
 
-func getRegularBalancesGwei(state *spec.VersionedBeaconState) []phase0.Gwei {
+func getRegularBalancesGwei(state *spec.VersionedBeaconState, validators []utils.ValidatorWithIndex) map[uint64]phase0.Gwei {
 	validatorBalances, err := state.ValidatorBalances()
 	utils.PanicOnError("failed to load validator balances", err)
 
-	return validatorBalances
+	balances := map[uint64]phase0.Gwei{}
+	for _, validator := range validators {
+		balances[validator.Index] = validatorBalances[validator.Index]
+	}
+	return balances
 }
 
-func sumValidatorBeaconBalancesGwei(allValidators []utils.ValidatorWithOnchainInfo, allBalances []phase0.Gwei) *big.Int {
+func sumValidatorBeaconBalancesGwei(allValidators []utils.ValidatorWithOnchainInfo, allBalances map[uint64]phase0.Gwei) *big.Int {
 	sumGwei := big.NewInt(0)
 
 	for i := 0; i < len(allValidators); i++ {
@@ -77,6 +89,64 @@ func sumRestakedBalancesGwei(activeValidators []utils.ValidatorWithOnchainInfo)
 }
 
 func GetStatus(ctx context.Context, eigenpodAddress string, eth *ethclient.Client, beaconClient utils.BeaconClient) EigenpodStatus {
+	// Fetch the beacon state associated with the checkpoint (or "head" if there is no checkpoint)
+	checkpointTimestamp, state, err := utils.GetCheckpointTimestampAndBeaconState(ctx, eigenpodAddress, eth, beaconClient)
+	utils.PanicOnError("failed to fetch checkpoint and beacon state", err)
+
+	allValidatorsForEigenpod, err := utils.FindAllValidatorsForEigenpod(eigenpodAddress, state)
+	utils.PanicOnError("failed to find validators", err)
+
+	// the checkpoint's state may be up to a day old, so the queues are estimated as of head
+	queueState := state
+	if checkpointTimestamp != 0 {
+		queueState, err = beaconClient.GetBeaconState(ctx, "head")
+		utils.PanicOnError("failed to fetch head beacon state", err)
+	}
+	etaEstimator, err := NewETAEstimator(queueState)
+	utils.PanicOnError("failed to load beacon chain queues", err)
+
+	return getStatus(ctx, eigenpodAddress, eth, checkpointTimestamp, allValidatorsForEigenpod, getRegularBalancesGwei(state, allValidatorsForEigenpod), etaEstimator)
+}
+
+// GetStatusLight is GetStatus without downloading the beacon state: the pod's validators are found
+// from its ValidatorRestaked events and then fetched from the beacon node by index. Validators whose
+// withdrawal credentials were never verified are not listed, and the beacon chain's queues are not
+// estimated.
+func GetStatusLight(ctx context.Context, eigenpodAddress string, eth *ethclient.Client, beaconClient utils.BeaconClient) EigenpodStatus {
+	checkpointTimestamp, stateId, err := utils.GetCheckpointTimestampAndStateId(ctx, eigenpodAddress, eth, beaconClient)
+	utils.PanicOnError("failed to fetch checkpoint", err)
+
+	indices, err := utils.FindRestakedValidatorIndices(ctx, eth, eigenpodAddress)
+	utils.PanicOnError("failed to find validators", err)
+
+	beaconValidators, err := utils.GetValidatorsByIndex(ctx, beaconClient, stateId, indices)
+	utils.PanicOnError("failed to fetch validators", err)
+
+	allValidatorsForEigenpod := make([]utils.ValidatorWithIndex, len(indices))
+	balances := map[uint64]phase0.Gwei{}
+	for i, validator := range beaconValidators {
+		allValidatorsForEigenpod[i] = utils.ValidatorWithIndex{
+			Validator: validator.Validator,
+			Index:     indices[i],
+		}
+		balances[indices[i]] = validator.Balance
+	}
+
+	return getStatus(ctx, eigenpodAddress, eth, checkpointTimestamp, allValidatorsForEigenpod, balances, nil)
+}
+
+// getStatus computes the status of the pod from its validators and their balances as of the
+// checkpoint's beacon state (or head). etaEstimator may be nil, in which case no queue ETAs are given;
+// otherwise it should be built from the head state, which exits are also read from.
+func getStatus(
+	ctx context.Context,
+	eigenpodAddress string,
+	eth *ethclient.Client,
+	checkpointTimestamp uint64,
+	allValidatorsForEigenpod []utils.ValidatorWithIndex,
+	allBeaconBalancesGwei map[uint64]phase0.Gwei,
+	etaEstimator *ETAEstimator,
+) EigenpodStatus {
 	validators := map[string]utils.Validator{}
 	var activeCheckpoint *utils.Checkpoint = nil
 
@@ -86,18 +156,9 @@ func GetStatus(ctx context.Context, eigenpodAddress string, eth *ethclient.Clien
 	checkpoint, err := eigenPod.CurrentCheckpoint(nil)
 	utils.PanicOnError("failed to fetch checkpoint information", err)
 
-	// Fetch the beacon state associated with the checkpoint (or "head" if there is no checkpoint)
-	checkpointTimestamp, state, err := utils.GetCheckpointTimestampAndBeaconState(ctx, eigenpodAddress, eth, beaconClient)
-	utils.PanicOnError("failed to fetch checkpoint and beacon state", err)
-
-	allValidatorsForEigenpod, err := utils.FindAllValidatorsForEigenpod(eigenpodAddress, state)
-	utils.PanicOnError("failed to find validators", err)
-
 	allValidatorsWithInfoForEigenpod, err := utils.FetchMultipleOnchainValidatorInfo(ctx, eth, eigenpodAddress, allValidatorsForEigenpod)
 	utils.PanicOnError("failed to fetch validator info", err)
 
-	allBeaconBalancesGwei := getRegularBalancesGwei(state)
-
 	activeValidators, err := utils.SelectActiveValidators(eth, eigenpodAddress, allValidatorsWithInfoForEigenpod)
 	utils.PanicOnError("failed to find active validators", err)
 
@@ -124,6 +185,22 @@ func GetStatus(ctx context.Context, eigenpodAddress string, eth *ethclient.Clien
 		}
 	}
 
+	var exitQueue *QueueETA
+	exitingValidators := map[string]ValidatorETA{}
+	if etaEstimator != nil {
+		queues := etaEstimator.Queues(utils.MIN_ACTIVATION_BALANCE)
+		exitQueue = &queues
+
+		for _, validator := range allValidatorsWithInfoForEigenpod {
+			if validator.Index >= uint64(len(etaEstimator.validators)) {
+				continue
+			}
+			if utils.IsExitingValidator(etaEstimator.validators[validator.Index]) && validator.Info.Status != utils.ValidatorStatusWithdrawn {
+				exitingValidators[fmt.Sprintf("%d", validator.Index)] = etaEstimator.EstimateExisting(validator.Index)
+			}
+		}
+	}
+
 	eigenpodManagerContractAddress, err := eigenPod.EigenPodManager(nil)
 	utils.PanicOnError("failed to get manager address", err)
 
@@ -224,5 +301,7 @@ func GetStatus(ctx context.Context, eigenpodAddress string, eth *ethclient.Clien
 		PodOwner:                       eigenPodOwner,
 		ProofSubmitter:                 proofSubmitter,
 		MustForceCheckpoint:            mustForceCheckpoint,
+		ExitQueue:                      exitQueue,
+		ExitingValidators:              exitingValidators,
 	}
 }
@@ -1,15 +1,25 @@
 package utils
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Layr-Labs/eigenpod-proofs-generation/common"
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/attestantio/go-eth2-client/api"
 	v1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/http"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -24,17 +34,43 @@ var (
 type BeaconClient interface {
 	GetBeaconHeader(ctx context.Context, blockId string) (*v1.BeaconBlockHeader, error)
 	GetBeaconState(ctx context.Context, stateId string) (*spec.VersionedBeaconState, error)
+	GetBlock(ctx context.Context, blockId string) (*spec.VersionedSignedBeaconBlock, error)
 	GetValidator(ctx context.Context, index uint64) (*v1.Validator, error)
+
+	// GetValidators returns the validators `ids` (indices, or 0x-prefixed pubkeys) as of `stateId`,
+	// keyed by index. Unknown validators are omitted.
+	GetValidators(ctx context.Context, stateId string, ids []string) (map[phase0.ValidatorIndex]*v1.Validator, error)
+	// GetBalances returns the balances of validators `ids` (indices, or 0x-prefixed pubkeys) as of
+	// `stateId`, keyed by index. Unknown validators are omitted.
+	GetBalances(ctx context.Context, stateId string, ids []string) (map[phase0.ValidatorIndex]phase0.Gwei, error)
 	GetGenesisForkVersion(ctx context.Context) (*phase0.Version, error)
+
+	// GetStateProof returns a multiproof of the nodes `gindices` of the beacon state `stateId`, for
+	// beacon nodes that serve /eth/v0/beacon/proof/state/{state_id} (e.g. Lodestar). This lets
+	// proofs be generated without downloading the state (see eigenpodproofs.StateProofProvider).
+	GetStateProof(ctx context.Context, stateId string, gindices []uint64) (*common.CompactMultiproof, error)
+
+	// SubscribeEvents streams the beacon node's events for `topics` (see EventTopic*) until `ctx` is
+	// cancelled, reconnecting if the stream drops.
+	SubscribeEvents(ctx context.Context, topics ...string) (<-chan BeaconEvent, error)
 }
 
 type beaconClient struct {
 	eth2client eth2client.Service
-	verbose    bool
+	events     *eventStream
+	// for the endpoints eth2client doesn't implement
+	endpoint string
+	http     *nethttp.Client
+	verbose  bool
 }
 
 func NewBeaconClient(endpoint string, verbose bool) (BeaconClient, context.CancelFunc, error) {
-	beaconClient := beaconClient{verbose: verbose}
+	beaconClient := beaconClient{
+		verbose:  verbose,
+		events:   newEventStream(endpoint, verbose),
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		http:     &nethttp.Client{Timeout: 300 * time.Second},
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 
 	client, err := http.New(ctx,
@@ -68,6 +104,83 @@ func (b *beaconClient) GetBeaconHeader(ctx context.Context, blockId string) (*v1
 	return nil, ErrBeaconClientNotSupported
 }
 
+// GetBlock returns the signed beacon block `blockId` (a slot, block root, "head", ...), or nil if
+// there is no such block (e.g. the slot was missed).
+func (b *beaconClient) GetBlock(ctx context.Context, blockId string) (*spec.VersionedSignedBeaconBlock, error) {
+	if provider, isProvider := b.eth2client.(eth2client.SignedBeaconBlockProvider); isProvider {
+		opts := &api.SignedBeaconBlockOpts{Block: blockId}
+		response, err := provider.SignedBeaconBlock(ctx, opts)
+		if err != nil {
+			var apiErr *api.Error
+			if errors.As(err, &apiErr) && apiErr.StatusCode == 404 {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return response.Data, nil
+	}
+
+	return nil, ErrBeaconClientNotSupported
+}
+
+func (b *beaconClient) GetStateProof(ctx context.Context, stateId string, gindices []uint64) (*common.CompactMultiproof, error) {
+	descriptor, err := common.ComputeDescriptor(gindices)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/eth/v0/beacon/proof/state/%s?format=0x%s", b.endpoint, stateId, hex.EncodeToString(descriptor))
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	if b.verbose {
+		log.Info().Msgf("fetching proof of %d nodes of beacon state %s", len(gindices), stateId)
+	}
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != nethttp.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("beacon node returned %s for state proof (does it serve /eth/v0/beacon/proof?): %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var response struct {
+		Data struct {
+			Leaves     []hexutil.Bytes `json:"leaves"`
+			Descriptor hexutil.Bytes   `json:"descriptor"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode state proof: %w", err)
+	}
+
+	if !bytes.Equal(response.Data.Descriptor, descriptor) {
+		return nil, fmt.Errorf("beacon node returned a proof with descriptor %s, requested %#x", response.Data.Descriptor, descriptor)
+	}
+
+	proof := &common.CompactMultiproof{
+		Leaves:     make([]phase0.Root, len(response.Data.Leaves)),
+		Descriptor: descriptor,
+	}
+	for i, leaf := range response.Data.Leaves {
+		if len(leaf) != len(phase0.Root{}) {
+			return nil, fmt.Errorf("invalid state proof leaf %s", leaf)
+		}
+		proof.Leaves[i] = phase0.Root(leaf)
+	}
+	return proof, nil
+}
+
+func (b *beaconClient) SubscribeEvents(ctx context.Context, topics ...string) (<-chan BeaconEvent, error) {
+	return b.events.Subscribe(ctx, topics...)
+}
+
 func (b *beaconClient) GetGenesisForkVersion(ctx context.Context) (*phase0.Version, error) {
 	if provider, isProvider := b.eth2client.(eth2client.GenesisProvider); isProvider {
 		opts := &api.GenesisOpts{}
@@ -105,6 +218,92 @@ func (b *beaconClient) GetValidator(ctx context.Context, index uint64) (*v1.Vali
 	return nil, ErrBeaconClientNotSupported
 }
 
+func (b *beaconClient) GetValidators(ctx context.Context, stateId string, ids []string) (map[phase0.ValidatorIndex]*v1.Validator, error) {
+	// the beacon API returns every validator when no filter is given
+	if len(ids) == 0 {
+		return map[phase0.ValidatorIndex]*v1.Validator{}, nil
+	}
+
+	indices, pubkeys, err := parseValidatorIds(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider, ok := b.eth2client.(eth2client.ValidatorsProvider); ok {
+		opts := &api.ValidatorsOpts{
+			State:   stateId,
+			Indices: indices,
+			PubKeys: pubkeys,
+		}
+		response, err := provider.Validators(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if response == nil {
+			return nil, errors.New("validators response is nil")
+		}
+		return response.Data, nil
+	}
+
+	return nil, ErrBeaconClientNotSupported
+}
+
+func (b *beaconClient) GetBalances(ctx context.Context, stateId string, ids []string) (map[phase0.ValidatorIndex]phase0.Gwei, error) {
+	// the beacon API returns every balance when no filter is given
+	if len(ids) == 0 {
+		return map[phase0.ValidatorIndex]phase0.Gwei{}, nil
+	}
+
+	indices, pubkeys, err := parseValidatorIds(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider, ok := b.eth2client.(eth2client.ValidatorBalancesProvider); ok {
+		opts := &api.ValidatorBalancesOpts{
+			State:   stateId,
+			Indices: indices,
+			PubKeys: pubkeys,
+		}
+		response, err := provider.ValidatorBalances(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if response == nil {
+			return nil, errors.New("validator balances response is nil")
+		}
+		return response.Data, nil
+	}
+
+	return nil, ErrBeaconClientNotSupported
+}
+
+// parseValidatorIds splits validator ids, as accepted by the beacon API's `id` filters, into
+// indices and pubkeys.
+func parseValidatorIds(ids []string) ([]phase0.ValidatorIndex, []phase0.BLSPubKey, error) {
+	indices := []phase0.ValidatorIndex{}
+	pubkeys := []phase0.BLSPubKey{}
+	for _, id := range ids {
+		if strings.HasPrefix(id, "0x") {
+			pubkey, err := hex.DecodeString(id[2:])
+			if err != nil || len(pubkey) != len(phase0.BLSPubKey{}) {
+				return nil, nil, fmt.Errorf("invalid validator pubkey %s", id)
+			}
+			pubkeys = append(pubkeys, phase0.BLSPubKey(pubkey))
+			continue
+		}
+
+		index, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid validator index %s", id)
+		}
+		indices = append(indices, phase0.ValidatorIndex(index))
+	}
+	return indices, pubkeys, nil
+}
+
 func (b *beaconClient) GetBeaconState(ctx context.Context, stateId string) (*spec.VersionedBeaconState, error) {
 	timeout, _ := time.ParseDuration("200s")
 	if provider, ok := b.eth2client.(eth2client.BeaconStateProvider); ok {
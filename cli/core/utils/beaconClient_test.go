@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/common"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTreeDepth = 4
+
+// newTestTree returns the layers of a tree of 2^testTreeDepth distinct leaves, from the leaves up.
+func newTestTree(t *testing.T) [][]phase0.Root {
+	leaves := make([]phase0.Root, 1<<testTreeDepth)
+	for i := range leaves {
+		leaves[i][0] = byte(i + 1)
+	}
+	tree, err := common.ComputeMerkleTreeFromLeaves(leaves, testTreeDepth)
+	require.NoError(t, err)
+	return tree
+}
+
+func treeNode(tree [][]phase0.Root, gindex uint64) phase0.Root {
+	return tree[testTreeDepth-common.GindexDepth(gindex)][common.GindexIndex(gindex)]
+}
+
+// newProofServer serves multiproofs of `tree` the way a beacon node's proof endpoint does.
+func newProofServer(t *testing.T, tree [][]phase0.Root) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/eth/v0/beacon/proof/state/") {
+			http.NotFound(w, r)
+			return
+		}
+		descriptor, err := hex.DecodeString(strings.TrimPrefix(r.URL.Query().Get("format"), "0x"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		gindices, err := common.DescriptorGindices(descriptor)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		leaves := []string{}
+		for _, gindex := range gindices {
+			leaves = append(leaves, fmt.Sprintf("%#x", treeNode(tree, gindex)))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"leaves":     leaves,
+				"descriptor": fmt.Sprintf("%#x", descriptor),
+			},
+		}))
+	}))
+}
+
+func TestGetStateProof(t *testing.T) {
+	tree := newTestTree(t)
+	server := newProofServer(t, tree)
+	defer server.Close()
+
+	client := &beaconClient{endpoint: server.URL, http: server.Client()}
+
+	// two leaves and a branch node, in different subtrees
+	gindices := []uint64{18, 27, 7}
+	proof, err := client.GetStateProof(context.Background(), "head", gindices)
+	require.NoError(t, err)
+
+	nodes, err := proof.Nodes()
+	require.NoError(t, err)
+	root := tree[testTreeDepth][0]
+	assert.Equal(t, root, nodes.Root())
+
+	for _, gindex := range gindices {
+		node, err := nodes.Get(gindex)
+		require.NoError(t, err)
+		assert.Equal(t, treeNode(tree, gindex), node)
+
+		branch, err := common.Branch(nodes, gindex, 1)
+		require.NoError(t, err)
+		assert.True(t, common.ValidateProof(root, branch, node, common.GindexIndex(gindex)), "invalid branch for gindex %d", gindex)
+	}
+
+	// only the nodes needed for the proof are served
+	assert.Len(t, proof.Leaves, 8)
+	_, err = nodes.Get(16)
+	assert.Error(t, err)
+}
+
+func TestGetStateProofUnsupported(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	client := &beaconClient{endpoint: server.URL, http: server.Client()}
+	_, err := client.GetStateProof(context.Background(), "head", []uint64{18})
+	assert.ErrorContains(t, err, "404")
+}
@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/electra"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog/log"
+)
+
+// Topics of the beacon node event stream (/eth/v1/events) that can be subscribed to.
+const (
+	EventTopicHead                 = "head"
+	EventTopicFinalizedCheckpoint  = "finalized_checkpoint"
+	EventTopicProposerSlashing     = "proposer_slashing"
+	EventTopicAttesterSlashing     = "attester_slashing"
+	EventTopicVoluntaryExit        = "voluntary_exit"
+	EventTopicBLSToExecutionChange = "bls_to_execution_change"
+)
+
+var supportedEventTopics = map[string]bool{
+	EventTopicHead:                 true,
+	EventTopicFinalizedCheckpoint:  true,
+	EventTopicProposerSlashing:     true,
+	EventTopicAttesterSlashing:     true,
+	EventTopicVoluntaryExit:        true,
+	EventTopicBLSToExecutionChange: true,
+}
+
+// How long to wait before reconnecting to a dropped event stream. Doubles after each failed
+// attempt, up to the max.
+const (
+	EVENT_STREAM_MIN_BACKOFF = time.Second
+	EVENT_STREAM_MAX_BACKOFF = 30 * time.Second
+)
+
+type BeaconEvent struct {
+	Topic string
+	Data  json.RawMessage
+}
+
+// Decode parses the event's data into the type the beacon API uses for its topic:
+//   - head: *v1.HeadEvent
+//   - finalized_checkpoint: *v1.FinalizedCheckpointEvent
+//   - proposer_slashing: *phase0.ProposerSlashing
+//   - attester_slashing: *electra.AttesterSlashing
+//   - voluntary_exit: *phase0.SignedVoluntaryExit
+//   - bls_to_execution_change: *capella.SignedBLSToExecutionChange
+func (e BeaconEvent) Decode() (interface{}, error) {
+	var out interface{}
+	switch e.Topic {
+	case EventTopicHead:
+		out = &v1.HeadEvent{}
+	case EventTopicFinalizedCheckpoint:
+		out = &v1.FinalizedCheckpointEvent{}
+	case EventTopicProposerSlashing:
+		out = &phase0.ProposerSlashing{}
+	case EventTopicAttesterSlashing:
+		out = &electra.AttesterSlashing{}
+	case EventTopicVoluntaryExit:
+		out = &phase0.SignedVoluntaryExit{}
+	case EventTopicBLSToExecutionChange:
+		out = &capella.SignedBLSToExecutionChange{}
+	default:
+		return nil, fmt.Errorf("unsupported event topic %s", e.Topic)
+	}
+
+	if err := json.Unmarshal(e.Data, out); err != nil {
+		return nil, fmt.Errorf("failed to decode %s event: %w", e.Topic, err)
+	}
+	return out, nil
+}
+
+// eventStream reads server-sent events from a beacon node's /eth/v1/events endpoint.
+type eventStream struct {
+	client   *http.Client
+	endpoint string
+	verbose  bool
+
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+func newEventStream(endpoint string, verbose bool) *eventStream {
+	return &eventStream{
+		// no timeout: the response body stays open for as long as we're subscribed
+		client:     &http.Client{},
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		verbose:    verbose,
+		minBackoff: EVENT_STREAM_MIN_BACKOFF,
+		maxBackoff: EVENT_STREAM_MAX_BACKOFF,
+	}
+}
+
+// Subscribe connects to the event stream for `topics`, and delivers its events on the returned
+// channel until `ctx` is cancelled, at which point the channel is closed. If the connection drops,
+// it is re-established with exponential backoff; events emitted in the meantime are missed.
+func (s *eventStream) Subscribe(ctx context.Context, topics ...string) (<-chan BeaconEvent, error) {
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("no event topics given")
+	}
+	for _, topic := range topics {
+		if !supportedEventTopics[topic] {
+			return nil, fmt.Errorf("unsupported event topic %s", topic)
+		}
+	}
+
+	// connect once up front, so a misconfigured node is reported to the caller
+	resp, err := s.connect(ctx, topics)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan BeaconEvent)
+	go func() {
+		defer close(events)
+
+		backoff := s.minBackoff
+		for {
+			if resp != nil {
+				err := s.read(ctx, resp, events)
+				if ctx.Err() != nil {
+					return
+				}
+				if s.verbose {
+					log.Warn().Msgf("beacon event stream dropped (%v), reconnecting", err)
+				}
+				backoff = s.minBackoff
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			resp, err = s.connect(ctx, topics)
+			if err != nil {
+				if s.verbose {
+					log.Warn().Msgf("failed to reconnect to beacon event stream: %v", err)
+				}
+				resp = nil
+				backoff = min(2*backoff, s.maxBackoff)
+			}
+		}
+	}()
+	return events, nil
+}
+
+func (s *eventStream) connect(ctx context.Context, topics []string) (*http.Response, error) {
+	query := url.Values{"topics": {strings.Join(topics, ",")}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint+"/eth/v1/events?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to beacon event stream: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("beacon event stream returned status %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// read forwards the events in `resp` to `events` until the stream ends. Per the SSE format, an
+// event is a block of `event:` and `data:` lines terminated by an empty line.
+func (s *eventStream) read(ctx context.Context, resp *http.Response, events chan<- BeaconEvent) error {
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	// slashings and their attestations can be large
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var topic string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if topic != "" && len(data) > 0 {
+				select {
+				case events <- BeaconEvent{Topic: topic, Data: json.RawMessage(strings.Join(data, "\n"))}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			topic, data = "", nil
+		case strings.HasPrefix(line, ":"):
+			// comment (used as a keep-alive by some nodes)
+		case strings.HasPrefix(line, "event:"):
+			topic = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("stream closed by beacon node")
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const headEventData = `{"slot":"10","block":"0x9a2fefd2fdb57f74993c7780ea5b9030d2897b615b89f808011ca5aebed54eaf","state":"0x600e852a08c1200654ddf11025f1ceacb3c2e74bdd5c630cde0838b2591b69f9","epoch_transition":false,"previous_duty_dependent_root":"0x5e0043f107cb57913498fbf2f99ff55e730bf1e151f02f221e977c91a90a0e91","current_duty_dependent_root":"0x5e0043f107cb57913498fbf2f99ff55e730bf1e151f02f221e977c91a90a0e91","execution_optimistic":false}`
+
+const voluntaryExitData = `{"message":{"epoch":"1","validator_index":"42"},"signature":"0x1b66ac1fb663c9bc59509846d6ec05345bd908eda73e670af888da41af171505cc411d61252fb6cb3fa0017b679f8bb2305b26a285fa2737f175668d0dff91cc1b66ac1fb663c9bc59509846d6ec05345bd908eda73e670af888da41af171505"}`
+
+func newTestEventStream(endpoint string) *eventStream {
+	stream := newEventStream(endpoint, false)
+	stream.minBackoff = 10 * time.Millisecond
+	stream.maxBackoff = 50 * time.Millisecond
+	return stream
+}
+
+func receive(t *testing.T, events <-chan BeaconEvent) BeaconEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "event channel closed")
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return BeaconEvent{}
+	}
+}
+
+func TestEventStreamReconnects(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/eth/v1/events", r.URL.Path)
+		assert.Equal(t, "head,voluntary_exit", r.URL.Query().Get("topics"))
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		flusher := w.(http.Flusher)
+
+		if connections.Add(1) == 1 {
+			// drop the connection after a single event
+			fmt.Fprintf(w, ": keep-alive\n\nevent: head\ndata: %s\n\n", headEventData)
+			flusher.Flush()
+			return
+		}
+
+		fmt.Fprintf(w, "event: voluntary_exit\ndata: %s\n\n", voluntaryExitData)
+		flusher.Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := newTestEventStream(server.URL).Subscribe(ctx, EventTopicHead, EventTopicVoluntaryExit)
+	require.NoError(t, err)
+
+	head := receive(t, events)
+	assert.Equal(t, EventTopicHead, head.Topic)
+	decoded, err := head.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Slot(10), decoded.(*v1.HeadEvent).Slot)
+
+	exit := receive(t, events)
+	assert.Equal(t, EventTopicVoluntaryExit, exit.Topic)
+	decoded, err = exit.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, phase0.ValidatorIndex(42), decoded.(*phase0.SignedVoluntaryExit).Message.ValidatorIndex)
+	assert.Equal(t, int32(2), connections.Load())
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok, "expected the channel to be closed")
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel wasn't closed after cancelling")
+	}
+}
+
+func TestEventStreamRejectsBadSubscriptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	stream := newTestEventStream(server.URL)
+
+	_, err := stream.Subscribe(context.Background(), "blob_sidecar")
+	assert.ErrorContains(t, err, "unsupported event topic")
+
+	_, err = stream.Subscribe(context.Background())
+	assert.Error(t, err)
+
+	_, err = stream.Subscribe(context.Background(), EventTopicHead)
+	assert.ErrorContains(t, err, "status 400")
+}
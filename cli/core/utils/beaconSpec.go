@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Beacon chain constants, from:
+// - https://github.com/ethereum/consensus-specs/blob/dev/specs/phase0/beacon-chain.md
+// - https://github.com/ethereum/consensus-specs/blob/dev/specs/electra/beacon-chain.md
+const (
+	SHARD_COMMITTEE_PERIOD              = uint64(256) // epochs
+	MAX_SEED_LOOKAHEAD                  = uint64(4)   // epochs
+	MIN_VALIDATOR_WITHDRAWABILITY_DELAY = uint64(256) // epochs
+
+	MIN_ACTIVATION_BALANCE        = uint64(32_000_000_000)    // gwei
+	MAX_EFFECTIVE_BALANCE_ELECTRA = uint64(2_048_000_000_000) // gwei
+	EFFECTIVE_BALANCE_INCREMENT   = uint64(1_000_000_000)     // gwei
+
+	CHURN_LIMIT_QUOTIENT                      = uint64(65536)
+	MIN_PER_EPOCH_CHURN_LIMIT_ELECTRA         = uint64(128_000_000_000) // gwei
+	MAX_PER_EPOCH_ACTIVATION_EXIT_CHURN_LIMIT = uint64(256_000_000_000) // gwei
+
+	PENDING_CONSOLIDATIONS_LIMIT      = uint64(262144)
+	PENDING_PARTIAL_WITHDRAWALS_LIMIT = uint64(134217728)
+
+	MAX_WITHDRAWALS_PER_PAYLOAD          = uint64(16)
+	MAX_VALIDATORS_PER_WITHDRAWALS_SWEEP = uint64(16384)
+)
+
+func CurrentEpoch(state *spec.VersionedBeaconState) (uint64, error) {
+	slot, err := state.Slot()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read slot from beacon state: %w", err)
+	}
+	return uint64(slot) / beacon.SLOTS_PER_EPOCH, nil
+}
+
+// ComputeActivationExitEpoch implements `compute_activation_exit_epoch`.
+func ComputeActivationExitEpoch(epoch uint64) uint64 {
+	return epoch + 1 + MAX_SEED_LOOKAHEAD
+}
+
+// IsActiveValidator implements `is_active_validator` from the phase0 spec.
+func IsActiveValidator(validator *phase0.Validator, epoch uint64) bool {
+	return uint64(validator.ActivationEpoch) <= epoch && epoch < uint64(validator.ExitEpoch)
+}
+
+func IsExitingValidator(validator *phase0.Validator) bool {
+	return uint64(validator.ExitEpoch) != FAR_FUTURE_EPOCH
+}
+
+// HasBeenActiveLongEnoughToExit returns whether `validator` has been active for at least
+// SHARD_COMMITTEE_PERIOD, which is required before it can exit or be a consolidation source.
+func HasBeenActiveLongEnoughToExit(validator *phase0.Validator, epoch uint64) bool {
+	return IsActiveValidator(validator, epoch) && epoch >= uint64(validator.ActivationEpoch)+SHARD_COMMITTEE_PERIOD
+}
+
+// GetTotalActiveBalance implements `get_total_active_balance`.
+func GetTotalActiveBalance(state *spec.VersionedBeaconState) (uint64, error) {
+	epoch, err := CurrentEpoch(state)
+	if err != nil {
+		return 0, err
+	}
+
+	validators, err := state.Validators()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read validators from beacon state: %w", err)
+	}
+
+	total := uint64(0)
+	for _, validator := range validators {
+		if IsActiveValidator(validator, epoch) {
+			total += uint64(validator.EffectiveBalance)
+		}
+	}
+	return max(EFFECTIVE_BALANCE_INCREMENT, total), nil
+}
+
+// GetBalanceChurnLimit implements `get_balance_churn_limit` (Electra).
+func GetBalanceChurnLimit(totalActiveBalance uint64) uint64 {
+	churn := max(MIN_PER_EPOCH_CHURN_LIMIT_ELECTRA, totalActiveBalance/CHURN_LIMIT_QUOTIENT)
+	return churn - churn%EFFECTIVE_BALANCE_INCREMENT
+}
+
+// GetActivationExitChurnLimit implements `get_activation_exit_churn_limit` (Electra).
+func GetActivationExitChurnLimit(totalActiveBalance uint64) uint64 {
+	return min(MAX_PER_EPOCH_ACTIVATION_EXIT_CHURN_LIMIT, GetBalanceChurnLimit(totalActiveBalance))
+}
+
+// GetConsolidationChurnLimit implements `get_consolidation_churn_limit` (Electra).
+func GetConsolidationChurnLimit(totalActiveBalance uint64) uint64 {
+	return GetBalanceChurnLimit(totalActiveBalance) - GetActivationExitChurnLimit(totalActiveBalance)
+}
+
+// GetPendingBalancesToWithdraw returns `get_pending_balance_to_withdraw` for every validator
+// with a pending partial withdrawal, keyed by validator index.
+func GetPendingBalancesToWithdraw(state *spec.VersionedBeaconState) (map[uint64]uint64, error) {
+	pendingWithdrawals, err := state.PendingPartialWithdrawals()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending partial withdrawals from beacon state: %w", err)
+	}
+
+	out := map[uint64]uint64{}
+	for _, withdrawal := range pendingWithdrawals {
+		out[uint64(withdrawal.ValidatorIndex)] += uint64(withdrawal.Amount)
+	}
+	return out, nil
+}
+
+// GetMaxEffectiveBalance implements `get_max_effective_balance` (Electra).
+func GetMaxEffectiveBalance(validator *phase0.Validator) uint64 {
+	if validator.WithdrawalCredentials[0] == CompoundingWithdrawalPrefix {
+		return MAX_EFFECTIVE_BALANCE_ELECTRA
+	}
+	return MIN_ACTIVATION_BALANCE
+}
+
+// IsSweepable returns whether the withdrawals sweep would withdraw anything from `validator` in `epoch`,
+// i.e. `is_fully_withdrawable_validator` or `is_partially_withdrawable_validator` (Electra).
+func IsSweepable(validator *phase0.Validator, balance uint64, epoch uint64) bool {
+	prefix := validator.WithdrawalCredentials[0]
+	if prefix != ETH1WithdrawalPrefix && prefix != CompoundingWithdrawalPrefix {
+		return false
+	}
+
+	if uint64(validator.WithdrawableEpoch) <= epoch && balance > 0 {
+		return true
+	}
+
+	maxEffectiveBalance := GetMaxEffectiveBalance(validator)
+	return uint64(validator.EffectiveBalance) == maxEffectiveBalance && balance > maxEffectiveBalance
+}
@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/golang/snappy"
+)
+
+// Era files (https://github.com/eth-clients/e2store-format-specs/blob/main/formats/era.md) archive
+// SLOTS_PER_HISTORICAL_ROOT slots of the beacon chain: the blocks of the era, and the state at the
+// first slot of the next era, before that slot's block. They are a sequence of e2store entries:
+//
+//	Version | block* | state | block-index | state-index
+//
+// where each entry is an 8 byte header (2 byte type, 4 byte little endian length, 2 zero bytes)
+// followed by its data, and blocks and states are snappy-framed SSZ.
+
+const SLOTS_PER_HISTORICAL_ROOT = 8192
+
+var (
+	e2TypeVersion         = [2]byte{0x65, 0x32}
+	e2TypeCompressedBlock = [2]byte{0x01, 0x00}
+	e2TypeCompressedState = [2]byte{0x02, 0x00}
+	e2TypeSlotIndex       = [2]byte{0x69, 0x32}
+)
+
+const e2HeaderSize = 8
+
+// eraFile is an open .era file, whose entries are read as needed.
+type eraFile struct {
+	path string
+	file *os.File
+
+	// blockStartSlot is the slot of blockOffsets[0]. An offset of 0 means the slot has no block.
+	blockStartSlot uint64
+	blockOffsets   []int64
+
+	stateSlot   uint64
+	stateOffset int64
+}
+
+// openEraFile opens `path` and reads its slot indices.
+func openEraFile(path string) (*eraFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	era := &eraFile{path: path, file: file}
+	if err := era.readIndices(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("invalid era file %s: %w", path, err)
+	}
+	return era, nil
+}
+
+func (e *eraFile) Close() error {
+	return e.file.Close()
+}
+
+// readIndices reads the state index, which ends the file, and the block index before it (which the
+// genesis era doesn't have).
+func (e *eraFile) readIndices() error {
+	info, err := e.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	version, _, err := e.readHeader(0)
+	if err != nil {
+		return err
+	}
+	if version != e2TypeVersion {
+		return errors.New("missing version entry")
+	}
+
+	// a state index has a single offset
+	stateIndexOffset := info.Size() - (e2HeaderSize + 8 + 8 + 8)
+	stateSlot, stateOffsets, err := e.readSlotIndex(stateIndexOffset)
+	if err != nil {
+		return err
+	}
+	if len(stateOffsets) != 1 {
+		return fmt.Errorf("state index has %d entries", len(stateOffsets))
+	}
+	e.stateSlot = stateSlot
+	e.stateOffset = stateOffsets[0]
+
+	if stateSlot == 0 {
+		return nil
+	}
+	blockIndexOffset := stateIndexOffset - (e2HeaderSize + 8 + 8*SLOTS_PER_HISTORICAL_ROOT + 8)
+	e.blockStartSlot, e.blockOffsets, err = e.readSlotIndex(blockIndexOffset)
+	if err != nil {
+		return err
+	}
+	if len(e.blockOffsets) != SLOTS_PER_HISTORICAL_ROOT {
+		return fmt.Errorf("block index has %d entries", len(e.blockOffsets))
+	}
+	return nil
+}
+
+// readSlotIndex reads the slot index entry at `offset`: its starting slot, and the absolute file
+// offsets of the entries of each slot (0 if there is none).
+func (e *eraFile) readSlotIndex(offset int64) (uint64, []int64, error) {
+	entryType, data, err := e.readEntry(offset)
+	if err != nil {
+		return 0, nil, err
+	}
+	if entryType != e2TypeSlotIndex {
+		return 0, nil, fmt.Errorf("expected slot index at offset %d", offset)
+	}
+	if len(data) < 16 || len(data)%8 != 0 {
+		return 0, nil, fmt.Errorf("invalid slot index at offset %d", offset)
+	}
+
+	startSlot := binary.LittleEndian.Uint64(data[:8])
+	count := binary.LittleEndian.Uint64(data[len(data)-8:])
+	if count != uint64(len(data)-16)/8 {
+		return 0, nil, fmt.Errorf("invalid slot index count at offset %d", offset)
+	}
+
+	offsets := make([]int64, count)
+	for i := range offsets {
+		// offsets are relative to the index entry
+		relative := int64(binary.LittleEndian.Uint64(data[8+8*i:]))
+		if relative != 0 {
+			offsets[i] = offset + relative
+		}
+	}
+	return startSlot, offsets, nil
+}
+
+func (e *eraFile) readHeader(offset int64) ([2]byte, uint32, error) {
+	header := make([]byte, e2HeaderSize)
+	if _, err := e.file.ReadAt(header, offset); err != nil {
+		return [2]byte{}, 0, err
+	}
+	if header[6] != 0 || header[7] != 0 {
+		return [2]byte{}, 0, fmt.Errorf("invalid entry header at offset %d", offset)
+	}
+	return [2]byte{header[0], header[1]}, binary.LittleEndian.Uint32(header[2:6]), nil
+}
+
+func (e *eraFile) readEntry(offset int64) ([2]byte, []byte, error) {
+	entryType, length, err := e.readHeader(offset)
+	if err != nil {
+		return [2]byte{}, nil, err
+	}
+	data := make([]byte, length)
+	if _, err := e.file.ReadAt(data, offset+e2HeaderSize); err != nil {
+		return [2]byte{}, nil, err
+	}
+	return entryType, data, nil
+}
+
+// readCompressed returns the decompressed SSZ of the entry of type `expectedType` at `offset`.
+func (e *eraFile) readCompressed(offset int64, expectedType [2]byte) ([]byte, error) {
+	entryType, length, err := e.readHeader(offset)
+	if err != nil {
+		return nil, err
+	}
+	if entryType != expectedType {
+		return nil, fmt.Errorf("unexpected entry type %x at offset %d", entryType, offset)
+	}
+	return io.ReadAll(snappy.NewReader(io.NewSectionReader(e.file, offset+e2HeaderSize, int64(length))))
+}
+
+// hasBlockSlot returns whether `slot` is one of the era's block slots.
+func (e *eraFile) hasBlockSlot(slot uint64) bool {
+	return slot >= e.blockStartSlot && slot-e.blockStartSlot < uint64(len(e.blockOffsets))
+}
+
+// readBlockSSZ returns the SSZ of the signed block at `slot`, or nil if the slot is empty.
+func (e *eraFile) readBlockSSZ(slot uint64) ([]byte, error) {
+	if !e.hasBlockSlot(slot) {
+		return nil, fmt.Errorf("slot %d is not in era file %s", slot, e.path)
+	}
+	offset := e.blockOffsets[slot-e.blockStartSlot]
+	if offset == 0 {
+		return nil, nil
+	}
+	return e.readCompressed(offset, e2TypeCompressedBlock)
+}
+
+// readStateSSZ returns the SSZ of the era's state (at stateSlot).
+func (e *eraFile) readStateSSZ() ([]byte, error) {
+	return e.readCompressed(e.stateOffset, e2TypeCompressedState)
+}
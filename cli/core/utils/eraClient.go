@@ -0,0 +1,432 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/electra"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/common"
+)
+
+var ErrNotSupportedByEra = errors.New("not supported when reading from era files")
+
+// eraClient is a BeaconClient reading archived blocks and states from local .era files, rather than
+// from a beacon node.
+//
+// An era file has every block of its era, but a single state: the one at the first slot of the next
+// era, before that slot's block. No block header commits to that state, so it can't be proven
+// against, and states aren't served: only blocks and headers. The states are still read to tell
+// which fork blocks belong to, and which network the files are from.
+type eraClient struct {
+	files   []*eraFile // by slot
+	verbose bool
+
+	mu sync.Mutex
+	// the roots of the blocks hashed so far, and how many blocks of each file were hashed
+	blockSlots map[phase0.Root]uint64
+	scanned    map[*eraFile]int
+	// states are large, so only the last one read is kept
+	stateFile *eraFile
+	state     *spec.VersionedBeaconState
+}
+
+// IsEraPath returns whether `beaconUri` is a local .era file, or a directory of them, rather than the
+// URL of a beacon node.
+func IsEraPath(beaconUri string) bool {
+	if strings.Contains(beaconUri, "://") {
+		return false
+	}
+	_, err := os.Stat(beaconUri)
+	return err == nil
+}
+
+// NewEraClient reads the .era file at `path`, or every .era file in the directory `path`.
+func NewEraClient(path string, verbose bool) (BeaconClient, error) {
+	paths := []string{path}
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		paths, err = filepath.Glob(filepath.Join(path, "*.era"))
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no .era files in %s", path)
+		}
+	}
+
+	client := &eraClient{
+		verbose:    verbose,
+		blockSlots: map[phase0.Root]uint64{},
+		scanned:    map[*eraFile]int{},
+	}
+	for _, path := range paths {
+		era, err := openEraFile(path)
+		if err != nil {
+			for _, opened := range client.files {
+				opened.Close()
+			}
+			return nil, err
+		}
+		client.files = append(client.files, era)
+	}
+	sort.Slice(client.files, func(i, j int) bool {
+		return client.files[i].stateSlot < client.files[j].stateSlot
+	})
+
+	if verbose {
+		log.Info().Msgf("read %d era files, with blocks from slot %d and states up to slot %d", len(client.files), client.files[0].blockStartSlot, client.files[len(client.files)-1].stateSlot)
+	}
+	return client, nil
+}
+
+func (c *eraClient) GetBeaconHeader(ctx context.Context, blockId string) (*v1.BeaconBlockHeader, error) {
+	era, slot, err := c.findBlock(blockId)
+	if err != nil {
+		return nil, err
+	}
+	data, err := era.readBlockSSZ(slot)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("no block at slot %d", slot)
+	}
+
+	header, signature, err := decodeBlockHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	root, err := header.HashTreeRoot()
+	if err != nil {
+		return nil, err
+	}
+	return &v1.BeaconBlockHeader{
+		Root:      root,
+		Canonical: true,
+		Header: &phase0.SignedBeaconBlockHeader{
+			Message:   header,
+			Signature: signature,
+		},
+	}, nil
+}
+
+func (c *eraClient) GetBlock(ctx context.Context, blockId string) (*spec.VersionedSignedBeaconBlock, error) {
+	era, slot, err := c.findBlock(blockId)
+	if err != nil {
+		return nil, err
+	}
+	data, err := era.readBlockSSZ(slot)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	// blocks don't say which fork they belong to, but their era's state does
+	state, err := c.readState(era)
+	if err != nil {
+		return nil, err
+	}
+	version, err := blockVersion(state, slot)
+	if err != nil {
+		return nil, err
+	}
+
+	block := &spec.VersionedSignedBeaconBlock{Version: version}
+	switch version {
+	case spec.DataVersionFulu:
+		block.Fulu = &electra.SignedBeaconBlock{}
+		err = block.Fulu.UnmarshalSSZ(data)
+	case spec.DataVersionElectra:
+		block.Electra = &electra.SignedBeaconBlock{}
+		err = block.Electra.UnmarshalSSZ(data)
+	case spec.DataVersionDeneb:
+		block.Deneb = &deneb.SignedBeaconBlock{}
+		err = block.Deneb.UnmarshalSSZ(data)
+	default:
+		return nil, fmt.Errorf("unsupported block version %s", version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode block at slot %d: %w", slot, err)
+	}
+	return block, nil
+}
+
+// GetBeaconState fails: an era file's state is taken before the block at its slot is applied, so no
+// block header's state root commits to it, and proofs made from it could never be verified.
+func (c *eraClient) GetBeaconState(ctx context.Context, stateId string) (*spec.VersionedBeaconState, error) {
+	return nil, fmt.Errorf("%w: era files only hold the state from before the block at the start of each era, which no block header commits to, so it can't be proven", ErrNotSupportedByEra)
+}
+
+func (c *eraClient) GetValidator(ctx context.Context, index uint64) (*v1.Validator, error) {
+	// the latest state stands in for the head
+	state, err := c.readState(c.files[len(c.files)-1])
+	if err != nil {
+		return nil, err
+	}
+	validators, err := stateValidators(state, []phase0.ValidatorIndex{phase0.ValidatorIndex(index)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return validators[phase0.ValidatorIndex(index)], nil
+}
+
+func (c *eraClient) GetValidators(ctx context.Context, stateId string, ids []string) (map[phase0.ValidatorIndex]*v1.Validator, error) {
+	_, err := c.GetBeaconState(ctx, stateId)
+	return nil, err
+}
+
+func (c *eraClient) GetBalances(ctx context.Context, stateId string, ids []string) (map[phase0.ValidatorIndex]phase0.Gwei, error) {
+	_, err := c.GetBeaconState(ctx, stateId)
+	return nil, err
+}
+
+func (c *eraClient) GetGenesisForkVersion(ctx context.Context) (*phase0.Version, error) {
+	return nil, ErrNotSupportedByEra
+}
+
+// GenesisValidatorsRoot returns the genesis validators root of the chain the era files archive,
+// which identifies its network.
+func (c *eraClient) GenesisValidatorsRoot() (phase0.Root, error) {
+	state, err := c.readState(c.files[len(c.files)-1])
+	if err != nil {
+		return phase0.Root{}, err
+	}
+	return beacon.GetGenesisValidatorsRoot(state)
+}
+
+func (c *eraClient) GetStateProof(ctx context.Context, stateId string, gindices []uint64) (*common.CompactMultiproof, error) {
+	return nil, ErrNotSupportedByEra
+}
+
+func (c *eraClient) SubscribeEvents(ctx context.Context, topics ...string) (<-chan BeaconEvent, error) {
+	return nil, ErrNotSupportedByEra
+}
+
+// findBlock returns the era file and slot of the block `blockId` (a slot, a 0x-prefixed root, or
+// "head", which is the latest archived block).
+func (c *eraClient) findBlock(blockId string) (*eraFile, uint64, error) {
+	if blockId == "head" {
+		last := c.files[len(c.files)-1]
+		for i := len(last.blockOffsets) - 1; i >= 0; i-- {
+			if last.blockOffsets[i] != 0 {
+				return last, last.blockStartSlot + uint64(i), nil
+			}
+		}
+		return nil, 0, fmt.Errorf("%s has no blocks", last.path)
+	}
+	if !strings.HasPrefix(blockId, "0x") {
+		slot, err := strconv.ParseUint(blockId, 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("era files can only look up blocks by slot, root or \"head\", not %q", blockId)
+		}
+		for _, era := range c.files {
+			if era.hasBlockSlot(slot) {
+				return era, slot, nil
+			}
+		}
+		return nil, 0, fmt.Errorf("slot %d is not in any era file", slot)
+	}
+
+	rootBytes, err := hexutil.Decode(blockId)
+	if err != nil || len(rootBytes) != len(phase0.Root{}) {
+		return nil, 0, fmt.Errorf("invalid block root %s", blockId)
+	}
+	root := phase0.Root(rootBytes)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if slot, ok := c.blockSlots[root]; ok {
+		era, _, err := c.findBlock(strconv.FormatUint(slot, 10))
+		return era, slot, err
+	}
+
+	// hash the blocks we haven't yet until we find it
+	for _, era := range c.files {
+		for i := c.scanned[era]; i < len(era.blockOffsets); i++ {
+			c.scanned[era] = i + 1
+			slot := era.blockStartSlot + uint64(i)
+			data, err := era.readBlockSSZ(slot)
+			if err != nil {
+				return nil, 0, err
+			}
+			if data == nil {
+				continue
+			}
+			header, _, err := decodeBlockHeader(data)
+			if err != nil {
+				return nil, 0, err
+			}
+			headerRoot, err := header.HashTreeRoot()
+			if err != nil {
+				return nil, 0, err
+			}
+			c.blockSlots[headerRoot] = slot
+			if headerRoot == root {
+				return era, slot, nil
+			}
+		}
+	}
+	return nil, 0, fmt.Errorf("block %s is not in any era file", blockId)
+}
+
+func (c *eraClient) readState(era *eraFile) (*spec.VersionedBeaconState, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stateFile == era {
+		return c.state, nil
+	}
+
+	if c.verbose {
+		log.Info().Msgf("reading beacon state %d from %s", era.stateSlot, era.path)
+	}
+	data, err := era.readStateSSZ()
+	if err != nil {
+		return nil, err
+	}
+	state, err := beacon.UnmarshalSSZVersionedBeaconState(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode beacon state in %s: %w", era.path, err)
+	}
+
+	c.stateFile = era
+	c.state = state
+	return state, nil
+}
+
+// decodeBlockHeader returns the header of a signed block, and its signature. Blocks of every
+// supported fork start with the same fields, but their bodies differ, so each is tried in turn.
+func decodeBlockHeader(data []byte) (*phase0.BeaconBlockHeader, phase0.BLSSignature, error) {
+	electraBlock := &electra.SignedBeaconBlock{}
+	if err := electraBlock.UnmarshalSSZ(data); err == nil {
+		bodyRoot, err := electraBlock.Message.Body.HashTreeRoot()
+		if err != nil {
+			return nil, phase0.BLSSignature{}, err
+		}
+		return &phase0.BeaconBlockHeader{
+			Slot:          electraBlock.Message.Slot,
+			ProposerIndex: electraBlock.Message.ProposerIndex,
+			ParentRoot:    electraBlock.Message.ParentRoot,
+			StateRoot:     electraBlock.Message.StateRoot,
+			BodyRoot:      bodyRoot,
+		}, electraBlock.Signature, nil
+	}
+
+	denebBlock := &deneb.SignedBeaconBlock{}
+	if err := denebBlock.UnmarshalSSZ(data); err != nil {
+		return nil, phase0.BLSSignature{}, fmt.Errorf("failed to decode block: %w", err)
+	}
+	bodyRoot, err := denebBlock.Message.Body.HashTreeRoot()
+	if err != nil {
+		return nil, phase0.BLSSignature{}, err
+	}
+	return &phase0.BeaconBlockHeader{
+		Slot:          denebBlock.Message.Slot,
+		ProposerIndex: denebBlock.Message.ProposerIndex,
+		ParentRoot:    denebBlock.Message.ParentRoot,
+		StateRoot:     denebBlock.Message.StateRoot,
+		BodyRoot:      bodyRoot,
+	}, denebBlock.Signature, nil
+}
+
+// blockVersion returns the fork of the block at `slot`, from the state at the end of its era: the
+// state's fork, or the one before it for blocks before the state's fork epoch.
+func blockVersion(state *spec.VersionedBeaconState, slot uint64) (spec.DataVersion, error) {
+	var fork *phase0.Fork
+	switch state.Version {
+	case spec.DataVersionFulu:
+		fork = state.Fulu.Fork
+	case spec.DataVersionElectra:
+		fork = state.Electra.Fork
+	case spec.DataVersionDeneb:
+		fork = state.Deneb.Fork
+	default:
+		return spec.DataVersionUnknown, errors.New("unsupported beacon state version")
+	}
+	if phase0.Epoch(slot/32) >= fork.Epoch {
+		return state.Version, nil
+	}
+
+	switch state.Version {
+	case spec.DataVersionFulu:
+		return spec.DataVersionElectra, nil
+	case spec.DataVersionElectra:
+		return spec.DataVersionDeneb, nil
+	default:
+		return spec.DataVersionUnknown, fmt.Errorf("unsupported block version before %s", state.Version)
+	}
+}
+
+func hashBeaconState(state *spec.VersionedBeaconState) (phase0.Root, error) {
+	switch state.Version {
+	case spec.DataVersionFulu:
+		return state.Fulu.HashTreeRoot()
+	case spec.DataVersionElectra:
+		return state.Electra.HashTreeRoot()
+	case spec.DataVersionDeneb:
+		return state.Deneb.HashTreeRoot()
+	default:
+		return phase0.Root{}, errors.New("unsupported beacon state version")
+	}
+}
+
+// stateValidators returns the validators of `state` with the given indices or pubkeys, as the
+// beacon API would.
+func stateValidators(state *spec.VersionedBeaconState, indices []phase0.ValidatorIndex, pubkeys []phase0.BLSPubKey) (map[phase0.ValidatorIndex]*v1.Validator, error) {
+	validators, err := state.Validators()
+	if err != nil {
+		return nil, err
+	}
+	balances, err := state.ValidatorBalances()
+	if err != nil {
+		return nil, err
+	}
+	slot, err := state.Slot()
+	if err != nil {
+		return nil, err
+	}
+	epoch := phase0.Epoch(uint64(slot) / 32)
+
+	wanted := map[phase0.ValidatorIndex]bool{}
+	for _, index := range indices {
+		wanted[index] = true
+	}
+	wantedPubkeys := map[phase0.BLSPubKey]bool{}
+	for _, pubkey := range pubkeys {
+		wantedPubkeys[pubkey] = true
+	}
+
+	result := map[phase0.ValidatorIndex]*v1.Validator{}
+	for i, validator := range validators {
+		index := phase0.ValidatorIndex(i)
+		if !wanted[index] && !wantedPubkeys[validator.PublicKey] {
+			continue
+		}
+		result[index] = &v1.Validator{
+			Index:     index,
+			Balance:   balances[i],
+			Status:    v1.ValidatorToState(validator, &balances[i], epoch, phase0.Epoch(FAR_FUTURE_EPOCH)),
+			Validator: validator,
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/golang/snappy"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// e2Writer writes an e2store file, keeping track of the offset of each entry.
+type e2Writer struct {
+	buf bytes.Buffer
+}
+
+func (w *e2Writer) entry(entryType [2]byte, data []byte) int64 {
+	offset := int64(w.buf.Len())
+	header := make([]byte, e2HeaderSize)
+	copy(header, entryType[:])
+	binary.LittleEndian.PutUint32(header[2:], uint32(len(data)))
+	w.buf.Write(header)
+	w.buf.Write(data)
+	return offset
+}
+
+func (w *e2Writer) compressed(t *testing.T, entryType [2]byte, ssz []byte) int64 {
+	var compressed bytes.Buffer
+	writer := snappy.NewBufferedWriter(&compressed)
+	_, err := writer.Write(ssz)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return w.entry(entryType, compressed.Bytes())
+}
+
+func (w *e2Writer) slotIndex(startSlot uint64, offsets []int64) {
+	indexOffset := int64(w.buf.Len())
+	data := binary.LittleEndian.AppendUint64(nil, startSlot)
+	for _, offset := range offsets {
+		relative := int64(0)
+		if offset != 0 {
+			relative = offset - indexOffset
+		}
+		data = binary.LittleEndian.AppendUint64(data, uint64(relative))
+	}
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(offsets)))
+	w.entry(e2TypeSlotIndex, data)
+}
+
+func newTestBlock(slot uint64) *deneb.SignedBeaconBlock {
+	return &deneb.SignedBeaconBlock{
+		Message: &deneb.BeaconBlock{
+			Slot:          phase0.Slot(slot),
+			ProposerIndex: phase0.ValidatorIndex(slot % 7),
+			StateRoot:     phase0.Root{byte(slot)},
+			Body: &deneb.BeaconBlockBody{
+				ETH1Data:      &phase0.ETH1Data{BlockHash: make([]byte, 32)},
+				SyncAggregate: &altair.SyncAggregate{SyncCommitteeBits: make([]byte, 64)},
+				ExecutionPayload: &deneb.ExecutionPayload{
+					BlockNumber:   slot,
+					BaseFeePerGas: uint256.NewInt(7),
+				},
+			},
+		},
+	}
+}
+
+func newTestState(slot uint64) *deneb.BeaconState {
+	state := &deneb.BeaconState{
+		GenesisValidatorsRoot:        phase0.Root{0x4b},
+		Slot:                         phase0.Slot(slot),
+		Fork:                         &phase0.Fork{},
+		BlockRoots:                   make([]phase0.Root, 8192),
+		StateRoots:                   make([]phase0.Root, 8192),
+		RANDAOMixes:                  make([]phase0.Root, 65536),
+		Slashings:                    make([]phase0.Gwei, 8192),
+		JustificationBits:            []byte{0},
+		ETH1Data:                     &phase0.ETH1Data{BlockHash: make([]byte, 32)},
+		PreviousJustifiedCheckpoint:  &phase0.Checkpoint{},
+		CurrentJustifiedCheckpoint:   &phase0.Checkpoint{},
+		FinalizedCheckpoint:          &phase0.Checkpoint{},
+		CurrentSyncCommittee:         &altair.SyncCommittee{Pubkeys: make([]phase0.BLSPubKey, 512)},
+		NextSyncCommittee:            &altair.SyncCommittee{Pubkeys: make([]phase0.BLSPubKey, 512)},
+		LatestExecutionPayloadHeader: &deneb.ExecutionPayloadHeader{BaseFeePerGas: uint256.NewInt(7)},
+	}
+	for i := 0; i < 3; i++ {
+		state.Validators = append(state.Validators, &phase0.Validator{
+			PublicKey:             phase0.BLSPubKey{byte(i + 1)},
+			WithdrawalCredentials: make([]byte, 32),
+			EffectiveBalance:      32_000_000_000,
+			ExitEpoch:             phase0.Epoch(FAR_FUTURE_EPOCH),
+			WithdrawableEpoch:     phase0.Epoch(FAR_FUTURE_EPOCH),
+		})
+		state.Balances = append(state.Balances, phase0.Gwei(32_000_000_000+i))
+		state.PreviousEpochParticipation = append(state.PreviousEpochParticipation, 0)
+		state.CurrentEpochParticipation = append(state.CurrentEpochParticipation, 0)
+		state.InactivityScores = append(state.InactivityScores, 0)
+	}
+	return state
+}
+
+// writeTestEra writes the era file ending at `stateSlot`, with blocks at `blockSlots`.
+func writeTestEra(t *testing.T, dir string, stateSlot uint64, blockSlots []uint64) {
+	w := &e2Writer{}
+	w.entry(e2TypeVersion, nil)
+
+	startSlot := stateSlot - SLOTS_PER_HISTORICAL_ROOT
+	blockOffsets := make([]int64, SLOTS_PER_HISTORICAL_ROOT)
+	for _, slot := range blockSlots {
+		ssz, err := newTestBlock(slot).MarshalSSZ()
+		require.NoError(t, err)
+		blockOffsets[slot-startSlot] = w.compressed(t, e2TypeCompressedBlock, ssz)
+	}
+
+	ssz, err := newTestState(stateSlot).MarshalSSZ()
+	require.NoError(t, err)
+	stateOffset := w.compressed(t, e2TypeCompressedState, ssz)
+
+	w.slotIndex(startSlot, blockOffsets)
+	w.slotIndex(stateSlot, []int64{stateOffset})
+
+	path := filepath.Join(dir, "test-00001-00000000.era")
+	require.NoError(t, os.WriteFile(path, w.buf.Bytes(), 0644))
+}
+
+func TestEraClient(t *testing.T) {
+	dir := t.TempDir()
+	writeTestEra(t, dir, SLOTS_PER_HISTORICAL_ROOT, []uint64{5, 100, 8191})
+
+	require.True(t, IsEraPath(dir))
+	client, err := NewEraClient(dir, false)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// by slot
+	header, err := client.GetBeaconHeader(ctx, "100")
+	require.NoError(t, err)
+	expected := newTestBlock(100)
+	assert.Equal(t, expected.Message.StateRoot, header.Header.Message.StateRoot)
+	expectedRoot, err := expected.Message.HashTreeRoot()
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Root(expectedRoot), header.Root)
+
+	// by root
+	byRoot, err := client.GetBeaconHeader(ctx, header.Root.String())
+	require.NoError(t, err)
+	assert.Equal(t, header, byRoot)
+
+	block, err := client.GetBlock(ctx, header.Root.String())
+	require.NoError(t, err)
+	assert.Equal(t, spec.DataVersionDeneb, block.Version)
+	assert.Equal(t, uint64(100), block.Deneb.Message.Body.ExecutionPayload.BlockNumber)
+
+	// empty slots
+	_, err = client.GetBeaconHeader(ctx, "101")
+	assert.Error(t, err)
+	block, err = client.GetBlock(ctx, "101")
+	require.NoError(t, err)
+	assert.Nil(t, block)
+
+	// the archived state precedes the block at its slot, so it can't be proven against
+	_, err = client.GetBeaconState(ctx, "8192")
+	assert.ErrorIs(t, err, ErrNotSupportedByEra)
+	_, err = client.GetValidators(ctx, "8192", []string{"1"})
+	assert.ErrorIs(t, err, ErrNotSupportedByEra)
+
+	// but it still tells the network, and stands in for the head's validators
+	genesisValidatorsRoot, err := client.(*eraClient).GenesisValidatorsRoot()
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Root{0x4b}, genesisValidatorsRoot)
+	validator, err := client.GetValidator(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Gwei(32_000_000_001), validator.Balance)
+
+	// the latest archived block stands in for the head
+	head, err := client.GetBeaconHeader(ctx, "head")
+	require.NoError(t, err)
+	assert.Equal(t, phase0.Slot(8191), head.Header.Message.Slot)
+
+	_, err = client.GetBeaconHeader(ctx, "finalized")
+	assert.Error(t, err)
+}
@@ -0,0 +1,387 @@
+package utils
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/DelegationManager"
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPodManager"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fatih/color"
+)
+
+type namedABI struct {
+	Name string
+	ABI  *abi.ABI
+}
+
+var loadKnownABIs = sync.OnceValues(func() ([]namedABI, error) {
+	eigenPodAbi, err := EigenPod.EigenPodMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	eigenPodManagerAbi, err := EigenPodManager.EigenPodManagerMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	delegationManagerAbi, err := DelegationManager.DelegationManagerMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+
+	legacyEigenPodAbi, err := LegacyEigenPodABI()
+	if err != nil {
+		return nil, err
+	}
+	legacyDelegationManagerAbi, err := LegacyDelegationManagerABI()
+	if err != nil {
+		return nil, err
+	}
+
+	return []namedABI{
+		{Name: "EigenPod", ABI: eigenPodAbi},
+		{Name: "EigenPodManager", ABI: eigenPodManagerAbi},
+		{Name: "DelegationManager", ABI: delegationManagerAbi},
+		{Name: "EigenPod", ABI: legacyEigenPodAbi},
+		{Name: "DelegationManager", ABI: legacyDelegationManagerAbi},
+	}, nil
+})
+
+// knownABIs returns the ABIs of the contracts the CLI interacts with, in the order
+// they should be searched when decoding events or errors.
+func knownABIs() []namedABI {
+	abis, err := loadKnownABIs()
+	PanicOnError("failed to parse contract ABIs", err)
+	return abis
+}
+
+type EventArg struct {
+	Name  string
+	Value interface{}
+}
+
+type DecodedEvent struct {
+	Contract string
+	Address  common.Address
+	Name     string
+	Args     []EventArg
+}
+
+func (e DecodedEvent) Arg(name string) interface{} {
+	for _, arg := range e.Args {
+		if arg.Name == name {
+			return arg.Value
+		}
+	}
+	return nil
+}
+
+// ArgUint64 returns the argument `name`, and whether the event has it as a uint64.
+func (e DecodedEvent) ArgUint64(name string) (uint64, bool) {
+	value, ok := e.Arg(name).(uint64)
+	return value, ok
+}
+
+// ArgBig returns the argument `name`, and whether the event has it as an integer wider than 64 bits.
+func (e DecodedEvent) ArgBig(name string) (*big.Int, bool) {
+	value, ok := e.Arg(name).(*big.Int)
+	return value, ok && value != nil
+}
+
+// ArgBytes32 returns the argument `name`, and whether the event has it as a bytes32.
+func (e DecodedEvent) ArgBytes32(name string) ([32]byte, bool) {
+	value, ok := e.Arg(name).([32]byte)
+	return value, ok
+}
+
+// ArgAddress returns the argument `name`, and whether the event has it as an address.
+func (e DecodedEvent) ArgAddress(name string) (common.Address, bool) {
+	value, ok := e.Arg(name).(common.Address)
+	return value, ok
+}
+
+func (e DecodedEvent) String() string {
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = fmt.Sprintf("%s=%s", arg.Name, formatEventValue(arg.Value))
+	}
+	return fmt.Sprintf("%s.%s(%s)", e.Contract, e.Name, strings.Join(args, ", "))
+}
+
+// ArgsJSON encodes the event's arguments as a JSON object keyed by argument name. Byte strings are
+// hex-encoded and integers that may not fit in a float64 are encoded as decimal strings.
+func (e DecodedEvent) ArgsJSON() ([]byte, error) {
+	args := make(map[string]interface{}, len(e.Args))
+	for _, arg := range e.Args {
+		switch value := arg.Value.(type) {
+		case []byte, [32]byte, *big.Int, []*big.Int:
+			args[arg.Name] = formatEventValue(value)
+		default:
+			args[arg.Name] = value
+		}
+	}
+	return json.Marshal(args)
+}
+
+// Describe returns a human-readable account of the state change the event records, falling back
+// to the raw event for events without a description.
+func (e DecodedEvent) Describe() string {
+	describe, ok := eventDescriptions[e.Name]
+	if !ok {
+		return e.String()
+	}
+	return describe(e)
+}
+
+var eventDescriptions = map[string]func(e DecodedEvent) string{
+	"CheckpointCreated": func(e DecodedEvent) string {
+		return fmt.Sprintf("checkpoint %d started, covering %s validator(s)", e.Arg("checkpointTimestamp"), formatEventValue(e.Arg("validatorCount")))
+	},
+	"CheckpointFinalized": func(e DecodedEvent) string {
+		return fmt.Sprintf("checkpoint %d finalized, pod shares changed by %s ETH", e.Arg("checkpointTimestamp"), formatEther(e.Arg("totalShareDeltaWei")))
+	},
+	"ValidatorCheckpointed": func(e DecodedEvent) string {
+		return fmt.Sprintf("validator %s checkpointed", describeValidator(e))
+	},
+	"ValidatorWithdrawn": func(e DecodedEvent) string {
+		return fmt.Sprintf("validator %s has fully exited and is now WITHDRAWN", describeValidator(e))
+	},
+	"ValidatorRestaked": func(e DecodedEvent) string {
+		return fmt.Sprintf("validator %s is now ACTIVE in the pod", describeValidator(e))
+	},
+	"ValidatorBalanceUpdated": func(e DecodedEvent) string {
+		return fmt.Sprintf("validator %s balance is now %s ETH", describeValidator(e), formatGweiAsEther(e.Arg("newValidatorBalanceGwei")))
+	},
+	"WithdrawalRequested": func(e DecodedEvent) string {
+		return fmt.Sprintf("requested a withdrawal of %d gwei from validator %s", e.Arg("withdrawalAmountGwei"), formatEventValue(e.Arg("validatorPubkeyHash")))
+	},
+	"ExitRequested": func(e DecodedEvent) string {
+		return fmt.Sprintf("requested a full exit of validator %s", formatEventValue(e.Arg("validatorPubkeyHash")))
+	},
+	"ConsolidationRequested": func(e DecodedEvent) string {
+		return fmt.Sprintf("requested consolidation of validator %s into %s", formatEventValue(e.Arg("sourcePubkeyHash")), formatEventValue(e.Arg("targetPubkeyHash")))
+	},
+	"SwitchToCompoundingRequested": func(e DecodedEvent) string {
+		return fmt.Sprintf("requested validator %s switch to 0x02 credentials", formatEventValue(e.Arg("validatorPubkeyHash")))
+	},
+	"ProofSubmitterUpdated": func(e DecodedEvent) string {
+		return fmt.Sprintf("proof submitter changed from %s to %s", formatEventValue(e.Arg("prevProofSubmitter")), formatEventValue(e.Arg("newProofSubmitter")))
+	},
+	"PodSharesUpdated": func(e DecodedEvent) string {
+		return fmt.Sprintf("shares of %s changed by %s ETH", formatEventValue(e.Arg("podOwner")), formatEther(e.Arg("sharesDelta")))
+	},
+	"NewTotalShares": func(e DecodedEvent) string {
+		return fmt.Sprintf("%s now has %s ETH of shares", formatEventValue(e.Arg("podOwner")), formatEther(e.Arg("newTotalShares")))
+	},
+	"BeaconChainSlashingFactorDecreased": func(e DecodedEvent) string {
+		return fmt.Sprintf("beacon chain slashing factor of %s decreased from %d to %d", formatEventValue(e.Arg("staker")), e.Arg("prevBeaconChainSlashingFactor"), e.Arg("newBeaconChainSlashingFactor"))
+	},
+	"SlashingWithdrawalQueued": func(e DecodedEvent) string {
+		return fmt.Sprintf("withdrawal %s queued (shares: %s)", formatEventValue(e.Arg("withdrawalRoot")), formatEventValue(e.Arg("sharesToWithdraw")))
+	},
+	"SlashingWithdrawalCompleted": func(e DecodedEvent) string {
+		return fmt.Sprintf("withdrawal %s completed", formatEventValue(e.Arg("withdrawalRoot")))
+	},
+	"WithdrawalQueued": func(e DecodedEvent) string {
+		return fmt.Sprintf("withdrawal %s queued", formatEventValue(e.Arg("withdrawalRoot")))
+	},
+	"WithdrawalCompleted": func(e DecodedEvent) string {
+		return fmt.Sprintf("withdrawal %s completed", formatEventValue(e.Arg("withdrawalRoot")))
+	},
+	"WithdrawalRequestAdded": func(e DecodedEvent) string {
+		return fmt.Sprintf("predeploy queued a withdrawal of %d gwei for validator %s", e.Arg("amountGwei"), formatEventValue(e.Arg("pubkey")))
+	},
+	"ConsolidationRequestAdded": func(e DecodedEvent) string {
+		return fmt.Sprintf("predeploy queued consolidation of validator %s into %s", formatEventValue(e.Arg("sourcePubkey")), formatEventValue(e.Arg("targetPubkey")))
+	},
+}
+
+// DecodeLogs decodes every log emitted by the EigenPod, EigenPodManager, DelegationManager and
+// EIP-7002/7251 predeploys. Logs that can't be decoded are skipped.
+func DecodeLogs(logs []*types.Log) []DecodedEvent {
+	events := []DecodedEvent{}
+	for _, log := range logs {
+		if event, ok := DecodeLog(log); ok {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func DecodeLog(log *types.Log) (DecodedEvent, bool) {
+	switch log.Address {
+	case WITHDRAWAL_PREDEPLOY:
+		return decodeWithdrawalPredeployLog(log)
+	case CONSOLIDATION_PREDEPLOY:
+		return decodeConsolidationPredeployLog(log)
+	}
+
+	if len(log.Topics) == 0 {
+		return DecodedEvent{}, false
+	}
+
+	for _, contract := range knownABIs() {
+		event, err := contract.ABI.EventByID(log.Topics[0])
+		if err != nil {
+			continue
+		}
+
+		values := map[string]interface{}{}
+		if err := contract.ABI.UnpackIntoMap(values, event.Name, log.Data); err != nil {
+			continue
+		}
+
+		indexed := abi.Arguments{}
+		for _, input := range event.Inputs {
+			if input.Indexed {
+				indexed = append(indexed, input)
+			}
+		}
+		if err := abi.ParseTopicsIntoMap(values, indexed, log.Topics[1:]); err != nil {
+			continue
+		}
+
+		args := make([]EventArg, len(event.Inputs))
+		for i, input := range event.Inputs {
+			args[i] = EventArg{Name: input.Name, Value: values[input.Name]}
+		}
+
+		return DecodedEvent{
+			Contract: contract.Name,
+			Address:  log.Address,
+			Name:     event.Name,
+			Args:     args,
+		}, true
+	}
+
+	return DecodedEvent{}, false
+}
+
+// The EIP-7002 predeploy logs `source_address ++ validator_pubkey ++ amount` (20 + 48 + 8 bytes).
+func decodeWithdrawalPredeployLog(log *types.Log) (DecodedEvent, bool) {
+	if len(log.Data) != 76 {
+		return DecodedEvent{}, false
+	}
+
+	return DecodedEvent{
+		Contract: "WithdrawalPredeploy",
+		Address:  log.Address,
+		Name:     "WithdrawalRequestAdded",
+		Args: []EventArg{
+			{Name: "sourceAddress", Value: common.BytesToAddress(log.Data[0:20])},
+			{Name: "pubkey", Value: log.Data[20:68]},
+			{Name: "amountGwei", Value: binary.BigEndian.Uint64(log.Data[68:76])},
+		},
+	}, true
+}
+
+// The EIP-7251 predeploy logs `source_address ++ source_pubkey ++ target_pubkey` (20 + 48 + 48 bytes).
+func decodeConsolidationPredeployLog(log *types.Log) (DecodedEvent, bool) {
+	if len(log.Data) != 116 {
+		return DecodedEvent{}, false
+	}
+
+	return DecodedEvent{
+		Contract: "ConsolidationPredeploy",
+		Address:  log.Address,
+		Name:     "ConsolidationRequestAdded",
+		Args: []EventArg{
+			{Name: "sourceAddress", Value: common.BytesToAddress(log.Data[0:20])},
+			{Name: "sourcePubkey", Value: log.Data[20:68]},
+			{Name: "targetPubkey", Value: log.Data[68:116]},
+		},
+	}, true
+}
+
+// describeValidator names the validator of an EigenPod event: by pubkey hash, or by index for events
+// emitted before the slashing upgrade.
+func describeValidator(e DecodedEvent) string {
+	if index, ok := e.ArgBig("validatorIndex"); ok {
+		return fmt.Sprintf("#%s", index)
+	}
+	return formatEventValue(e.Arg("pubkeyHash"))
+}
+
+// formatEther formats a wei amount in ETH, or falls back to formatEventValue for other values.
+func formatEther(v interface{}) string {
+	if wei, ok := v.(*big.Int); ok && wei != nil {
+		return IweiToEther(wei).String()
+	}
+	return formatEventValue(v)
+}
+
+// formatGweiAsEther formats a gwei amount in ETH, or falls back to formatEventValue for other values.
+func formatGweiAsEther(v interface{}) string {
+	if gwei, ok := v.(uint64); ok {
+		return GweiToEther(new(big.Float).SetUint64(gwei)).String()
+	}
+	return formatEventValue(v)
+}
+
+func formatEventValue(v interface{}) string {
+	switch value := v.(type) {
+	case []byte:
+		return "0x" + hex.EncodeToString(value)
+	case [32]byte:
+		return "0x" + hex.EncodeToString(value[:])
+	case common.Address:
+		return value.Hex()
+	case *big.Int:
+		return value.String()
+	case []*big.Int:
+		out := make([]string, len(value))
+		for i, x := range value {
+			out[i] = x.String()
+		}
+		return "[" + strings.Join(out, ",") + "]"
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+type ReceiptSummary struct {
+	TxHash    common.Hash
+	Succeeded bool
+	GasUsed   uint64
+
+	// gasUsed * effectiveGasPrice + msg.value
+	EthSpentWei *big.Int
+
+	Events []DecodedEvent
+}
+
+// SummarizeReceipt decodes `receipt`'s logs and computes the total ETH spent by `txn`.
+func SummarizeReceipt(txn *types.Transaction, receipt *types.Receipt) ReceiptSummary {
+	spent := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+	if txn != nil && txn.Value() != nil {
+		spent.Add(spent, txn.Value())
+	}
+
+	return ReceiptSummary{
+		TxHash:      receipt.TxHash,
+		Succeeded:   receipt.Status == types.ReceiptStatusSuccessful,
+		GasUsed:     receipt.GasUsed,
+		EthSpentWei: spent,
+		Events:      DecodeLogs(receipt.Logs),
+	}
+}
+
+func PrintReceiptSummary(summary ReceiptSummary) {
+	if summary.Succeeded {
+		color.Green("txn %s succeeded (gas used: %d, spent: %s ETH)", summary.TxHash.Hex(), summary.GasUsed, IweiToEther(summary.EthSpentWei).String())
+	} else {
+		color.Red("txn %s REVERTED (gas used: %d, spent: %s ETH)", summary.TxHash.Hex(), summary.GasUsed, IweiToEther(summary.EthSpentWei).String())
+	}
+
+	for _, event := range summary.Events {
+		fmt.Printf("\t- %s\n", event.Describe())
+	}
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeMalformedEvents(t *testing.T) {
+	for name := range eventDescriptions {
+		t.Run(name, func(t *testing.T) {
+			assert.NotPanics(t, func() {
+				// missing arguments
+				DecodedEvent{Name: name}.Describe()
+				// arguments of the wrong type
+				DecodedEvent{Name: name, Args: []EventArg{
+					{Name: "totalShareDeltaWei", Value: uint64(1)},
+					{Name: "newValidatorBalanceGwei", Value: big.NewInt(1)},
+					{Name: "sharesDelta", Value: (*big.Int)(nil)},
+					{Name: "pubkey", Value: "0x01"},
+				}}.Describe()
+			})
+		})
+	}
+
+	event := DecodedEvent{Name: "ValidatorBalanceUpdated", Args: []EventArg{
+		{Name: "pubkeyHash", Value: [32]byte{0x1}},
+		{Name: "newValidatorBalanceGwei", Value: "32"},
+	}}
+	assert.Contains(t, event.Describe(), "balance is now 32 ETH")
+}
+
+func TestDecodedEventArgs(t *testing.T) {
+	event := DecodedEvent{Args: []EventArg{
+		{Name: "amount", Value: big.NewInt(5)},
+		{Name: "timestamp", Value: uint64(7)},
+		{Name: "nil", Value: (*big.Int)(nil)},
+	}}
+
+	amount, ok := event.ArgBig("amount")
+	assert.True(t, ok)
+	assert.Equal(t, int64(5), amount.Int64())
+	_, ok = event.ArgBig("nil")
+	assert.False(t, ok)
+	_, ok = event.ArgBig("timestamp")
+	assert.False(t, ok)
+
+	timestamp, ok := event.ArgUint64("timestamp")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(7), timestamp)
+	_, ok = event.ArgUint64("amount")
+	assert.False(t, ok)
+
+	_, ok = event.ArgBytes32("missing")
+	assert.False(t, ok)
+	_, ok = event.ArgAddress("amount")
+	assert.False(t, ok)
+}
+
+func TestDecodeLegacyLogs(t *testing.T) {
+	checkpointed := &types.Log{Topics: []common.Hash{
+		crypto.Keccak256Hash([]byte("ValidatorCheckpointed(uint64,uint40)")),
+		common.BigToHash(big.NewInt(100)),
+		common.BigToHash(big.NewInt(7)),
+	}}
+	event, ok := DecodeLog(checkpointed)
+	require.True(t, ok)
+	assert.Equal(t, "EigenPod", event.Contract)
+	assert.Equal(t, "ValidatorCheckpointed", event.Name)
+	index, ok := event.ArgBig("validatorIndex")
+	require.True(t, ok)
+	assert.Equal(t, int64(7), index.Int64())
+	timestamp, ok := event.ArgUint64("checkpointTimestamp")
+	require.True(t, ok)
+	assert.Equal(t, uint64(100), timestamp)
+	assert.Contains(t, event.Describe(), "#7")
+
+	data := append(append(common.BigToHash(big.NewInt(7)).Bytes(), common.BigToHash(big.NewInt(100)).Bytes()...), common.BigToHash(big.NewInt(32_000_000_000)).Bytes()...)
+	event, ok = DecodeLog(&types.Log{
+		Topics: []common.Hash{crypto.Keccak256Hash([]byte("ValidatorBalanceUpdated(uint40,uint64,uint64)"))},
+		Data:   data,
+	})
+	require.True(t, ok)
+	assert.Equal(t, "ValidatorBalanceUpdated", event.Name)
+	balance, ok := event.ArgUint64("newValidatorBalanceGwei")
+	require.True(t, ok)
+	assert.Equal(t, uint64(32_000_000_000), balance)
+
+	_, ok = DecodeLog(&types.Log{Topics: []common.Hash{crypto.Keccak256Hash([]byte("Unknown()"))}})
+	assert.False(t, ok)
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/fatih/color"
+)
+
+type PredeployScheduleOptions struct {
+	// the highest per-request predeploy fee to send at (wei). nil disables the cap.
+	MaxFeePerRequest *big.Int
+
+	// how often to poll for new blocks while waiting
+	PollInterval time.Duration
+
+	// give up if the fee stays above MaxFeePerRequest for this long. 0 waits forever.
+	MaxWait time.Duration
+
+	Verbose bool
+}
+
+func DefaultPredeployScheduleOptions() PredeployScheduleOptions {
+	return PredeployScheduleOptions{
+		PollInterval: 6 * time.Second,
+	}
+}
+
+// PredeployScheduler paces requests to the EIP-7002 or EIP-7251 predeploy.
+//
+// The predeploy fee is derived from the queue's "excess", which is updated once per block by
+// `excess = max(0, excess + requestsInBlock - TARGET)`. Every request above the target in one
+// block raises the fee for all subsequent blocks, so the scheduler sends at most one chunk per
+// block, and holds a chunk back until the excess has drained enough for the fee to be at or
+// under MaxFeePerRequest.
+type PredeployScheduler struct {
+	eth  *ethclient.Client
+	opts PredeployScheduleOptions
+
+	name           string
+	minFee         *big.Int
+	updateFraction *big.Int
+	target         *big.Int
+	getExcess      func(*ethclient.Client) (*big.Int, error)
+
+	// the block the last chunk was included in
+	lastBlock uint64
+}
+
+func NewWithdrawalScheduler(eth *ethclient.Client, opts PredeployScheduleOptions) *PredeployScheduler {
+	return &PredeployScheduler{
+		eth:            eth,
+		opts:           opts,
+		name:           "withdrawal",
+		minFee:         MIN_WITHDRAWAL_REQUEST_FEE,
+		updateFraction: WITHDRAWAL_REQUEST_FEE_UPDATE_FRACTION,
+		target:         TARGET_WITHDRAWAL_REQUESTS_PER_BLOCK,
+		getExcess:      GetExcessWithdrawalRequests,
+	}
+}
+
+func NewConsolidationScheduler(eth *ethclient.Client, opts PredeployScheduleOptions) *PredeployScheduler {
+	return &PredeployScheduler{
+		eth:            eth,
+		opts:           opts,
+		name:           "consolidation",
+		minFee:         MIN_CONSOLIDATION_REQUEST_FEE,
+		updateFraction: CONSOLIDATION_REQUEST_FEE_UPDATE_FRACTION,
+		target:         TARGET_CONSOLIDATION_REQUESTS_PER_BLOCK,
+		getExcess:      GetExcessConsolidationRequests,
+	}
+}
+
+// ChunkSize returns the largest chunk that should go into a single transaction. Each request past
+// the predeploy's per-block target adds to the excess, raising the fee of every later chunk.
+func (s *PredeployScheduler) ChunkSize(batchSize uint64) uint64 {
+	if batchSize == 0 {
+		return s.target.Uint64()
+	}
+	return min(batchSize, s.target.Uint64())
+}
+
+// CapValue returns the msg.value to send `requests` requests with: `overestimate`, but no more than
+// MaxFeePerRequest per request. It returns false if `totalFee`, the fee the requests cost right now,
+// is already above that (the fee rose since Wait), in which case the chunk should wait again.
+func (s *PredeployScheduler) CapValue(overestimate *big.Int, totalFee *big.Int, requests int) (*big.Int, bool) {
+	if s.opts.MaxFeePerRequest == nil {
+		return overestimate, true
+	}
+	limit := new(big.Int).Mul(s.opts.MaxFeePerRequest, big.NewInt(int64(requests)))
+	if totalFee.Cmp(limit) > 0 {
+		return nil, false
+	}
+	return minBig(overestimate, limit), true
+}
+
+// Fee returns the per-request fee for a given excess.
+func (s *PredeployScheduler) Fee(excess *big.Int) *big.Int {
+	return fakeExponential(s.minFee, excess, s.updateFraction)
+}
+
+// BlocksUntilAffordable returns how many blocks without new requests it takes for `excess` to drain
+// to a point where the fee is at or under MaxFeePerRequest.
+func (s *PredeployScheduler) BlocksUntilAffordable(excess *big.Int) uint64 {
+	if s.opts.MaxFeePerRequest == nil {
+		return 0
+	}
+
+	cur := new(big.Int).Set(excess)
+	blocks := uint64(0)
+	for cur.Sign() > 0 && s.Fee(cur).Cmp(s.opts.MaxFeePerRequest) > 0 {
+		cur.Sub(cur, s.target)
+		if cur.Sign() < 0 {
+			cur.SetUint64(0)
+		}
+		blocks++
+	}
+	return blocks
+}
+
+// Wait blocks until a new chunk may be sent: the head is past the block holding the previous chunk,
+// and the current fee is at or under MaxFeePerRequest. It returns the current per-request fee.
+func (s *PredeployScheduler) Wait(ctx context.Context) (*big.Int, error) {
+	start := time.Now()
+	announced := false
+
+	for {
+		head, err := s.eth.BlockNumber(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch block number: %w", err)
+		}
+
+		if head > s.lastBlock {
+			excess, err := s.getExcess(s.eth)
+			if err != nil {
+				return nil, fmt.Errorf("error getting excess requests from predeploy: %w", err)
+			}
+
+			fee := s.Fee(excess)
+			if s.opts.MaxFeePerRequest == nil || fee.Cmp(s.opts.MaxFeePerRequest) <= 0 {
+				return fee, nil
+			}
+
+			if s.opts.MaxWait != 0 && time.Since(start) > s.opts.MaxWait {
+				return nil, fmt.Errorf("%s fee (%s wei/request) stayed above the cap of %s wei/request for %s", s.name, fee, s.opts.MaxFeePerRequest, s.opts.MaxWait)
+			}
+
+			if !announced || s.opts.Verbose {
+				color.Yellow(
+					"%s fee is %s wei/request (queue excess: %s), above the cap of %s wei/request. Waiting for the queue to drain (~%d blocks)...",
+					s.name, fee, excess, s.opts.MaxFeePerRequest, s.BlocksUntilAffordable(excess),
+				)
+				announced = true
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.opts.PollInterval):
+		}
+	}
+}
+
+// Included records the block a chunk was included in, so the next chunk goes into a later block.
+func (s *PredeployScheduler) Included(blockNumber uint64) {
+	s.lastBlock = max(s.lastBlock, blockNumber)
+}
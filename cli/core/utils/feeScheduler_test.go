@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPredeploySchedulerCapValue(t *testing.T) {
+	scheduler := NewWithdrawalScheduler(nil, PredeployScheduleOptions{MaxFeePerRequest: big.NewInt(10)})
+
+	// within the cap, the overestimate is sent as is
+	value, ok := scheduler.CapValue(big.NewInt(15), big.NewInt(10), 2)
+	assert.True(t, ok)
+	assert.Equal(t, big.NewInt(15), value)
+	// otherwise it's capped at MaxFeePerRequest per request
+	value, ok = scheduler.CapValue(big.NewInt(30), big.NewInt(20), 2)
+	assert.True(t, ok)
+	assert.Equal(t, big.NewInt(20), value)
+	// and if the fee itself rose past the cap, the chunk has to wait
+	_, ok = scheduler.CapValue(big.NewInt(36), big.NewInt(24), 2)
+	assert.False(t, ok)
+
+	uncapped := NewWithdrawalScheduler(nil, PredeployScheduleOptions{})
+	value, ok = uncapped.CapValue(big.NewInt(30), big.NewInt(20), 2)
+	assert.True(t, ok)
+	assert.Equal(t, big.NewInt(30), value)
+}
+
+func TestPredeploySchedulerChunkSize(t *testing.T) {
+	withdrawals := NewWithdrawalScheduler(nil, PredeployScheduleOptions{})
+	assert.Equal(t, TARGET_WITHDRAWAL_REQUESTS_PER_BLOCK.Uint64(), withdrawals.ChunkSize(0))
+	assert.Equal(t, TARGET_WITHDRAWAL_REQUESTS_PER_BLOCK.Uint64(), withdrawals.ChunkSize(16))
+	assert.Equal(t, uint64(1), withdrawals.ChunkSize(1))
+
+	consolidations := NewConsolidationScheduler(nil, PredeployScheduleOptions{})
+	assert.Equal(t, TARGET_CONSOLIDATION_REQUESTS_PER_BLOCK.Uint64(), consolidations.ChunkSize(2))
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// Events emitted by contracts before the slashing upgrade (eigenlayer-contracts v1.x), which are
+// not in the current bindings' ABIs. Pods and the DelegationManager keep these in their history, so
+// DecodeLog knows them too.
+//
+// EigenPods identified validators by their index rather than their pubkey hash, so the legacy
+// validator events carry a `validatorIndex` instead of a `pubkeyHash`.
+const legacyEigenPodABI = `[
+	{"type":"event","anonymous":false,"name":"ValidatorRestaked","inputs":[
+		{"name":"validatorIndex","type":"uint40","indexed":false}]},
+	{"type":"event","anonymous":false,"name":"ValidatorBalanceUpdated","inputs":[
+		{"name":"validatorIndex","type":"uint40","indexed":false},
+		{"name":"balanceTimestamp","type":"uint64","indexed":false},
+		{"name":"newValidatorBalanceGwei","type":"uint64","indexed":false}]},
+	{"type":"event","anonymous":false,"name":"ValidatorCheckpointed","inputs":[
+		{"name":"checkpointTimestamp","type":"uint64","indexed":true},
+		{"name":"validatorIndex","type":"uint40","indexed":true}]},
+	{"type":"event","anonymous":false,"name":"ValidatorWithdrawn","inputs":[
+		{"name":"checkpointTimestamp","type":"uint64","indexed":true},
+		{"name":"validatorIndex","type":"uint40","indexed":true}]}
+]`
+
+// The pre-slashing DelegationManager queued withdrawals of `shares` rather than `scaledShares`, and
+// didn't emit the shares to withdraw separately.
+const legacyDelegationManagerABI = `[
+	{"type":"event","anonymous":false,"name":"WithdrawalQueued","inputs":[
+		{"name":"withdrawalRoot","type":"bytes32","indexed":false},
+		{"name":"withdrawal","type":"tuple","indexed":false,"internalType":"struct IDelegationManager.Withdrawal","components":[
+			{"name":"staker","type":"address"},
+			{"name":"delegatedTo","type":"address"},
+			{"name":"withdrawer","type":"address"},
+			{"name":"nonce","type":"uint256"},
+			{"name":"startBlock","type":"uint32"},
+			{"name":"strategies","type":"address[]"},
+			{"name":"shares","type":"uint256[]"}]}]},
+	{"type":"event","anonymous":false,"name":"WithdrawalCompleted","inputs":[
+		{"name":"withdrawalRoot","type":"bytes32","indexed":false}]}
+]`
+
+// LegacyEigenPodABI returns the ABI of the pre-slashing EigenPod events.
+func LegacyEigenPodABI() (*abi.ABI, error) {
+	parsed, err := abi.JSON(strings.NewReader(legacyEigenPodABI))
+	return &parsed, err
+}
+
+// LegacyDelegationManagerABI returns the ABI of the pre-slashing DelegationManager events.
+func LegacyDelegationManagerABI() (*abi.ABI, error) {
+	parsed, err := abi.JSON(strings.NewReader(legacyDelegationManagerABI))
+	return &parsed, err
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Most RPC providers cap the block range (or the number of results) of a single eth_getLogs call.
+const DEFAULT_LOG_FILTER_CHUNK = uint64(10_000)
+
+// FilterLogsChunked runs `query` over [fromBlock, toBlock] in ranges of at most `chunkSize` blocks.
+// When the node rejects a range, it is retried in halves until it is a single block.
+func FilterLogsChunked(ctx context.Context, eth *ethclient.Client, query ethereum.FilterQuery, fromBlock, toBlock, chunkSize uint64) ([]types.Log, error) {
+	if chunkSize == 0 {
+		chunkSize = DEFAULT_LOG_FILTER_CHUNK
+	}
+
+	out := []types.Log{}
+	for start := fromBlock; start <= toBlock; {
+		end := min(start+chunkSize-1, toBlock)
+
+		q := query
+		q.FromBlock = new(big.Int).SetUint64(start)
+		q.ToBlock = new(big.Int).SetUint64(end)
+
+		logs, err := eth.FilterLogs(ctx, q)
+		if err != nil {
+			if end == start {
+				return nil, fmt.Errorf("failed to fetch logs for block %d: %w", start, err)
+			}
+			chunkSize = max(1, (end-start+1)/2)
+			continue
+		}
+
+		out = append(out, logs...)
+		start = end + 1
+	}
+	return out, nil
+}
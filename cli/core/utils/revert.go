@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Explanations for the custom errors users are most likely to hit, keyed by error name.
+var revertExplanations = map[string]string{
+	// EigenPod
+	"CheckpointAlreadyActive":               "a checkpoint is already active. Complete it with `./cli checkpoint` before starting another.",
+	"NoActiveCheckpoint":                    "no checkpoint is active. Start one with `./cli checkpoint --sender <pk>`.",
+	"CannotCheckpointTwiceInSingleBlock":    "a checkpoint was already started in this block. Wait for the next block and try again.",
+	"NoBalanceToCheckpoint":                 "the pod has no new balance to checkpoint. Re-run with `--force` to checkpoint anyway.",
+	"OnlyEigenPodOwner":                     "the sender is not the pod owner. Only the pod owner can call this function.",
+	"OnlyEigenPodOwnerOrProofSubmitter":     "the sender is neither the pod owner nor its proofSubmitter. Use the owner's key, or allow this sender with `./cli assign-submitter`.",
+	"ValidatorNotActiveInPod":               "the validator is not ACTIVE in the pod. Its withdrawal credentials are unverified, or it has already been withdrawn.",
+	"ValidatorIsExitingBeaconChain":         "the validator is exiting the beacon chain, so its withdrawal credentials can no longer be verified.",
+	"ValidatorInactiveOnBeaconChain":        "the validator has not been activated on the beacon chain yet. Wait for activation and try again.",
+	"ValidatorNotSlashedOnBeaconChain":      "the validator is not slashed on the beacon chain, so the pod's balance is not stale.",
+	"CredentialsAlreadyVerified":            "the validator's withdrawal credentials are already verified.",
+	"WithdrawalCredentialsNotForEigenPod":   "the validator's withdrawal credentials do not point to this pod.",
+	"BeaconTimestampTooFarInPast":           "the proof was generated against a beacon state that is too old. Regenerate the proof.",
+	"BeaconTimestampBeforeLatestCheckpoint": "the proof is older than the pod's latest checkpoint. Regenerate the proof.",
+	"TimestampOutOfRange":                   "no beacon block root is available for this timestamp (EIP-4788 only stores roughly a day of roots). Regenerate the proof.",
+	"InvalidEIP4788Response":                "no beacon block root is available for this timestamp. Regenerate the proof.",
+	"InvalidProof":                          "the proof does not match the beacon block root. Make sure your beacon node is synced and regenerate the proof.",
+	"InvalidProofLength":                    "the proof has the wrong length. Make sure the CLI supports the current beacon chain fork.",
+	"InvalidValidatorFieldsLength":          "the validator fields have the wrong length. Make sure the CLI supports the current beacon chain fork.",
+	"InvalidPubKeyLength":                   "a validator pubkey is not 48 bytes long.",
+	"InsufficientFunds":                     "msg.value does not cover the predeploy fee. The fee may have risen since it was estimated; try a larger --fee-overestimate-factor.",
+	"InsufficientWithdrawableBalance":       "the pod does not have enough withdrawable ETH. Checkpoint to claim beacon chain ETH first.",
+	"PredeployFailed":                       "the EIP-7002/EIP-7251 predeploy rejected the request.",
+	"FeeQueryFailed":                        "failed to query the EIP-7002/EIP-7251 predeploy fee.",
+	"CurrentlyPaused":                       "this functionality is currently paused by EigenLayer.",
+	"InputArrayLengthMismatch":              "input arrays have different lengths.",
+	"InputAddressZero":                      "an input address was the zero address.",
+
+	// DelegationManager
+	"WithdrawalDelayNotElapsed": "the withdrawal delay has not passed yet. Check when it becomes completable with `./cli show-withdrawals`.",
+	"WithdrawalNotQueued":       "the withdrawal is not queued. It may have been completed already.",
+	"WithdrawerNotCaller":       "the sender is not the withdrawer. Withdrawals must be completed by the pod owner.",
+	"FullySlashed":              "the staker has been fully slashed, so there are no shares to withdraw.",
+}
+
+// the selector of Solidity's Panic(uint256), which abi.UnpackRevert decodes along with Error(string)
+var panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+
+// RevertError is a revert which was decoded into a named error from one of the CLI's known
+// contracts, or into a Solidity `Error(string)`/`Panic(uint256)`.
+type RevertError struct {
+	Contract    string
+	Name        string
+	Args        []EventArg
+	Explanation string
+	Data        []byte
+
+	// the error this revert was decoded from
+	Cause error
+}
+
+func (e *RevertError) Error() string {
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = fmt.Sprintf("%s=%s", arg.Name, formatEventValue(arg.Value))
+	}
+
+	msg := fmt.Sprintf("reverted with %s.%s(%s)", e.Contract, e.Name, strings.Join(args, ", "))
+	if e.Explanation != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Explanation)
+	}
+	return msg
+}
+
+func (e *RevertError) Unwrap() error {
+	return e.Cause
+}
+
+// DecodeRevertData decodes raw revert data against the EigenPod, EigenPodManager and
+// DelegationManager ABIs.
+func DecodeRevertData(data []byte) (*RevertError, bool) {
+	if len(data) < 4 {
+		return nil, false
+	}
+
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		name := "Error"
+		if bytes.Equal(data[:4], panicSelector) {
+			name = "Panic"
+		}
+		return &RevertError{
+			Contract:    "solidity",
+			Name:        name,
+			Explanation: reason,
+			Data:        data,
+		}, true
+	}
+
+	for _, contract := range knownABIs() {
+		for _, abiErr := range contract.ABI.Errors {
+			if !bytes.Equal(abiErr.ID[:4], data[:4]) {
+				continue
+			}
+
+			args := []EventArg{}
+			if values, err := abiErr.Inputs.Unpack(data[4:]); err == nil {
+				for i, input := range abiErr.Inputs {
+					args = append(args, EventArg{Name: input.Name, Value: values[i]})
+				}
+			}
+
+			return &RevertError{
+				Contract:    contract.Name,
+				Name:        abiErr.Name,
+				Args:        args,
+				Explanation: revertExplanations[abiErr.Name],
+				Data:        data,
+			}, true
+		}
+	}
+
+	return nil, false
+}
+
+// DecodeRevert inspects an error returned by a JSON-RPC call (eth_call, eth_estimateGas, ...) and,
+// if it carries revert data that can be decoded, returns a *RevertError wrapping it. Any other
+// error is returned unchanged.
+func DecodeRevert(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return err
+	}
+
+	var data []byte
+	switch raw := dataErr.ErrorData().(type) {
+	case string:
+		decoded, decodeErr := hexutil.Decode(raw)
+		if decodeErr != nil {
+			return err
+		}
+		data = decoded
+	case []byte:
+		data = raw
+	default:
+		return err
+	}
+
+	revert, ok := DecodeRevertData(data)
+	if !ok {
+		return fmt.Errorf("%w (revert data: %s)", err, common.Bytes2Hex(data))
+	}
+	revert.Cause = err
+	return revert
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// customError returns the revert data of the error `signature` with ABI-encoded `args`.
+func customError(signature string, args []byte) []byte {
+	return append(crypto.Keccak256([]byte(signature))[:4], args...)
+}
+
+// rpcDataError is a JSON-RPC error carrying revert data, as returned by eth_call or eth_estimateGas.
+type rpcDataError struct {
+	data interface{}
+}
+
+func (e rpcDataError) Error() string          { return "execution reverted" }
+func (e rpcDataError) ErrorData() interface{} { return e.data }
+
+func TestDecodeRevertData(t *testing.T) {
+	stringType, err := abi.NewType("string", "", nil)
+	require.NoError(t, err)
+	stringArgs, err := abi.Arguments{{Type: stringType}}.Pack("too long")
+	require.NoError(t, err)
+	errorString := customError("Error(string)", stringArgs)
+	panicCode := customError("Panic(uint256)", common.BigToHash(big.NewInt(1)).Bytes())
+
+	tests := []struct {
+		name string
+		data []byte
+
+		wantOk          bool
+		wantContract    string
+		wantName        string
+		wantArgs        []EventArg
+		wantExplanation string
+	}{
+		{
+			name:            "EigenPod error",
+			data:            customError("CheckpointAlreadyActive()", nil),
+			wantOk:          true,
+			wantContract:    "EigenPod",
+			wantName:        "CheckpointAlreadyActive",
+			wantArgs:        []EventArg{},
+			wantExplanation: revertExplanations["CheckpointAlreadyActive"],
+		},
+		{
+			name:         "EigenPodManager error",
+			data:         customError("EigenPodAlreadyExists()", nil),
+			wantOk:       true,
+			wantContract: "EigenPodManager",
+			wantName:     "EigenPodAlreadyExists",
+			wantArgs:     []EventArg{},
+		},
+		{
+			name:            "DelegationManager error",
+			data:            customError("WithdrawalDelayNotElapsed()", nil),
+			wantOk:          true,
+			wantContract:    "DelegationManager",
+			wantName:        "WithdrawalDelayNotElapsed",
+			wantArgs:        []EventArg{},
+			wantExplanation: revertExplanations["WithdrawalDelayNotElapsed"],
+		},
+		{
+			name:         "error with arguments",
+			data:         customError("StringTooLong(string)", stringArgs),
+			wantOk:       true,
+			wantContract: "EigenPod",
+			wantName:     "StringTooLong",
+			wantArgs:     []EventArg{{Name: "str", Value: "too long"}},
+		},
+		{
+			name:            "Error(string)",
+			data:            errorString,
+			wantOk:          true,
+			wantContract:    "solidity",
+			wantName:        "Error",
+			wantExplanation: "too long",
+		},
+		{
+			name:            "Panic(uint256)",
+			data:            panicCode,
+			wantOk:          true,
+			wantContract:    "solidity",
+			wantName:        "Panic",
+			wantExplanation: "assert(false)",
+		},
+		{
+			name: "unknown selector",
+			data: customError("Unknown()", nil),
+		},
+		{
+			name: "shorter than a selector",
+			data: []byte{0x01, 0x02},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revert, ok := DecodeRevertData(tt.data)
+			require.Equal(t, tt.wantOk, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tt.wantContract, revert.Contract)
+			assert.Equal(t, tt.wantName, revert.Name)
+			assert.Equal(t, tt.wantArgs, revert.Args)
+			assert.Equal(t, tt.wantExplanation, revert.Explanation)
+			assert.Equal(t, tt.data, revert.Data)
+		})
+	}
+}
+
+func TestDecodeRevert(t *testing.T) {
+	checkpointAlreadyActive := customError("CheckpointAlreadyActive()", nil)
+
+	tests := []struct {
+		name string
+		err  error
+
+		// the decoded error's name, or "" if the error is returned as is
+		wantName    string
+		wantMessage string
+	}{
+		{
+			name:        "hex string revert data",
+			err:         rpcDataError{data: hexutil.Encode(checkpointAlreadyActive)},
+			wantName:    "CheckpointAlreadyActive",
+			wantMessage: "reverted with EigenPod.CheckpointAlreadyActive(): a checkpoint is already active",
+		},
+		{
+			name:        "wrapped byte revert data",
+			err:         fmt.Errorf("failed to estimate gas: %w", rpcDataError{data: checkpointAlreadyActive}),
+			wantName:    "CheckpointAlreadyActive",
+			wantMessage: "reverted with EigenPod.CheckpointAlreadyActive()",
+		},
+		{
+			name:        "undecodable revert data",
+			err:         rpcDataError{data: "0xdeadbeef"},
+			wantMessage: "execution reverted (revert data: deadbeef)",
+		},
+		{
+			name:        "invalid hex",
+			err:         rpcDataError{data: "not hex"},
+			wantMessage: "execution reverted",
+		},
+		{
+			name:        "no revert data",
+			err:         errors.New("connection refused"),
+			wantMessage: "connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DecodeRevert(tt.err)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantMessage)
+			assert.ErrorIs(t, err, tt.err)
+
+			var revert *RevertError
+			if tt.wantName == "" {
+				assert.False(t, errors.As(err, &revert))
+				return
+			}
+			require.True(t, errors.As(err, &revert))
+			assert.Equal(t, tt.wantName, revert.Name)
+		})
+	}
+
+	assert.NoError(t, DecodeRevert(nil))
+}
@@ -0,0 +1,272 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/golang/snappy"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+)
+
+const (
+	// the cache is pruned down to this many bytes after each state it stores
+	STATE_CACHE_MAX_SIZE = int64(4 << 30)
+	// states that weren't used for this long are pruned
+	STATE_CACHE_MAX_AGE = 7 * 24 * time.Hour
+)
+
+const stateCacheFileSuffix = ".ssz.sz"
+
+// the directory states are cached in (see UseStateCache); empty disables the cache
+var sharedStateCacheDir string
+
+// UseStateCache makes GetBeaconClient cache the beacon states it fetches in `dir`. An empty `dir`
+// disables the cache.
+func UseStateCache(dir string) {
+	sharedStateCacheDir = dir
+}
+
+// DefaultStateCacheDir returns the directory states are cached in by default: eigenpod-proofs/states
+// in the user's cache directory ($XDG_CACHE_HOME, or ~/.cache, on Linux). It is empty if there is no
+// such directory.
+func DefaultStateCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "eigenpod-proofs", "states")
+}
+
+type TStateCacheKey string
+
+const STATE_CACHE_KEY TStateCacheKey = "com.eigen.stateCache"
+
+// ContextWithoutStateCache makes beacon clients fetch states without caching them, for states that
+// won't be needed again (e.g. each round of a long-running process).
+func ContextWithoutStateCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, STATE_CACHE_KEY, false)
+}
+
+func isStateCacheEnabled(ctx context.Context) bool {
+	enabled, ok := ctx.Value(STATE_CACHE_KEY).(bool)
+	return !ok || enabled
+}
+
+// stateCacheClient is a BeaconClient that keeps the beacon states fetched by another one on disk, as
+// snappy-compressed SSZ named after their block's slot and root, so that proving against the same
+// state again (e.g. resuming a checkpoint) doesn't download it again.
+//
+// Only states requested by slot or root are cached, as "head" or "finalized" states are rarely
+// requested again. They must also be identified by a block header: each entry is checked against its
+// header's state root when it's read, and other states (e.g. at empty slots) are fetched every time.
+type stateCacheClient struct {
+	BeaconClient
+	dir     string
+	verbose bool
+
+	maxSize int64
+	maxAge  time.Duration
+}
+
+// NewStateCacheClient caches the states `client` fetches in `dir`, which is created if needed.
+func NewStateCacheClient(client BeaconClient, dir string, verbose bool) (BeaconClient, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create state cache %s: %w", dir, err)
+	}
+	return &stateCacheClient{
+		BeaconClient: client,
+		dir:          dir,
+		verbose:      verbose,
+		maxSize:      STATE_CACHE_MAX_SIZE,
+		maxAge:       STATE_CACHE_MAX_AGE,
+	}, nil
+}
+
+// isPinnedStateId returns whether `stateId` is a slot or a root, which (unlike "head" or "finalized")
+// keep naming the same state, so that it's worth caching.
+func isPinnedStateId(stateId string) bool {
+	if strings.HasPrefix(stateId, "0x") {
+		return true
+	}
+	_, err := strconv.ParseUint(stateId, 10, 64)
+	return err == nil
+}
+
+func (c *stateCacheClient) GetBeaconState(ctx context.Context, stateId string) (*spec.VersionedBeaconState, error) {
+	if !isPinnedStateId(stateId) || !isStateCacheEnabled(ctx) {
+		return c.BeaconClient.GetBeaconState(ctx, stateId)
+	}
+
+	header, err := c.GetBeaconHeader(ctx, stateId)
+	if err != nil || header == nil || header.Header == nil {
+		// e.g. an empty slot, or a state root
+		return c.BeaconClient.GetBeaconState(ctx, stateId)
+	}
+
+	path := filepath.Join(c.dir, fmt.Sprintf("%d-%s%s", header.Header.Message.Slot, header.Root, stateCacheFileSuffix))
+	if state := c.read(path, header); state != nil {
+		return state, nil
+	}
+
+	state, err := c.BeaconClient.GetBeaconState(ctx, stateId)
+	if err != nil {
+		return nil, err
+	}
+
+	// the state may not be the header's, e.g. if the block was reorged out in between
+	stateRoot, err := hashBeaconState(state)
+	if err != nil || stateRoot != header.Header.Message.StateRoot {
+		return state, nil
+	}
+	if err := c.write(path, state); err != nil {
+		log.Warn().Msgf("failed to cache beacon state at slot %d: %s", header.Header.Message.Slot, err)
+		return state, nil
+	}
+	if err := c.prune(); err != nil {
+		log.Warn().Msgf("failed to prune state cache %s: %s", c.dir, err)
+	}
+	return state, nil
+}
+
+// read returns the state cached at `path`, or nil if there is none. Entries that can't be decoded,
+// or aren't the state of `header`, are removed.
+func (c *stateCacheClient) read(path string, header *v1.BeaconBlockHeader) *spec.VersionedBeaconState {
+	state, err := readCachedState(path, header.Header.Message.StateRoot)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		log.Warn().Msgf("discarding cached beacon state %s: %s", path, err)
+		_ = os.Remove(path)
+		return nil
+	}
+
+	if c.verbose {
+		log.Info().Msgf("read beacon state %d from %s", header.Header.Message.Slot, path)
+	}
+	// pruning goes by when states were last used
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return state
+}
+
+func readCachedState(path string, stateRoot phase0.Root) (*spec.VersionedBeaconState, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(snappy.NewReader(file))
+	if err != nil {
+		return nil, err
+	}
+	state, err := beacon.UnmarshalSSZVersionedBeaconState(data)
+	if err != nil {
+		return nil, err
+	}
+	root, err := hashBeaconState(state)
+	if err != nil {
+		return nil, err
+	}
+	if root != stateRoot {
+		return nil, fmt.Errorf("state root %s doesn't match the header's %s", root, stateRoot)
+	}
+	return state, nil
+}
+
+// write stores `state` at `path`, through a temporary file so that other processes never read a
+// partial entry.
+func (c *stateCacheClient) write(path string, state *spec.VersionedBeaconState) error {
+	data, err := beacon.MarshalSSZVersionedBeaconState(*state)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	writer := snappy.NewBufferedWriter(file)
+	if _, err := writer.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return err
+	}
+	if c.verbose {
+		log.Info().Msgf("cached beacon state at %s", path)
+	}
+	return nil
+}
+
+// prune removes the entries that weren't used for maxAge, then the least recently used ones until
+// the cache is no larger than maxSize.
+func (c *stateCacheClient) prune() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	type cached struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []cached
+	var total int64
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), stateCacheFileSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, cached{filepath.Join(c.dir, entry.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+
+	// most recently used first
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	for len(files) > 0 {
+		oldest := files[len(files)-1]
+		if total <= c.maxSize && time.Since(oldest.modTime) <= c.maxAge {
+			break
+		}
+		if err := os.Remove(oldest.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if c.verbose {
+			log.Info().Msgf("pruned cached beacon state %s", oldest.path)
+		}
+		total -= oldest.size
+		files = files[:len(files)-1]
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statesBeaconClient serves a state, and the header of a block at each of `slots`, counting the
+// states it serves.
+type statesBeaconClient struct {
+	BeaconClient
+	slots   map[string]uint64
+	fetches int
+}
+
+func (c *statesBeaconClient) GetBeaconHeader(ctx context.Context, blockId string) (*v1.BeaconBlockHeader, error) {
+	slot, ok := c.slots[blockId]
+	if !ok {
+		return nil, errors.New("no such block")
+	}
+	stateRoot, err := newTestState(slot).HashTreeRoot()
+	if err != nil {
+		return nil, err
+	}
+	return &v1.BeaconBlockHeader{
+		Root: phase0.Root{byte(slot)},
+		Header: &phase0.SignedBeaconBlockHeader{
+			Message: &phase0.BeaconBlockHeader{Slot: phase0.Slot(slot), StateRoot: stateRoot},
+		},
+	}, nil
+}
+
+func (c *statesBeaconClient) GetBeaconState(ctx context.Context, stateId string) (*spec.VersionedBeaconState, error) {
+	c.fetches++
+	slot, ok := c.slots[stateId]
+	if !ok {
+		slot = 1
+	}
+	return &spec.VersionedBeaconState{Version: spec.DataVersionDeneb, Deneb: newTestState(slot)}, nil
+}
+
+func TestStateCacheClient(t *testing.T) {
+	dir := t.TempDir()
+	inner := &statesBeaconClient{slots: map[string]uint64{"10": 10, phase0.Root{10}.String(): 10, "head": 10, "11": 11}}
+	client, err := NewStateCacheClient(inner, dir, false)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	getSlotIn := func(ctx context.Context, stateId string) uint64 {
+		state, err := client.GetBeaconState(ctx, stateId)
+		require.NoError(t, err)
+		slot, err := state.Slot()
+		require.NoError(t, err)
+		return uint64(slot)
+	}
+	getSlot := func(stateId string) uint64 {
+		return getSlotIn(ctx, stateId)
+	}
+
+	assert.Equal(t, uint64(10), getSlot("10"))
+	assert.Equal(t, 1, inner.fetches)
+	path := filepath.Join(dir, "10-"+phase0.Root{10}.String()+stateCacheFileSuffix)
+	require.FileExists(t, path)
+
+	// the same block by root
+	assert.Equal(t, uint64(10), getSlot(phase0.Root{10}.String()))
+	assert.Equal(t, 1, inner.fetches)
+
+	// moving ids aren't cached
+	assert.Equal(t, uint64(10), getSlot("head"))
+	assert.Equal(t, 2, inner.fetches)
+
+	assert.Equal(t, uint64(11), getSlotIn(ContextWithoutStateCache(ctx), "11"))
+	assert.Equal(t, 3, inner.fetches)
+	assert.NoFileExists(t, filepath.Join(dir, "11-"+phase0.Root{11}.String()+stateCacheFileSuffix))
+
+	// states without a header aren't cached
+	assert.Equal(t, uint64(1), getSlot("12"))
+	assert.Equal(t, uint64(1), getSlot("12"))
+	assert.Equal(t, 5, inner.fetches)
+
+	// entries that don't match their header are replaced
+	other, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(11), getSlot("11"))
+	path11 := filepath.Join(dir, "11-"+phase0.Root{11}.String()+stateCacheFileSuffix)
+	require.NoError(t, os.WriteFile(path11, other, 0o644))
+	assert.Equal(t, uint64(11), getSlot("11"))
+	assert.Equal(t, 7, inner.fetches)
+	assert.Equal(t, uint64(11), getSlot("11"))
+	assert.Equal(t, 7, inner.fetches)
+
+	// pruning
+	cache := client.(*stateCacheClient)
+	old := time.Now().Add(-2 * STATE_CACHE_MAX_AGE)
+	require.NoError(t, os.Chtimes(path, old, old))
+	require.NoError(t, cache.prune())
+	assert.NoFileExists(t, path)
+	assert.FileExists(t, path11)
+
+	cache.maxSize = 0
+	require.NoError(t, cache.prune())
+	assert.NoFileExists(t, path11)
+}
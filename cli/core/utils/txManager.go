@@ -0,0 +1,549 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/fatih/color"
+)
+
+type TxManagerOptions struct {
+	// maximum number of unconfirmed transactions before `Send` blocks.
+	MaxInFlight int
+
+	// how long a transaction may sit unconfirmed before it is replaced with a higher fee.
+	StuckTimeout time.Duration
+
+	// how often receipts are polled for.
+	PollInterval time.Duration
+
+	// percentage by which both the tip and the fee cap are raised on replacement. Most
+	// clients refuse replacements that bump by less than 10%.
+	FeeBumpPercent int64
+
+	// maximum number of replacements per nonce. After this, the manager keeps waiting and
+	// suggests `cancel-nonce`.
+	MaxFeeBumps int
+
+	// print gas used, ETH spent and decoded events for every mined transaction.
+	PrintSummaries bool
+
+	Verbose bool
+}
+
+func DefaultTxManagerOptions() TxManagerOptions {
+	return TxManagerOptions{
+		MaxInFlight:    4,
+		StuckTimeout:   3 * time.Minute,
+		PollInterval:   4 * time.Second,
+		FeeBumpPercent: 20,
+		MaxFeeBumps:    5,
+		PrintSummaries: true,
+	}
+}
+
+type managedTransaction struct {
+	// position in the order transactions were handed to the manager.
+	index int
+
+	// latest signed version of the transaction.
+	txn *types.Transaction
+
+	// every version that was broadcast for this nonce, any of which may end up mined.
+	hashes []common.Hash
+
+	lastBroadcast time.Time
+	bumps         int
+}
+
+// TxBackend is the part of an ethereum client a TxManager uses. *ethclient.Client implements it.
+type TxBackend interface {
+	ethereum.ChainReader
+	ethereum.ChainStateReader
+	ethereum.ContractCaller
+	ethereum.GasEstimator
+	ethereum.GasPricer1559
+	ethereum.PendingStateReader
+	ethereum.TransactionReader
+	ethereum.TransactionSender
+}
+
+// TxManager submits transactions from a single account. Nonces are assigned locally so that
+// several transactions can be in flight at once, and transactions which don't confirm within
+// `StuckTimeout` are replaced (same nonce, higher EIP-1559 fees).
+//
+// When the owner is a dry run, transactions are built (and gas-estimated, if a sender was supplied)
+// but never broadcast.
+type TxManager struct {
+	eth   TxBackend
+	owner *Owner
+	opts  TxManagerOptions
+
+	nonce       uint64
+	nonceLoaded bool
+
+	sent     int
+	inFlight []*managedTransaction
+	txns     []*types.Transaction
+	receipts []*types.Receipt
+}
+
+func NewTxManager(eth TxBackend, owner *Owner, opts TxManagerOptions) *TxManager {
+	if opts.MaxInFlight <= 0 {
+		opts.MaxInFlight = 1
+	}
+	if opts.FeeBumpPercent < 10 {
+		opts.FeeBumpPercent = 10
+	}
+	return &TxManager{
+		eth:   eth,
+		owner: owner,
+		opts:  opts,
+	}
+}
+
+// PrepareTxManager parses `sender` (see PrepareAccount) and returns a manager which submits from it
+// using the default options.
+func PrepareTxManager(eth *ethclient.Client, sender string, chainId *big.Int, noSend bool, verbose bool) (*TxManager, error) {
+	owner, err := PrepareAccount(&sender, chainId, noSend)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := DefaultTxManagerOptions()
+	opts.Verbose = verbose
+	return NewTxManager(eth, owner, opts), nil
+}
+
+func (m *TxManager) Owner() *Owner {
+	return m.owner
+}
+
+func (m *TxManager) IsDryRun() bool {
+	return m.owner.IsDryRun
+}
+
+// Send builds a transaction with `build` and broadcasts it using the next local nonce. If
+// `MaxInFlight` transactions are already unconfirmed, Send blocks until one of them is mined.
+//
+// `build` is typically a thin wrapper around a contract binding, e.g.
+//
+//	txMgr.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+//		return eigenPod.StartCheckpoint(opts, true)
+//	})
+func (m *TxManager) Send(ctx context.Context, build func(opts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	opts := *m.owner.TransactionOptions
+	opts.Context = ctx
+	if m.owner.IsDryRun {
+		// when a sender was supplied, the binding estimates gas, which simulates the call.
+		txn, err := build(&opts)
+		if err != nil {
+			return nil, DecodeRevert(err)
+		}
+		return txn, nil
+	}
+
+	for len(m.inFlight) >= m.opts.MaxInFlight {
+		if err := m.poll(ctx); err != nil {
+			return nil, err
+		}
+		if len(m.inFlight) >= m.opts.MaxInFlight {
+			if err := m.sleep(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !m.nonceLoaded {
+		nonce, err := m.eth.PendingNonceAt(ctx, m.owner.FromAddress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load nonce for %s: %w", m.owner.FromAddress, err)
+		}
+		m.nonce = nonce
+		m.nonceLoaded = true
+	}
+
+	opts.Nonce = new(big.Int).SetUint64(m.nonce)
+	opts.NoSend = true
+
+	txn, err := build(&opts)
+	if err != nil {
+		return nil, DecodeRevert(err)
+	}
+
+	if err := m.preflight(ctx, txn); err != nil {
+		return nil, err
+	}
+
+	if err := m.eth.SendTransaction(ctx, txn); err != nil {
+		return nil, fmt.Errorf("failed to broadcast transaction (nonce %d): %w", m.nonce, err)
+	}
+	m.track(txn)
+	m.nonce++
+
+	if m.opts.Verbose {
+		color.Green("broadcast txn %s (nonce %d, %d in flight)", txn.Hash().Hex(), txn.Nonce(), len(m.inFlight))
+	}
+	return txn, nil
+}
+
+// Estimate builds a transaction with `build` without sending it, and returns the gas it would use
+// and the fee cap it would be sent with. Fees are left out of the gas estimate, so that it works
+// from an account without funds too (e.g. the throwaway key of a dry run without a sender).
+func (m *TxManager) Estimate(ctx context.Context, build func(opts *bind.TransactOpts) (*types.Transaction, error)) (uint64, *big.Int, error) {
+	tip, feeCap, err := m.suggestFees(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// everything is set, so that the binding only packs the call
+	opts := *m.owner.TransactionOptions
+	opts.Context = ctx
+	opts.NoSend = true
+	opts.Nonce = big.NewInt(0)
+	opts.GasLimit = 1
+	opts.GasPrice = nil
+	opts.GasTipCap = tip
+	opts.GasFeeCap = feeCap
+
+	txn, err := build(&opts)
+	if err != nil {
+		return 0, nil, DecodeRevert(err)
+	}
+	gas, err := m.eth.EstimateGas(ctx, ethereum.CallMsg{
+		From:  m.owner.FromAddress,
+		To:    txn.To(),
+		Value: txn.Value(),
+		Data:  txn.Data(),
+	})
+	if err != nil {
+		return 0, nil, DecodeRevert(err)
+	}
+	return gas, feeCap, nil
+}
+
+// Cancel replaces whatever is pending at `nonce` with an empty self-transfer. If the manager sent
+// the pending transaction, the replacement outbids it by `FeeBumpPercent`, and takes its place
+// among the transactions WaitAll waits for. Otherwise it starts at the network's suggested fees
+// raised by `FeeBumpPercent`, and is bumped until the node accepts it as a replacement. Either way
+// it is bumped further like any other transaction if it gets stuck.
+func (m *TxManager) Cancel(ctx context.Context, nonce uint64) (*types.Transaction, error) {
+	if m.owner.IsDryRun {
+		return nil, errors.New("cannot cancel a nonce without a --sender")
+	}
+
+	tip, feeCap, err := m.suggestFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mt := m.inFlightAt(nonce)
+	if mt != nil {
+		tip = maxBig(bump(mt.txn.GasTipCap(), m.opts.FeeBumpPercent), tip)
+		feeCap = maxBig(bump(mt.txn.GasFeeCap(), m.opts.FeeBumpPercent), feeCap)
+	} else {
+		tip = bump(tip, m.opts.FeeBumpPercent)
+		feeCap = bump(feeCap, m.opts.FeeBumpPercent)
+	}
+
+	to := m.owner.FromAddress
+	txn, err := m.broadcastReplacement(ctx, withFees(types.NewTx(&types.DynamicFeeTx{
+		Nonce: nonce,
+		To:    &to,
+		Value: big.NewInt(0),
+		Gas:   21000,
+	}), tip, feeCap))
+	if err != nil {
+		return nil, err
+	}
+
+	if mt != nil {
+		m.replaced(mt, txn)
+	} else {
+		m.track(txn)
+	}
+	return txn, nil
+}
+
+// WaitAll blocks until every transaction sent so far is mined, and returns their receipts in
+// the order the transactions were sent. Returns an error if any of them reverted.
+// In a dry run this returns immediately.
+func (m *TxManager) WaitAll(ctx context.Context) ([]*types.Receipt, error) {
+	for len(m.inFlight) > 0 {
+		if err := m.poll(ctx); err != nil {
+			return m.receipts, err
+		}
+		if len(m.inFlight) > 0 {
+			if err := m.sleep(ctx); err != nil {
+				return m.receipts, err
+			}
+		}
+	}
+
+	receipts := m.receipts
+	txns := m.txns
+	m.receipts = []*types.Receipt{}
+	m.txns = []*types.Transaction{}
+	m.sent = 0
+
+	reverted := []string{}
+	for i, receipt := range receipts {
+		if m.opts.PrintSummaries {
+			PrintReceiptSummary(SummarizeReceipt(txns[i], receipt))
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			reverted = append(reverted, receipt.TxHash.Hex())
+		}
+	}
+
+	if len(reverted) > 0 {
+		return receipts, fmt.Errorf("%d transaction(s) reverted: %s", len(reverted), strings.Join(reverted, ", "))
+	}
+	return receipts, nil
+}
+
+// preflight simulates `txn` against the latest block, so that a transaction which would revert
+// is never broadcast.
+func (m *TxManager) preflight(ctx context.Context, txn *types.Transaction) error {
+	_, err := m.eth.CallContract(ctx, ethereum.CallMsg{
+		From:  m.owner.FromAddress,
+		To:    txn.To(),
+		Gas:   txn.Gas(),
+		Value: txn.Value(),
+		Data:  txn.Data(),
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("transaction would revert: %w", DecodeRevert(err))
+	}
+	return nil
+}
+
+func (m *TxManager) track(txn *types.Transaction) {
+	m.inFlight = append(m.inFlight, &managedTransaction{
+		index:         m.sent,
+		txn:           txn,
+		hashes:        []common.Hash{txn.Hash()},
+		lastBroadcast: time.Now(),
+	})
+	m.txns = append(m.txns, txn)
+	m.receipts = append(m.receipts, nil)
+	m.sent++
+}
+
+func (m *TxManager) sleep(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(m.opts.PollInterval):
+		return nil
+	}
+}
+
+// poll checks every in-flight transaction once, recording receipts for those that were mined and
+// replacing those that have been pending for longer than `StuckTimeout`.
+func (m *TxManager) poll(ctx context.Context) error {
+	confirmedNonce, err := m.eth.NonceAt(ctx, m.owner.FromAddress, nil)
+	if err != nil {
+		return fmt.Errorf("failed to load nonce for %s: %w", m.owner.FromAddress, err)
+	}
+
+	stillPending := []*managedTransaction{}
+	for _, mt := range m.inFlight {
+		receipt, err := m.findReceipt(ctx, mt)
+		if err != nil {
+			return err
+		}
+		if receipt != nil {
+			m.receipts[mt.index] = receipt
+			continue
+		}
+
+		if confirmedNonce > mt.txn.Nonce() {
+			// the nonce was used, but not by any version of this transaction.
+			return fmt.Errorf("nonce %d was consumed by a transaction other than %s", mt.txn.Nonce(), mt.hashes[0].Hex())
+		}
+
+		if time.Since(mt.lastBroadcast) > m.opts.StuckTimeout {
+			if mt.bumps >= m.opts.MaxFeeBumps {
+				color.Yellow("txn %s (nonce %d) is still pending after %d fee bumps. Keep waiting, or replace it with `cancel-nonce --nonce %d`.", mt.txn.Hash().Hex(), mt.txn.Nonce(), mt.bumps, mt.txn.Nonce())
+				mt.lastBroadcast = time.Now()
+			} else if err := m.replace(ctx, mt); err != nil {
+				return err
+			}
+		}
+		stillPending = append(stillPending, mt)
+	}
+
+	m.inFlight = stillPending
+	return nil
+}
+
+func (m *TxManager) findReceipt(ctx context.Context, mt *managedTransaction) (*types.Receipt, error) {
+	for _, hash := range mt.hashes {
+		receipt, err := m.eth.TransactionReceipt(ctx, hash)
+		if err == nil {
+			return receipt, nil
+		}
+		// nodes that are still indexing transactions (e.g. just after starting) can't tell yet
+		if !errors.Is(err, ethereum.NotFound) && !strings.Contains(err.Error(), "transaction indexing is in progress") {
+			return nil, fmt.Errorf("failed to fetch receipt for %s: %w", hash.Hex(), err)
+		}
+	}
+	return nil, nil
+}
+
+// replace re-signs `mt` with the same nonce and fees raised by at least `FeeBumpPercent`
+// (or the network's current suggestion, whichever is higher).
+func (m *TxManager) replace(ctx context.Context, mt *managedTransaction) error {
+	tip, feeCap, err := m.suggestFees(ctx)
+	if err != nil {
+		return err
+	}
+
+	replacement, err := m.broadcastReplacement(ctx, withFees(
+		mt.txn,
+		maxBig(bump(mt.txn.GasTipCap(), m.opts.FeeBumpPercent), tip),
+		maxBig(bump(mt.txn.GasFeeCap(), m.opts.FeeBumpPercent), feeCap),
+	))
+	if err != nil {
+		return err
+	}
+
+	color.Yellow("txn %s (nonce %d) was pending for over %s, replaced with %s", mt.txn.Hash().Hex(), mt.txn.Nonce(), m.opts.StuckTimeout, replacement.Hash().Hex())
+	m.replaced(mt, replacement)
+	return nil
+}
+
+// replaced records that `replacement` was broadcast in place of `mt`'s latest version.
+func (m *TxManager) replaced(mt *managedTransaction, replacement *types.Transaction) {
+	mt.txn = replacement
+	m.txns[mt.index] = replacement
+	mt.hashes = append(mt.hashes, replacement.Hash())
+	mt.lastBroadcast = time.Now()
+	mt.bumps++
+}
+
+func (m *TxManager) inFlightAt(nonce uint64) *managedTransaction {
+	for _, mt := range m.inFlight {
+		if mt.txn.Nonce() == nonce {
+			return mt
+		}
+	}
+	return nil
+}
+
+// broadcastReplacement signs and broadcasts `unsigned`, which replaces a pending transaction. While
+// the node refuses it as underpriced (e.g. because the pending transaction pays more than the
+// manager knew of), its fees are raised by `FeeBumpPercent` and it is retried, up to `MaxFeeBumps`
+// times.
+func (m *TxManager) broadcastReplacement(ctx context.Context, unsigned *types.Transaction) (*types.Transaction, error) {
+	for attempt := 0; ; attempt++ {
+		replacement, err := m.owner.TransactionOptions.Signer(m.owner.FromAddress, unsigned)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign replacement for nonce %d: %w", unsigned.Nonce(), err)
+		}
+
+		err = m.eth.SendTransaction(ctx, replacement)
+		if err == nil {
+			return replacement, nil
+		}
+		if !isUnderpriced(err) || attempt >= m.opts.MaxFeeBumps {
+			return nil, fmt.Errorf("failed to broadcast replacement for nonce %d: %w", unsigned.Nonce(), err)
+		}
+		if m.opts.Verbose {
+			color.Yellow("replacement for nonce %d was underpriced, raising its fees by %d%%", unsigned.Nonce(), m.opts.FeeBumpPercent)
+		}
+		unsigned = withFees(unsigned, bump(unsigned.GasTipCap(), m.opts.FeeBumpPercent), bump(unsigned.GasFeeCap(), m.opts.FeeBumpPercent))
+	}
+}
+
+// isUnderpriced returns whether the node refused a transaction for paying too little, e.g. geth's
+// "replacement transaction underpriced".
+func isUnderpriced(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "underpriced")
+}
+
+// withFees returns an unsigned copy of `txn` paying `tip` and `feeCap`. Legacy transactions pay
+// `feeCap` as their gas price.
+func withFees(txn *types.Transaction, tip, feeCap *big.Int) *types.Transaction {
+	if feeCap.Cmp(tip) < 0 {
+		feeCap = tip
+	}
+	switch txn.Type() {
+	case types.LegacyTxType:
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    txn.Nonce(),
+			To:       txn.To(),
+			Value:    txn.Value(),
+			Gas:      txn.Gas(),
+			GasPrice: feeCap,
+			Data:     txn.Data(),
+		})
+	default:
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    txn.ChainId(),
+			Nonce:      txn.Nonce(),
+			To:         txn.To(),
+			Value:      txn.Value(),
+			Gas:        txn.Gas(),
+			GasTipCap:  tip,
+			GasFeeCap:  feeCap,
+			Data:       txn.Data(),
+			AccessList: txn.AccessList(),
+		})
+	}
+}
+
+// suggestFees returns the network's suggested tip, and a fee cap of (2 * baseFee + tip), mirroring
+// what the contract bindings use by default.
+func (m *TxManager) suggestFees(ctx context.Context) (*big.Int, *big.Int, error) {
+	tip, err := m.eth.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to suggest gas tip: %w", err)
+	}
+
+	head, err := m.eth.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load latest header: %w", err)
+	}
+	if head.BaseFee == nil {
+		return tip, tip, nil
+	}
+
+	feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	return tip, feeCap, nil
+}
+
+// bump returns x * (100 + percent) / 100, rounded up and always strictly greater than x.
+func bump(x *big.Int, percent int64) *big.Int {
+	out := new(big.Int).Mul(x, big.NewInt(100+percent))
+	out.Add(out, big.NewInt(99))
+	out.Div(out, big.NewInt(100))
+	if out.Cmp(x) <= 0 {
+		out.Add(x, big.NewInt(1))
+	}
+	return out
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+func minBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
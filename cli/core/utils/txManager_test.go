@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRecipient = common.HexToAddress("0x000000000000000000000000000000000000beef")
+
+// newTestTxManager returns a manager sending from a funded account of a simulated chain.
+func newTestTxManager(t *testing.T, configure func(opts *TxManagerOptions)) (*simulated.Backend, *TxManager) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+	})
+	t.Cleanup(func() { backend.Close() })
+
+	chainId, err := backend.Client().ChainID(context.Background())
+	require.NoError(t, err)
+	sender := hex.EncodeToString(crypto.FromECDSA(key))
+	owner, err := PrepareAccount(&sender, chainId, false)
+	require.NoError(t, err)
+
+	opts := DefaultTxManagerOptions()
+	opts.PrintSummaries = false
+	opts.PollInterval = 10 * time.Millisecond
+	if configure != nil {
+		configure(&opts)
+	}
+	return backend, NewTxManager(backend.Client(), owner, opts)
+}
+
+// transfer builds a transfer of `wei` to testRecipient, at the manager's nonce.
+func transfer(wei int64) func(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return opts.Signer(opts.From, types.NewTx(&types.DynamicFeeTx{
+			Nonce:     opts.Nonce.Uint64(),
+			To:        &testRecipient,
+			Value:     big.NewInt(wei),
+			Gas:       21000,
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: big.NewInt(10 * params.GWei),
+		}))
+	}
+}
+
+func TestTxManagerNonces(t *testing.T) {
+	backend, m := newTestTxManager(t, func(opts *TxManagerOptions) {
+		opts.MaxInFlight = 2
+	})
+	ctx := context.Background()
+
+	first, err := m.Send(ctx, transfer(1))
+	require.NoError(t, err)
+	second, err := m.Send(ctx, transfer(2))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), first.Nonce())
+	assert.Equal(t, uint64(1), second.Nonce())
+
+	// with MaxInFlight transactions pending, Send waits for one to be mined
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		backend.Commit()
+	}()
+	third, err := m.Send(ctx, transfer(3))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), third.Nonce())
+	backend.Commit()
+
+	receipts, err := m.WaitAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, receipts, 3)
+	for i, txn := range []*types.Transaction{first, second, third} {
+		assert.Equal(t, txn.Hash(), receipts[i].TxHash)
+		assert.Equal(t, types.ReceiptStatusSuccessful, receipts[i].Status)
+	}
+
+	balance, err := backend.Client().BalanceAt(ctx, testRecipient, nil)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(6), balance)
+
+	// nonces keep counting locally
+	fourth, err := m.Send(ctx, transfer(4))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), fourth.Nonce())
+}
+
+func TestTxManagerReplacesStuckTransactions(t *testing.T) {
+	backend, m := newTestTxManager(t, func(opts *TxManagerOptions) {
+		opts.StuckTimeout = 0
+		opts.MaxFeeBumps = 1
+	})
+	ctx := context.Background()
+
+	original, err := m.Send(ctx, transfer(1))
+	require.NoError(t, err)
+
+	require.NoError(t, m.poll(ctx))
+	require.Len(t, m.inFlight, 1)
+	replacement := m.inFlight[0].txn
+	assert.NotEqual(t, original.Hash(), replacement.Hash())
+	assert.Equal(t, original.Nonce(), replacement.Nonce())
+	assert.Equal(t, original.Data(), replacement.Data())
+	assert.GreaterOrEqual(t, replacement.GasTipCap().Cmp(bump(original.GasTipCap(), m.opts.FeeBumpPercent)), 0)
+	assert.GreaterOrEqual(t, replacement.GasFeeCap().Cmp(bump(original.GasFeeCap(), m.opts.FeeBumpPercent)), 0)
+
+	// no more than MaxFeeBumps replacements
+	require.NoError(t, m.poll(ctx))
+	assert.Equal(t, replacement.Hash(), m.inFlight[0].txn.Hash())
+	assert.Equal(t, []common.Hash{original.Hash(), replacement.Hash()}, m.inFlight[0].hashes)
+
+	backend.Commit()
+	receipts, err := m.WaitAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, receipts, 1)
+	assert.Equal(t, replacement.Hash(), receipts[0].TxHash)
+}
+
+func TestTxManagerCancel(t *testing.T) {
+	t.Run("outbids a tracked transaction", func(t *testing.T) {
+		backend, m := newTestTxManager(t, nil)
+		ctx := context.Background()
+
+		original, err := m.Send(ctx, transfer(1))
+		require.NoError(t, err)
+		cancel, err := m.Cancel(ctx, original.Nonce())
+		require.NoError(t, err)
+
+		assert.Equal(t, m.owner.FromAddress, *cancel.To())
+		assert.Equal(t, 0, cancel.Value().Sign())
+		assert.GreaterOrEqual(t, cancel.GasTipCap().Cmp(bump(original.GasTipCap(), m.opts.FeeBumpPercent)), 0)
+		assert.GreaterOrEqual(t, cancel.GasFeeCap().Cmp(bump(original.GasFeeCap(), m.opts.FeeBumpPercent)), 0)
+		// the cancellation takes the original's place
+		require.Len(t, m.inFlight, 1)
+		assert.Equal(t, []common.Hash{original.Hash(), cancel.Hash()}, m.inFlight[0].hashes)
+
+		backend.Commit()
+		receipts, err := m.WaitAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, receipts, 1)
+		assert.Equal(t, cancel.Hash(), receipts[0].TxHash)
+
+		balance, err := backend.Client().BalanceAt(ctx, testRecipient, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0, balance.Sign())
+	})
+
+	t.Run("retries an untracked transaction's replacement until it isn't underpriced", func(t *testing.T) {
+		backend, m := newTestTxManager(t, nil)
+		ctx := context.Background()
+
+		// pending from elsewhere, above the fees the manager starts the cancellation at
+		tip, feeCap, err := m.suggestFees(ctx)
+		require.NoError(t, err)
+		pending, err := m.owner.TransactionOptions.Signer(m.owner.FromAddress, types.NewTx(&types.DynamicFeeTx{
+			To:        &testRecipient,
+			Value:     big.NewInt(1),
+			Gas:       21000,
+			GasTipCap: bump(tip, 25),
+			GasFeeCap: bump(feeCap, 25),
+		}))
+		require.NoError(t, err)
+		require.NoError(t, backend.Client().SendTransaction(ctx, pending))
+
+		m.opts.MaxFeeBumps = 0
+		_, err = m.Cancel(ctx, 0)
+		assert.ErrorContains(t, err, "underpriced")
+
+		m.opts.MaxFeeBumps = 5
+		cancel, err := m.Cancel(ctx, 0)
+		require.NoError(t, err)
+		assert.Greater(t, cancel.GasFeeCap().Cmp(pending.GasFeeCap()), 0)
+
+		backend.Commit()
+		receipts, err := m.WaitAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, receipts, 1)
+		assert.Equal(t, cancel.Hash(), receipts[0].TxHash)
+	})
+}
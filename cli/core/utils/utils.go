@@ -19,6 +19,7 @@ import (
 
 	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/EigenPod"
 	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/ethereum/go-ethereum"
@@ -60,11 +61,13 @@ var (
 
 	// Consolidation constants
 	MAX_CONSOLIDATION_REQUESTS_PER_BLOCK      = big.NewInt(2)
+	TARGET_CONSOLIDATION_REQUESTS_PER_BLOCK   = big.NewInt(1)
 	MIN_CONSOLIDATION_REQUEST_FEE             = big.NewInt(1)
 	CONSOLIDATION_REQUEST_FEE_UPDATE_FRACTION = big.NewInt(17)
 
 	// Withdrawal constants
 	MAX_WITHDRAWAL_REQUESTS_PER_BLOCK      = big.NewInt(16)
+	TARGET_WITHDRAWAL_REQUESTS_PER_BLOCK   = big.NewInt(2)
 	MIN_WITHDRAWAL_REQUEST_FEE             = big.NewInt(1)
 	WITHDRAWAL_REQUEST_FEE_UPDATE_FRACTION = big.NewInt(17)
 )
@@ -189,12 +192,7 @@ type Owner = struct {
 	IsDryRun           bool
 }
 
-func StartCheckpoint(ctx context.Context, eigenpodAddress string, ownerPrivateKey string, chainId *big.Int, eth *ethclient.Client, forceCheckpoint bool, noSend bool) (*types.Transaction, error) {
-	ownerAccount, err := PrepareAccount(&ownerPrivateKey, chainId, noSend)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse private key: %w", err)
-	}
-
+func StartCheckpoint(ctx context.Context, txMgr *TxManager, eigenpodAddress string, eth *ethclient.Client, forceCheckpoint bool) (*types.Transaction, error) {
 	eigenPod, err := EigenPod.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reach eigenpod: %w", err)
@@ -202,7 +200,9 @@ func StartCheckpoint(ctx context.Context, eigenpodAddress string, ownerPrivateKe
 
 	revertIfNoBalance := !forceCheckpoint
 
-	txn, err := eigenPod.StartCheckpoint(ownerAccount.TransactionOptions, revertIfNoBalance)
+	txn, err := txMgr.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return eigenPod.StartCheckpoint(opts, revertIfNoBalance)
+	})
 	if err != nil {
 		if !forceCheckpoint {
 			return nil, fmt.Errorf("failed to start checkpoint (try running again with `--force`): %w", err)
@@ -215,8 +215,14 @@ func StartCheckpoint(ctx context.Context, eigenpodAddress string, ownerPrivateKe
 }
 
 func GetBeaconClient(beaconUri string, verbose bool) (BeaconClient, error) {
+	if IsEraPath(beaconUri) {
+		return NewEraClient(beaconUri, verbose)
+	}
 	beaconClient, _, err := NewBeaconClient(beaconUri, verbose)
-	return beaconClient, err
+	if err != nil || sharedStateCacheDir == "" {
+		return beaconClient, err
+	}
+	return NewStateCacheClient(beaconClient, sharedStateCacheDir, verbose)
 }
 
 func GetCurrentCheckpoint(eigenpodAddress string, client *ethclient.Client) (uint64, error) {
@@ -244,10 +250,35 @@ func GetCheckpointTimestampAndBeaconState(
 ) (uint64, *spec.VersionedBeaconState, error) {
 	tracing := GetContextTracingCallbacks(ctx)
 
+	checkpointTimestamp, beaconStateId, err := GetCheckpointTimestampAndStateId(ctx, eigenpodAddress, eth, beaconClient)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	tracing.OnStartSection("GetBeaconState", map[string]string{})
+	beaconState, err := beaconClient.GetBeaconState(ctx, beaconStateId)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to fetch beacon state: %w", err)
+	}
+	tracing.OnEndSection()
+
+	return checkpointTimestamp, beaconState, nil
+}
+
+// Like GetCheckpointTimestampAndBeaconState, but returns the id of the beacon state (the checkpoint's
+// slot, or "head") without downloading it.
+func GetCheckpointTimestampAndStateId(
+	ctx context.Context,
+	eigenpodAddress string,
+	eth *ethclient.Client,
+	beaconClient BeaconClient,
+) (uint64, string, error) {
+	tracing := GetContextTracingCallbacks(ctx)
+
 	tracing.OnStartSection("GetCurrentCheckpoint", map[string]string{})
 	checkpointTimestamp, err := GetCurrentCheckpoint(eigenpodAddress, eth)
 	if err != nil {
-		return 0, nil, fmt.Errorf("failed to fetch current checkpoint: %w", err)
+		return 0, "", fmt.Errorf("failed to fetch current checkpoint: %w", err)
 	}
 	tracing.OnEndSection()
 
@@ -260,15 +291,15 @@ func GetCheckpointTimestampAndBeaconState(
 		tracing.OnStartSection("GetCurrentCheckpointBlockRoot", map[string]string{})
 		blockRoot, err := GetCurrentCheckpointBlockRoot(eigenpodAddress, eth)
 		if err != nil {
-			return 0, nil, fmt.Errorf("failed to fetch last checkpoint: %w", err)
+			return 0, "", fmt.Errorf("failed to fetch last checkpoint: %w", err)
 		}
 		if blockRoot == nil {
-			return 0, nil, fmt.Errorf("failed to fetch last checkpoint - nil blockRoot")
+			return 0, "", fmt.Errorf("failed to fetch last checkpoint - nil blockRoot")
 		}
 		// Block root should be nonzero because we have an active checkpoint
 		rootBytes := *blockRoot
 		if AllZero(rootBytes[:]) {
-			return 0, nil, fmt.Errorf("failed to fetch last checkpoint - empty blockRoot")
+			return 0, "", fmt.Errorf("failed to fetch last checkpoint - empty blockRoot")
 		}
 		tracing.OnEndSection()
 
@@ -276,21 +307,14 @@ func GetCheckpointTimestampAndBeaconState(
 		tracing.OnStartSection("GetBeaconHeader", map[string]string{})
 		header, err := beaconClient.GetBeaconHeader(ctx, headerBlock)
 		if err != nil {
-			return 0, nil, fmt.Errorf("failed to fetch beacon header (%s): %w", headerBlock, err)
+			return 0, "", fmt.Errorf("failed to fetch beacon header (%s): %w", headerBlock, err)
 		}
 		tracing.OnEndSection()
 
 		beaconStateId = strconv.FormatUint(uint64(header.Header.Message.Slot), 10)
 	}
 
-	tracing.OnStartSection("GetBeaconState", map[string]string{})
-	beaconState, err := beaconClient.GetBeaconState(ctx, beaconStateId)
-	if err != nil {
-		return 0, nil, fmt.Errorf("failed to fetch beacon state: %w", err)
-	}
-	tracing.OnEndSection()
-
-	return checkpointTimestamp, beaconState, nil
+	return checkpointTimestamp, beaconStateId, nil
 }
 
 func GetBeaconHeadState(ctx context.Context, beaconClient BeaconClient) (*spec.VersionedBeaconState, error) {
@@ -358,35 +382,110 @@ func SortByStatus(validators map[string]Validator) ([]Validator, []Validator, []
 	return awaitingActivationQueueValidators, inactiveValidators, activeValidators, withdrawnValidators
 }
 
-// search through beacon state for validators whose withdrawal address is set to eigenpod.
+// search through beacon state for validators whose withdrawal address is set to eigenpod. Uses the
+// shared WithdrawalAddressIndex, so only the first call for a given state scans every validator.
 func FindAllValidatorsForEigenpod(eigenpodAddress string, beaconState *spec.VersionedBeaconState) ([]ValidatorWithIndex, error) {
-	allValidators, err := beaconState.Validators()
+	return SharedWithdrawalAddressIndex().ValidatorsFor(common.HexToAddress(eigenpodAddress), beaconState)
+}
+
+// Emitted by pods before the slashing upgrade, when ValidatorRestaked carried the validator's index
+// rather than its pubkey hash.
+var legacyValidatorRestakedEventId = crypto.Keccak256Hash([]byte("ValidatorRestaked(uint40)"))
+
+// FindRestakedValidatorIndices returns the index of every validator whose withdrawal credentials
+// were verified by `eigenpodAddress`, found from the pod's ValidatorRestaked events instead of a
+// beacon state. Validators pointed at the pod but never verified are not included.
+func FindRestakedValidatorIndices(ctx context.Context, eth *ethclient.Client, eigenpodAddress string) ([]uint64, error) {
+	eigenpodAbi, err := abi.JSON(strings.NewReader(EigenPod.EigenPodABI))
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch beacon state: %w", err)
+		return nil, fmt.Errorf("failed to load eigenpod abi: %w", err)
 	}
 
-	eigenpod := common.HexToAddress(eigenpodAddress)
+	latestBlock, err := eth.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest block: %w", err)
+	}
+
+	// a pod only emits a handful of these, so ask for the whole chain at once; FilterLogsChunked
+	// splits the range if the node refuses.
+	podAddress := common.HexToAddress(eigenpodAddress)
+	logs, err := FilterLogsChunked(ctx, eth, ethereum.FilterQuery{
+		Addresses: []common.Address{podAddress},
+		Topics:    [][]common.Hash{{eigenpodAbi.Events["ValidatorRestaked"].ID, legacyValidatorRestakedEventId}},
+	}, 0, latestBlock, latestBlock+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ValidatorRestaked events: %w", err)
+	}
 
-	var outputValidators []ValidatorWithIndex = []ValidatorWithIndex{}
-	var i uint64 = 0
-	maxValidators := uint64(len(allValidators))
-	for i = 0; i < maxValidators; i++ {
-		validator := allValidators[i]
-		if validator == nil || (validator.WithdrawalCredentials[0] != 1 && validator.WithdrawalCredentials[0] != 2) { // withdrawalCredentials _need_ their first byte set to 1 or 2 to withdraw to an eigenpod on the execution layer.
+	indices := map[uint64]bool{}
+	pubkeyHashes := [][32]byte{}
+	for _, entry := range logs {
+		if len(entry.Data) < 32 {
 			continue
 		}
-		// we check that the last 20 bytes of expectedCredentials matches validatorCredentials.
-		// // first 12 bytes are not the pubKeyHash, see (https://github.com/Layr-Labs/eigenlayer-contracts/blob/d148952a2942a97a218a2ab70f9b9f1792796081/src/contracts/pods/EigenPod.sol#L663)
-		validatorWithdrawalAddress := common.BytesToAddress(validator.WithdrawalCredentials[12:])
-
-		if eigenpod.Cmp(validatorWithdrawalAddress) == 0 {
-			outputValidators = append(outputValidators, ValidatorWithIndex{
-				Validator: validator,
-				Index:     i,
-			})
+		if entry.Topics[0] == legacyValidatorRestakedEventId {
+			indices[new(big.Int).SetBytes(entry.Data[:32]).Uint64()] = true
+		} else {
+			pubkeyHashes = append(pubkeyHashes, [32]byte(entry.Data[:32]))
+		}
+	}
+
+	if len(pubkeyHashes) > 0 {
+		calls := make([]*multicall.MultiCallMetaData[EigenPod.IEigenPodTypesValidatorInfo], len(pubkeyHashes))
+		for i, pubkeyHash := range pubkeyHashes {
+			calls[i], err = multicall.Describe[EigenPod.IEigenPodTypesValidatorInfo](podAddress, eigenpodAbi, "validatorPubkeyHashToInfo", pubkeyHash)
+			if err != nil {
+				return nil, fmt.Errorf("failed to form request for validator info: %w", err)
+			}
+		}
+
+		mc, err := multicall.NewMulticallClient(ctx, eth, &multicall.TMulticallClientOptions{
+			MaxBatchSizeBytes: 4096,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to contact multicall: %w", err)
+		}
+
+		results, err := multicall.DoMany(mc, calls...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch validator info: %w", err)
+		}
+		if results == nil {
+			return nil, errors.New("no results returned fetching validator info")
+		}
+
+		for _, info := range *results {
+			indices[info.ValidatorIndex] = true
+		}
+	}
+
+	out := lo.Keys(indices)
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out, nil
+}
+
+// GetValidatorsByIndex fetches validators `indices` as of `stateId` from the beacon node, in the
+// same order, without downloading the beacon state.
+func GetValidatorsByIndex(ctx context.Context, beaconClient BeaconClient, stateId string, indices []uint64) ([]*v1.Validator, error) {
+	ids := make([]string, len(indices))
+	for i, index := range indices {
+		ids[i] = strconv.FormatUint(index, 10)
+	}
+
+	validators, err := beaconClient.GetValidators(ctx, stateId, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch validators: %w", err)
+	}
+
+	out := make([]*v1.Validator, len(indices))
+	for i, index := range indices {
+		validator, ok := validators[phase0.ValidatorIndex(index)]
+		if !ok {
+			return nil, fmt.Errorf("validator %d not found in beacon state %s", index, stateId)
 		}
+		out[i] = validator
 	}
-	return outputValidators, nil
+	return out, nil
 }
 
 var zeroes = [16]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
@@ -529,6 +628,17 @@ func ForkVersions() map[uint64]string {
 	}
 }
 
+// GenesisValidatorsRoots identifies each network's beacon chain by its genesis validators root, for
+// sources that don't serve a genesis fork version (e.g. era files).
+func GenesisValidatorsRoots() map[uint64]string {
+	return map[uint64]string{
+		11155111: "d8ea171f3c94aea21ebc42a1ed61052acf3f9209c00e4efbaaddac09ed9b8078", // sepolia
+		560048:   "212f13fc4df078b6cb7db228f1c8307566dcecf900867401a92023d7ba99cb5f", // hoodi
+		17000:    "9143aa7c615a7f7115e2b6aac319c03529df8242ae705fba9df39b79c59fa8b1", // holesky
+		1:        "4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95", // mainnet
+	}
+}
+
 func GetEthClient(ctx context.Context, node string) (*ethclient.Client, *big.Int, error) {
 	eth, err := ethclient.Dial(node)
 	if err != nil {
@@ -557,6 +667,21 @@ func GetClients(ctx context.Context, node, beaconNodeUri string, enableLogs bool
 		return nil, nil, nil, fmt.Errorf("failed to reach beacon client: %w", err)
 	}
 
+	// era files have no genesis fork version, but their states record their chain's genesis
+	// validators root
+	if era, ok := beaconClient.(*eraClient); ok {
+		genesisValidatorsRoot, err := era.GenesisValidatorsRoot()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to read the era files' network: %w", err)
+		}
+		expected := GenesisValidatorsRoots()[chainId.Uint64()]
+		got := hex.EncodeToString(genesisValidatorsRoot[:])
+		if expected != got {
+			return nil, nil, nil, fmt.Errorf("check that the era files and --execNode correspond to the same network and try again (expected genesis_validators_root: %s, got %s)", expected, got)
+		}
+		return eth, beaconClient, chainId, nil
+	}
+
 	genesisForkVersion, err := beaconClient.GetGenesisForkVersion(ctx)
 	expectedForkVersion := ForkVersions()[chainId.Uint64()]
 	gotForkVersion := hex.EncodeToString((*genesisForkVersion)[:])
@@ -582,8 +707,10 @@ func CastBalanceProofs(proofs []*eigenpodproofs.BalanceProof) []EigenPod.BeaconC
 	return out
 }
 
+// PanicIfNoConsent asks the user to confirm `prompt` on stdin, and aborts unless they do. The prompt
+// is written to stderr, so that it doesn't end up in output meant for other programs (e.g. --json).
 func PanicIfNoConsent(prompt string) {
-	color.New(color.Bold).Printf("%s - Do you want to proceed? (y/n): ", prompt)
+	color.New(color.Bold).Fprintf(os.Stderr, "%s - Do you want to proceed? (y/n): ", prompt)
 	var reply string
 
 	fmt.Scanln(&reply)
@@ -903,10 +1030,10 @@ func EstimateConsolidationFeePerChunk(
 		})
 
 		// Simulate excess update across block boundary
-		// new_excess = max(0, curExcess + len(chunk) - MAX_DEQUEUE)
+		// new_excess = max(0, curExcess + len(chunk) - TARGET)
 		reqCount := big.NewInt(int64(len(chunk)))
 		curExcess.Add(curExcess, reqCount)
-		curExcess.Sub(curExcess, MAX_CONSOLIDATION_REQUESTS_PER_BLOCK)
+		curExcess.Sub(curExcess, TARGET_CONSOLIDATION_REQUESTS_PER_BLOCK)
 
 		if curExcess.Sign() == -1 {
 			curExcess.SetUint64(0)
@@ -953,10 +1080,10 @@ func EstimateWithdrawalFeePerChunk(
 		})
 
 		// Simulate excess update across block boundary
-		// new_excess = max(0, curExcess + len(chunk) - MAX_DEQUEUE)
+		// new_excess = max(0, curExcess + len(chunk) - TARGET)
 		reqCount := big.NewInt(int64(len(chunk)))
 		curExcess.Add(curExcess, reqCount)
-		curExcess.Sub(curExcess, MAX_WITHDRAWAL_REQUESTS_PER_BLOCK)
+		curExcess.Sub(curExcess, TARGET_WITHDRAWAL_REQUESTS_PER_BLOCK)
 
 		if curExcess.Sign() == -1 {
 			curExcess.SetUint64(0)
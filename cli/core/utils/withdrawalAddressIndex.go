@@ -0,0 +1,227 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// WithdrawalAddressIndex maps execution-layer withdrawal addresses to the indices of the validators
+// withdrawing to them, so that finding a pod's validators doesn't require scanning every validator.
+//
+// Validator indices are never reused, and withdrawal credentials only ever change from BLS (0x00) to
+// an execution address (which a later switch to 0x02 keeps). So, to bring the index up to date with a
+// newer state, only the validators appended since the last indexed slot and those that still had BLS
+// credentials need to be scanned.
+type WithdrawalAddressIndex struct {
+	mu sync.RWMutex
+	// whether the index changed since it was loaded
+	dirty bool
+
+	// identifies the chain the index was built for
+	GenesisValidatorsRoot common.Hash
+	// slot of the most recent state indexed
+	Slot phase0.Slot
+	// number of validators indexed
+	ValidatorCount uint64
+	// withdrawal address => indices of the validators withdrawing to it
+	Addresses map[common.Address][]uint64
+	// indexed validators that had BLS withdrawal credentials, which may since have been changed to an
+	// execution address
+	BLSValidators []uint64
+}
+
+func NewWithdrawalAddressIndex() *WithdrawalAddressIndex {
+	return &WithdrawalAddressIndex{
+		Addresses: map[common.Address][]uint64{},
+	}
+}
+
+var (
+	// the index used by FindAllValidatorsForEigenpod
+	sharedWithdrawalAddressIndex   = NewWithdrawalAddressIndex()
+	sharedWithdrawalAddressIndexMu sync.RWMutex
+)
+
+// SharedWithdrawalAddressIndex returns the index FindAllValidatorsForEigenpod uses, which is shared
+// by everything in the process.
+func SharedWithdrawalAddressIndex() *WithdrawalAddressIndex {
+	sharedWithdrawalAddressIndexMu.RLock()
+	defer sharedWithdrawalAddressIndexMu.RUnlock()
+	return sharedWithdrawalAddressIndex
+}
+
+// UseWithdrawalAddressIndex replaces the shared index, e.g. with one loaded from disk.
+func UseWithdrawalAddressIndex(index *WithdrawalAddressIndex) {
+	sharedWithdrawalAddressIndexMu.Lock()
+	defer sharedWithdrawalAddressIndexMu.Unlock()
+	sharedWithdrawalAddressIndex = index
+}
+
+// LoadWithdrawalAddressIndex reads an index saved with Save, or returns an empty index if there is
+// no file at `path` yet.
+func LoadWithdrawalAddressIndex(path string) (*WithdrawalAddressIndex, error) {
+	index := NewWithdrawalAddressIndex()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read withdrawal address index: %w", err)
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse withdrawal address index %s: %w", path, err)
+	}
+	if index.Addresses == nil {
+		index.Addresses = map[common.Address][]uint64{}
+	}
+	return index, nil
+}
+
+// Save writes the index to `path`, if it changed since it was loaded.
+func (idx *WithdrawalAddressIndex) Save(path string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if !idx.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so an interrupted save doesn't corrupt the index
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save withdrawal address index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save withdrawal address index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save withdrawal address index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save withdrawal address index: %w", err)
+	}
+	idx.dirty = false
+	return nil
+}
+
+// Update brings the index up to date with `state`. States older than the last indexed one are
+// ignored; ValidatorsFor still answers correctly for them.
+func (idx *WithdrawalAddressIndex) Update(state *spec.VersionedBeaconState) error {
+	genesisValidatorsRoot, err := beacon.GetGenesisValidatorsRoot(state)
+	if err != nil {
+		return err
+	}
+	slot, err := state.Slot()
+	if err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if common.Hash(genesisValidatorsRoot) != idx.GenesisValidatorsRoot {
+		// a different chain; start over
+		idx.GenesisValidatorsRoot = common.Hash(genesisValidatorsRoot)
+		idx.Slot = 0
+		idx.ValidatorCount = 0
+		idx.Addresses = map[common.Address][]uint64{}
+		idx.BLSValidators = nil
+	} else if slot <= idx.Slot {
+		return nil
+	}
+
+	validators, err := state.Validators()
+	if err != nil {
+		return fmt.Errorf("failed to read validators: %w", err)
+	}
+
+	stillBLS := []uint64{}
+	for _, i := range idx.BLSValidators {
+		if i < uint64(len(validators)) && !idx.add(i, validators[i]) {
+			stillBLS = append(stillBLS, i)
+		}
+	}
+	for i := idx.ValidatorCount; i < uint64(len(validators)); i++ {
+		if !idx.add(i, validators[i]) {
+			stillBLS = append(stillBLS, i)
+		}
+	}
+
+	idx.BLSValidators = stillBLS
+	idx.ValidatorCount = max(idx.ValidatorCount, uint64(len(validators)))
+	idx.Slot = slot
+	idx.dirty = true
+	return nil
+}
+
+// add records the validator under its withdrawal address, returning false if it doesn't have one yet.
+func (idx *WithdrawalAddressIndex) add(index uint64, validator *phase0.Validator) bool {
+	address := ExecutionWithdrawalAddress(validator)
+	if address == nil {
+		return false
+	}
+	idx.Addresses[*address] = append(idx.Addresses[*address], index)
+	return true
+}
+
+// ValidatorsFor returns the validators in `state` whose withdrawal address is `address`, ordered by
+// index, updating the index first if `state` is newer.
+func (idx *WithdrawalAddressIndex) ValidatorsFor(address common.Address, state *spec.VersionedBeaconState) ([]ValidatorWithIndex, error) {
+	if err := idx.Update(state); err != nil {
+		return nil, fmt.Errorf("failed to update withdrawal address index: %w", err)
+	}
+
+	validators, err := state.Validators()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch beacon state: %w", err)
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	out := []ValidatorWithIndex{}
+	for _, i := range idx.Addresses[address] {
+		// `state` may be older than the index, in which case the validator may not exist yet, or
+		// may still have BLS credentials.
+		if i >= uint64(len(validators)) {
+			continue
+		}
+		if addr := ExecutionWithdrawalAddress(validators[i]); addr != nil && *addr == address {
+			out = append(out, ValidatorWithIndex{Validator: validators[i], Index: i})
+		}
+	}
+	sort.Slice(out, func(a, b int) bool {
+		return out[a].Index < out[b].Index
+	})
+	return out, nil
+}
+
+// ExecutionWithdrawalAddress returns the validator's withdrawal address, or nil if it doesn't have an
+// execution-layer (0x01 or 0x02) withdrawal credential.
+func ExecutionWithdrawalAddress(validator *phase0.Validator) *common.Address {
+	// withdrawalCredentials _need_ their first byte set to 1 or 2 to withdraw to an eigenpod on the execution layer.
+	if validator == nil || (validator.WithdrawalCredentials[0] != 1 && validator.WithdrawalCredentials[0] != 2) {
+		return nil
+	}
+	// the first 12 bytes are the prefix and padding; see (https://github.com/Layr-Labs/eigenlayer-contracts/blob/d148952a2942a97a218a2ab70f9b9f1792796081/src/contracts/pods/EigenPod.sol#L663)
+	address := common.BytesToAddress(validator.WithdrawalCredentials[12:])
+	return &address
+}
@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	podA = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	podB = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+)
+
+// blsCredentials stands for a validator with BLS (0x00) withdrawal credentials.
+var blsCredentials = common.Address{}
+
+// newIndexTestState returns a state of the chain identified by `chain`, with one validator per entry
+// of `withdrawalAddresses`, using 0x01 credentials unless `compounding`.
+func newIndexTestState(chain byte, slot uint64, compounding bool, withdrawalAddresses ...common.Address) *spec.VersionedBeaconState {
+	state := &deneb.BeaconState{
+		GenesisValidatorsRoot: phase0.Root{chain},
+		Slot:                  phase0.Slot(slot),
+	}
+	for i, address := range withdrawalAddresses {
+		credentials := make([]byte, 32)
+		if address != blsCredentials {
+			credentials[0] = 1
+			if compounding {
+				credentials[0] = 2
+			}
+			copy(credentials[12:], address[:])
+		}
+		state.Validators = append(state.Validators, &phase0.Validator{
+			PublicKey:             phase0.BLSPubKey{byte(i + 1)},
+			WithdrawalCredentials: credentials,
+		})
+	}
+	return &spec.VersionedBeaconState{Version: spec.DataVersionDeneb, Deneb: state}
+}
+
+func TestWithdrawalAddressIndexUpdate(t *testing.T) {
+	type query struct {
+		state   *spec.VersionedBeaconState
+		address common.Address
+		want    []uint64
+	}
+
+	initial := newIndexTestState(1, 10, false, blsCredentials, podA, podB)
+	tests := []struct {
+		name    string
+		queries []query
+		// slot and validator count indexed after the queries
+		slot, validatorCount uint64
+	}{
+		{
+			name: "BLS credentials changed to 0x01",
+			queries: []query{
+				{initial, podA, []uint64{1}},
+				{newIndexTestState(1, 20, false, podA, podA, podB), podA, []uint64{0, 1}},
+			},
+			slot:           20,
+			validatorCount: 3,
+		},
+		{
+			name: "validators still with BLS credentials",
+			queries: []query{
+				{initial, podA, []uint64{1}},
+				{newIndexTestState(1, 20, false, blsCredentials, podA, podB), podA, []uint64{1}},
+				{newIndexTestState(1, 30, false, podB, podA, podB), podB, []uint64{0, 2}},
+			},
+			slot:           30,
+			validatorCount: 3,
+		},
+		{
+			name: "new validators",
+			queries: []query{
+				{initial, podB, []uint64{2}},
+				{newIndexTestState(1, 20, true, blsCredentials, podA, podB, podB, podA), podB, []uint64{2, 3}},
+			},
+			slot:           20,
+			validatorCount: 5,
+		},
+		{
+			name: "older states",
+			queries: []query{
+				{newIndexTestState(1, 20, false, podA, podA, podB, podA), podA, []uint64{0, 1, 3}},
+				// validator 0 still had BLS credentials, and validator 3 didn't exist yet
+				{initial, podA, []uint64{1}},
+			},
+			slot:           20,
+			validatorCount: 4,
+		},
+		{
+			name: "a different chain",
+			queries: []query{
+				{initial, podA, []uint64{1}},
+				// an earlier slot, but of another chain
+				{newIndexTestState(2, 5, false, podB, podA), podA, []uint64{1}},
+				{newIndexTestState(2, 5, false, podB, podA), podB, []uint64{0}},
+			},
+			slot:           5,
+			validatorCount: 2,
+		},
+		{
+			name: "no validators",
+			queries: []query{
+				{newIndexTestState(1, 5, false), podA, []uint64{}},
+				{initial, podA, []uint64{1}},
+			},
+			slot:           10,
+			validatorCount: 3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			index := NewWithdrawalAddressIndex()
+			for i, q := range test.queries {
+				validators, err := index.ValidatorsFor(q.address, q.state)
+				require.NoError(t, err)
+
+				got := []uint64{}
+				for _, v := range validators {
+					got = append(got, v.Index)
+				}
+				assert.Equal(t, q.want, got, "query %d", i)
+			}
+			assert.Equal(t, phase0.Slot(test.slot), index.Slot)
+			assert.Equal(t, test.validatorCount, index.ValidatorCount)
+		})
+	}
+
+	_, err := NewWithdrawalAddressIndex().ValidatorsFor(podA, &spec.VersionedBeaconState{Version: spec.DataVersionPhase0})
+	assert.Error(t, err)
+}
+
+func TestWithdrawalAddressIndexSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+
+	index, err := LoadWithdrawalAddressIndex(path)
+	require.NoError(t, err)
+	require.NoError(t, index.Update(newIndexTestState(1, 10, false, blsCredentials, podA, podB)))
+	require.NoError(t, index.Save(path))
+
+	loaded, err := LoadWithdrawalAddressIndex(path)
+	require.NoError(t, err)
+	assert.Equal(t, index.Addresses, loaded.Addresses)
+	assert.Equal(t, []uint64{0}, loaded.BLSValidators)
+	assert.Equal(t, phase0.Slot(10), loaded.Slot)
+
+	// the loaded index picks up where the saved one left off
+	validators, err := loaded.ValidatorsFor(podA, newIndexTestState(1, 20, false, podA, podA, podB))
+	require.NoError(t, err)
+	assert.Len(t, validators, 2)
+}
+
+func TestUseWithdrawalAddressIndex(t *testing.T) {
+	previous := SharedWithdrawalAddressIndex()
+	t.Cleanup(func() { UseWithdrawalAddressIndex(previous) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			UseWithdrawalAddressIndex(NewWithdrawalAddressIndex())
+		}()
+		go func() {
+			defer wg.Done()
+			assert.NotNil(t, SharedWithdrawalAddressIndex())
+		}()
+	}
+	wg.Wait()
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/utils"
 	v1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -41,13 +42,9 @@ func LoadValidatorProofFromFile(path string) (*SerializableCredentialProof, erro
 	return &res, nil
 }
 
-func SubmitValidatorProof(ctx context.Context, owner, eigenpodAddress string, chainId *big.Int, eth *ethclient.Client, batchSize uint64, proofs *eigenpodproofs.VerifyValidatorFieldsCallParams, oracleBeaconTimesetamp uint64, noPrompt bool, noSend bool, verbose bool) ([]*types.Transaction, [][]*big.Int, error) {
-	ownerAccount, err := utils.PrepareAccount(&owner, chainId, noSend)
-	if err != nil {
-		return nil, [][]*big.Int{}, err
-	}
-	utils.PanicOnError("failed to parse private key", err)
-
+// SubmitValidatorProof submits `proofs` in chunks of `batchSize`, keeping several chunks in flight at once.
+// Live submissions wait for every chunk to be mined before returning.
+func SubmitValidatorProof(ctx context.Context, txMgr *utils.TxManager, eigenpodAddress string, eth *ethclient.Client, batchSize uint64, proofs *eigenpodproofs.VerifyValidatorFieldsCallParams, oracleBeaconTimesetamp uint64, noPrompt bool, verbose bool) ([]*types.Transaction, [][]*big.Int, error) {
 	eigenPod, err := EigenPod.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
 	if err != nil {
 		return nil, [][]*big.Int{}, err
@@ -57,7 +54,7 @@ func SubmitValidatorProof(ctx context.Context, owner, eigenpodAddress string, ch
 	validatorIndicesChunks := utils.Chunk(indices, batchSize)
 	validatorProofsChunks := utils.Chunk(proofs.ValidatorFieldsProofs, batchSize)
 	validatorFieldsChunks := utils.Chunk(proofs.ValidatorFields, batchSize)
-	if !noPrompt && !noSend {
+	if !noPrompt && !txMgr.IsDryRun() {
 		utils.PanicIfNoConsent(utils.SubmitCredentialsProofConsent(len(validatorFieldsChunks)))
 	}
 
@@ -66,7 +63,7 @@ func SubmitValidatorProof(ctx context.Context, owner, eigenpodAddress string, ch
 
 	if verbose {
 		color.Green("calling EigenPod.VerifyWithdrawalCredentials() (using %d txn(s), max(%d) proofs per txn [%s])", numChunks, batchSize, func() string {
-			if ownerAccount.TransactionOptions.NoSend {
+			if txMgr.IsDryRun() {
 				return "simulated"
 			} else {
 				return "live"
@@ -86,37 +83,49 @@ func SubmitValidatorProof(ctx context.Context, owner, eigenpodAddress string, ch
 		}
 		var curValidatorFields [][][32]byte = utils.CastValidatorFields(validatorFieldsChunks[i])
 
-		if verbose {
-			fmt.Printf("Submitted chunk %d/%d -- waiting for transaction...: ", i+1, numChunks)
-		}
-		txn, err := SubmitValidatorProofChunk(ctx, ownerAccount, eigenPod, chainId, eth, curValidatorIndices, curValidatorFields, proofs.StateRootProof, validatorFieldsProofs, oracleBeaconTimesetamp, verbose)
+		txn, err := SubmitValidatorProofChunk(ctx, txMgr, eigenPod, curValidatorIndices, curValidatorFields, proofs.StateRootProof, validatorFieldsProofs, oracleBeaconTimesetamp, verbose)
 		if err != nil {
 			return transactions, validatorIndicesChunks, err
 		}
+		if verbose {
+			fmt.Printf("Submitted chunk %d/%d: %s\n", i+1, numChunks, txn.Hash().Hex())
+		}
 
 		transactions = append(transactions, txn)
 	}
 
-	return transactions, validatorIndicesChunks, err
+	if !txMgr.IsDryRun() {
+		if verbose {
+			fmt.Printf("waiting for %d transaction(s)...: ", len(transactions))
+		}
+		if _, err := txMgr.WaitAll(ctx); err != nil {
+			return transactions, validatorIndicesChunks, err
+		}
+		if verbose {
+			color.Green("OK")
+		}
+	}
+
+	return transactions, validatorIndicesChunks, nil
 }
 
-func SubmitValidatorProofChunk(ctx context.Context, ownerAccount *utils.Owner, eigenPod *EigenPod.EigenPod, chainId *big.Int, eth *ethclient.Client, indices []*big.Int, validatorFields [][][32]byte, stateRootProofs *eigenpodproofs.StateRootProof, validatorFieldsProofs [][]byte, oracleBeaconTimesetamp uint64, verbose bool) (*types.Transaction, error) {
+func SubmitValidatorProofChunk(ctx context.Context, txMgr *utils.TxManager, eigenPod *EigenPod.EigenPod, indices []*big.Int, validatorFields [][][32]byte, stateRootProofs *eigenpodproofs.StateRootProof, validatorFieldsProofs [][]byte, oracleBeaconTimesetamp uint64, verbose bool) (*types.Transaction, error) {
 	if verbose {
 		color.Green("submitting...")
 	}
-	txn, err := eigenPod.VerifyWithdrawalCredentials(
-		ownerAccount.TransactionOptions,
-		oracleBeaconTimesetamp,
-		EigenPod.BeaconChainProofsStateRootProof{
-			Proof:           stateRootProofs.Proof.ToByteSlice(),
-			BeaconStateRoot: stateRootProofs.BeaconStateRoot,
-		},
-		indices,
-		validatorFieldsProofs,
-		validatorFields,
-	)
-
-	return txn, err
+	return txMgr.Send(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return eigenPod.VerifyWithdrawalCredentials(
+			opts,
+			oracleBeaconTimesetamp,
+			EigenPod.BeaconChainProofsStateRootProof{
+				Proof:           stateRootProofs.Proof.ToByteSlice(),
+				BeaconStateRoot: stateRootProofs.BeaconStateRoot,
+			},
+			indices,
+			validatorFieldsProofs,
+			validatorFields,
+		)
+	})
 }
 
 /**
@@ -158,6 +167,63 @@ func GenerateValidatorProof(ctx context.Context, eigenpodAddress string, eth *et
 	return proofs, latestBlock.Time(), err
 }
 
+// GenerateValidatorProofFromNode is GenerateValidatorProof without downloading the beacon state, for
+// beacon nodes that serve state proofs (see BeaconClient.GetStateProof). Validators awaiting a
+// credential proof can't be found without the state, so `validatorIndex` must be given.
+func GenerateValidatorProofFromNode(ctx context.Context, eigenpodAddress string, eth *ethclient.Client, chainId *big.Int, beaconClient utils.BeaconClient, validatorIndex uint64, verbose bool) (*eigenpodproofs.VerifyValidatorFieldsCallParams, uint64, error) {
+	latestBlock, err := eth.BlockByNumber(ctx, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load latest block: %w", err)
+	}
+
+	eigenPod, err := EigenPod.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to reach eigenpod: %w", err)
+	}
+
+	expectedBlockRoot, err := eigenPod.GetParentBlockRoot(nil, latestBlock.Time())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load parent block root: %w", err)
+	}
+
+	header, err := beaconClient.GetBeaconHeader(ctx, "0x"+common.Bytes2Hex(expectedBlockRoot[:]))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch beacon header: %w", err)
+	}
+
+	// the proof layout depends on the fork of the state
+	block, err := beaconClient.GetBlock(ctx, header.Root.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch beacon block: %w", err)
+	}
+	if block == nil {
+		return nil, 0, fmt.Errorf("beacon block %s not found", header.Root)
+	}
+
+	validators, err := utils.GetValidatorsByIndex(ctx, beaconClient, strconv.FormatUint(uint64(header.Header.Message.Slot), 10), []uint64{validatorIndex})
+	if err != nil {
+		return nil, 0, err
+	}
+	if address := utils.ExecutionWithdrawalAddress(validators[0].Validator); address == nil || *address != common.HexToAddress(eigenpodAddress) {
+		return nil, 0, fmt.Errorf("validator at index %d does not have withdrawal credentials set to pod %s", validatorIndex, eigenpodAddress)
+	}
+
+	proofExecutor, err := eigenpodproofs.NewEigenPodProofs(chainId.Uint64(), 300 /* oracleStateCacheExpirySeconds - 5min */)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to initialize provider: %w", err)
+	}
+
+	if verbose {
+		color.Blue("Verifying validator %d", validatorIndex)
+	}
+
+	proofs, err := proofExecutor.ProveValidatorContainersFromNode(ctx, beaconClient, header.Header.Message, block.Version, []uint64{validatorIndex})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to prove validators: %w", err)
+	}
+	return proofs, latestBlock.Time(), nil
+}
+
 func GenerateValidatorProofAtState(ctx context.Context, proofs *eigenpodproofs.EigenPodProofs, eigenpodAddress string, beaconState *spec.VersionedBeaconState, eth *ethclient.Client, chainId *big.Int, header *v1.BeaconBlockHeader, blockTimestamp uint64, forSpecificValidatorIndex *big.Int, verbose bool) (*eigenpodproofs.VerifyValidatorFieldsCallParams, error) {
 	allValidators, err := utils.FindAllValidatorsForEigenpod(eigenpodAddress, beaconState)
 	if err != nil {
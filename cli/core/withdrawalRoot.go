@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/IDelegationManager"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Average execution layer block time, used to turn block counts into wall-clock estimates
+const BLOCK_TIME = 12 * time.Second
+
+var withdrawalArguments = func() abi.Arguments {
+	withdrawalType, err := abi.NewType("tuple", "", []abi.ArgumentMarshaling{
+		{Name: "staker", Type: "address"},
+		{Name: "delegatedTo", Type: "address"},
+		{Name: "withdrawer", Type: "address"},
+		{Name: "nonce", Type: "uint256"},
+		{Name: "startBlock", Type: "uint32"},
+		{Name: "strategies", Type: "address[]"},
+		{Name: "scaledShares", Type: "uint256[]"},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return abi.Arguments{{Type: withdrawalType}}
+}()
+
+// CalculateWithdrawalRoot implements `DelegationManager.calculateWithdrawalRoot`:
+// keccak256(abi.encode(withdrawal)).
+func CalculateWithdrawalRoot(withdrawal IDelegationManager.IDelegationManagerTypesWithdrawal) (common.Hash, error) {
+	encoded, err := withdrawalArguments.Pack(withdrawal)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode withdrawal: %w", err)
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
+
+// EstimateBlockTime estimates the wall-clock time `block` is produced at, given that `curBlock` was
+// produced at `curBlockTime`.
+func EstimateBlockTime(block uint64, curBlock uint64, curBlockTime time.Time) time.Time {
+	if block <= curBlock {
+		return curBlockTime.Add(-time.Duration(curBlock-block) * BLOCK_TIME)
+	}
+	return curBlockTime.Add(time.Duration(block-curBlock) * BLOCK_TIME)
+}
+
+// FindWithdrawalQueuedTxs finds the transaction that queued each withdrawal, from the
+// SlashingWithdrawalQueued events emitted in its start block. Withdrawals whose event can't be
+// found are left out of the result.
+func FindWithdrawalQueuedTxs(
+	ctx context.Context,
+	eth *ethclient.Client,
+	delegationManager common.Address,
+	withdrawals []QueuedWithdrawal,
+) (map[common.Hash]common.Hash, error) {
+	dmAbi, err := IDelegationManager.IDelegationManagerMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load delegation manager abi: %w", err)
+	}
+	filterer, err := IDelegationManager.NewIDelegationManagerFilterer(delegationManager, eth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach delegation manager: %w", err)
+	}
+
+	wanted := map[common.Hash]bool{}
+	blocks := map[uint32]bool{}
+	for _, w := range withdrawals {
+		wanted[w.Root] = true
+		blocks[w.Withdrawal.StartBlock] = true
+	}
+
+	out := map[common.Hash]common.Hash{}
+	for block := range blocks {
+		logs, err := eth.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(uint64(block)),
+			ToBlock:   new(big.Int).SetUint64(uint64(block)),
+			Addresses: []common.Address{delegationManager},
+			Topics:    [][]common.Hash{{dmAbi.Events["SlashingWithdrawalQueued"].ID}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch logs for block %d: %w", block, err)
+		}
+
+		for _, log := range logs {
+			event, err := filterer.ParseSlashingWithdrawalQueued(log)
+			if err != nil {
+				return nil, err
+			}
+			if root := common.Hash(event.WithdrawalRoot); wanted[root] {
+				out[root] = log.TxHash
+			}
+		}
+	}
+	return out, nil
+}
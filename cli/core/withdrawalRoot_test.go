@@ -0,0 +1,105 @@
+package core
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/DelegationManager"
+	"github.com/Layr-Labs/eigenlayer-contracts/pkg/bindings/IDelegationManager"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deployDelegationManager deploys a DelegationManager to a simulated chain, for its pure
+// calculateWithdrawalRoot.
+func deployDelegationManager(t *testing.T) *DelegationManager.DelegationManagerCaller {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(params.Ether)},
+	})
+	t.Cleanup(func() { backend.Close() })
+
+	chainId, err := backend.Client().ChainID(context.Background())
+	require.NoError(t, err)
+	auth, err := bind.NewKeyedTransactorWithChainID(key, chainId)
+	require.NoError(t, err)
+
+	// the dependencies aren't called; the pauser registry only has to be non-zero
+	dependency := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	address, _, _, err := DelegationManager.DeployDelegationManager(auth, backend.Client(), dependency, dependency, dependency, dependency, dependency, 1, "v1.6.2")
+	require.NoError(t, err)
+	backend.Commit()
+
+	caller, err := DelegationManager.NewDelegationManagerCaller(address, backend.Client())
+	require.NoError(t, err)
+	return caller
+}
+
+func TestCalculateWithdrawalRoot(t *testing.T) {
+	delegationManager := deployDelegationManager(t)
+
+	staker := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	operator := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	tests := []struct {
+		name       string
+		withdrawal IDelegationManager.IDelegationManagerTypesWithdrawal
+		// what the contract returns, pinned
+		want common.Hash
+	}{
+		{
+			name: "beacon chain ETH",
+			withdrawal: IDelegationManager.IDelegationManagerTypesWithdrawal{
+				Staker:       staker,
+				DelegatedTo:  operator,
+				Withdrawer:   staker,
+				Nonce:        big.NewInt(7),
+				StartBlock:   21_000_000,
+				Strategies:   []common.Address{BeaconStrategy()},
+				ScaledShares: []*big.Int{new(big.Int).Mul(big.NewInt(32), big.NewInt(params.Ether))},
+			},
+			want: common.HexToHash("0xaa0a2aeb61b8c90fa2aaed57a48891b1a5ba09916c0fde4bfc518a086947a28b"),
+		},
+		{
+			name: "several strategies, undelegated",
+			withdrawal: IDelegationManager.IDelegationManagerTypesWithdrawal{
+				Staker:       staker,
+				Withdrawer:   staker,
+				Nonce:        big.NewInt(0),
+				StartBlock:   1,
+				Strategies:   []common.Address{BeaconStrategy(), common.HexToAddress("0x93c4b944D05dfe6df7645A86cd2206016c51564D")},
+				ScaledShares: []*big.Int{big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), 255)},
+			},
+			want: common.HexToHash("0x8d758544c4f033a7c8a153c1e02ad9ff360b091284946e4fe78de9c8b515f3d7"),
+		},
+		{
+			name: "no strategies",
+			withdrawal: IDelegationManager.IDelegationManagerTypesWithdrawal{
+				Staker:       staker,
+				Nonce:        new(big.Int).SetUint64(^uint64(0)),
+				StartBlock:   ^uint32(0),
+				Strategies:   []common.Address{},
+				ScaledShares: []*big.Int{},
+			},
+			want: common.HexToHash("0x75115ff9e09818cf20441422d3c18a85c7668bd40f028edd83028d831608c343"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := CalculateWithdrawalRoot(test.withdrawal)
+			require.NoError(t, err)
+
+			want, err := delegationManager.CalculateWithdrawalRoot(nil, DelegationManager.IDelegationManagerTypesWithdrawal(test.withdrawal))
+			require.NoError(t, err)
+			assert.Equal(t, common.Hash(want), root)
+			assert.Equal(t, test.want, root)
+		})
+	}
+}
@@ -29,7 +29,7 @@ var BeaconNodeFlag = &cli.StringFlag{
 	Name:        "beaconNode",
 	Aliases:     []string{"b"},
 	Value:       "",
-	Usage:       "[required] `URL` to a functioning beacon node RPC (https://)",
+	Usage:       "[required] `URL` to a functioning beacon node RPC (https://), or a local .era file (or directory of them) for archived blocks",
 	Required:    true,
 	Destination: &beacon,
 }
@@ -88,6 +88,14 @@ var PrintJSONFlag = &cli.BoolFlag{
 	Destination: &useJSON,
 }
 
+// Optional use for commands that generate proofs
+var ProofsFromNodeFlag = &cli.BoolFlag{
+	Name:        "proofs-from-node",
+	Value:       false,
+	Usage:       "Build proofs from Merkle proofs served by the beacon node (/eth/v0/beacon/proof/state, e.g. Lodestar) instead of downloading the full beacon state.",
+	Destination: &proofsFromNode,
+}
+
 // shared flag --batch
 func BatchBySize(destination *uint64, defaultValue uint64) *cli.Uint64Flag {
 	return &cli.Uint64Flag{
@@ -100,6 +108,12 @@ func BatchBySize(destination *uint64, defaultValue uint64) *cli.Uint64Flag {
 }
 
 // Flags for each consolidation subcommand
+var MaxFeePerRequestFlag = &cli.Uint64Flag{
+	Name:        "max-fee-per-request",
+	Usage:       "The highest predeploy fee (in `gwei`) to pay per request. When set, requests are sent one chunk per block, waiting whenever the fee is above the cap for the predeploy queue to drain. By default, all chunks are sent at once at the current fee.",
+	Destination: &maxFeePerRequest,
+}
+
 var ConsolidationFlags = []cli.Flag{
 	VerboseFlag,
 	PodAddressFlag,
@@ -109,16 +123,17 @@ var ConsolidationFlags = []cli.Flag{
 	EstimateGasFlag,
 	PrintJSONFlag,
 	BatchBySize(&batchSize, utils.DEFAULT_BATCH_CONSOLIDATE),
-	// &cli.BoolFlag{
-	// 	Name: "no-warn",
-	// 	Usage: "Turn off warnings for various consolidation failure states. By default, this command will prompt you if a consolidation might fail because of:\n" +
-	// 		"* invalid switch request (source already has 0x02 credentials)\n" +
-	// 		"* conflicting consolidation already in progress\n" +
-	// 		"* conflicting withdrawal requests already in progress\n" +
-	// 		"* invalid consolidation source/target\n" +
-	// 		"* ... and more.", // TODO
-	// 	Destination: &noWarn,
-	// },
+	&cli.BoolFlag{
+		Name: "no-warn",
+		Usage: "Turn off warnings for consolidations that will be processed, but possibly not as expected (e.g. a target exceeding the max effective balance). " +
+			"Requests that the beacon chain would drop are always refused, e.g. because of:\n" +
+			"* invalid switch request (source already has 0x02 credentials)\n" +
+			"* target without 0x02 credentials\n" +
+			"* exiting, inactive or too-young validators\n" +
+			"* pending partial withdrawals on the source\n" +
+			"* no consolidation churn available",
+		Destination: &noWarn,
+	},
 	&cli.Float64Flag{
 		Name:        "fee-overestimate-factor",
 		Aliases:     []string{"overestimate", "over"},
@@ -126,6 +141,7 @@ var ConsolidationFlags = []cli.Flag{
 		Value:       feeOverestimateFactor,
 		Destination: &feeOverestimateFactor,
 	},
+	MaxFeePerRequestFlag,
 }
 
 // Flags for each consolidation subcommand
@@ -138,16 +154,16 @@ var RequestWithdrawalFlags = []cli.Flag{
 	EstimateGasFlag,
 	PrintJSONFlag,
 	BatchBySize(&batchSize, utils.DEFAULT_BATCH_WITHDRAWREQUEST),
-	// &cli.BoolFlag{
-	// 	Name: "no-warn",
-	// 	Usage: "Turn off warnings for various consolidation failure states. By default, this command will prompt you if a consolidation might fail because of:\n" +
-	// 		"* invalid switch request (source already has 0x02 credentials)\n" +
-	// 		"* conflicting consolidation already in progress\n" +
-	// 		"* conflicting withdrawal requests already in progress\n" +
-	// 		"* invalid consolidation source/target\n" +
-	// 		"* ... and more.", // TODO
-	// 	Destination: &noWarn,
-	// },
+	&cli.BoolFlag{
+		Name: "no-warn",
+		Usage: "Turn off warnings for withdrawals that will be processed, but possibly not as expected (e.g. a partial withdrawal capped to keep the validator at 32 ETH). " +
+			"Requests that the beacon chain would drop are always refused, e.g. because of:\n" +
+			"* partial withdrawals from validators without 0x02 credentials\n" +
+			"* exiting, inactive or too-young validators\n" +
+			"* no balance above 32 ETH left to withdraw\n" +
+			"* pending partial withdrawals blocking a full exit",
+		Destination: &noWarn,
+	},
 	&cli.Float64Flag{
 		Name:        "fee-overestimate-factor",
 		Aliases:     []string{"overestimate", "over"},
@@ -155,6 +171,7 @@ var RequestWithdrawalFlags = []cli.Flag{
 		Value:       feeOverestimateFactor,
 		Destination: &feeOverestimateFactor,
 	},
+	MaxFeePerRequestFlag,
 }
 
 // Hack to make a copy of a flag that sets `Required` to true
@@ -168,3 +185,8 @@ func Require(flag *cli.StringFlag) *cli.StringFlag {
 		Required:    true,
 	}
 }
+
+var PodCacheFlag = &cli.StringFlag{
+	Name:  "pod-cache",
+	Usage: "`path` to a file remembering which withdrawal addresses are eigenpods, so repeated scans only check new addresses. Created if it doesn't exist.",
+}
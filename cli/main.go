@@ -1,10 +1,12 @@
 package main
 
 import (
+	"fmt"
 	"math"
 	"os"
 
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/commands"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/utils"
 	cli "github.com/urfave/cli/v2"
 )
@@ -18,16 +20,66 @@ var slashedValidatorIndex uint64
 var amountWei uint64
 var verbose = false
 var checkFee = false
+var noWarn = false
 var feeOverestimateFactor = float64(1.5)
 var batchSize uint64
+var maxFeePerRequest uint64
+var fromBlock uint64
+var exitFlowStateFile string
+var receiveAsShares = false
+var indexDatabase string
+var withdrawalIndexFile string
+var stateCacheDir string
+var lightStatus = false
+var proofsFromNode = false
 
 const DefaultHealthcheckTolerance = float64(5.0)
 
+func indexQueryCommands(disableColor *bool) []*cli.Command {
+	queries := []struct{ name, usage string }{
+		{commands.IndexQueryVerifications, "When each validator's withdrawal credentials were verified, and by whom"},
+		{commands.IndexQueryCheckpoints, "Each checkpoint's share delta, and who submitted its proofs"},
+		{commands.IndexQuerySubmitters, "Every address that submitted credential or checkpoint proofs"},
+	}
+
+	out := make([]*cli.Command, len(queries))
+	for i, query := range queries {
+		out[i] = &cli.Command{
+			Name:  query.name,
+			Usage: query.usage,
+			Flags: []cli.Flag{
+				PrintJSONFlag,
+				&cli.StringFlag{
+					Name:        "db",
+					Value:       commands.DEFAULT_INDEX_DB,
+					Usage:       "`path` to the SQLite database",
+					Destination: &indexDatabase,
+				},
+				&cli.StringSliceFlag{
+					Name:  "pods",
+					Usage: "Only report on these pod `addresses`. Defaults to every indexed pod.",
+				},
+			},
+			Action: func(ctx *cli.Context) error {
+				return commands.IndexQueryCommand(commands.TIndexQueryCommandArgs{
+					Database:     indexDatabase,
+					Pods:         ctx.StringSlice("pods"),
+					Query:        query.name,
+					UseJSON:      useJSON,
+					DisableColor: *disableColor,
+				})
+			},
+		}
+	}
+	return out
+}
+
 func main() {
 	var forceCheckpoint = false
 	var disableColor = false
 	var noPrompt = false
 	var tolerance = DefaultHealthcheckTolerance
+	var cancelNonce uint64 = math.MaxUint64
 
 	app := &cli.App{
 		Name:                   "Eigenlayer Proofs CLI",
@@ -51,13 +103,17 @@ func main() {
 						Usage:       "The percentage balance deviation to tolerate when deciding whether an eigenpod should be corrected. Default is 5% (e.g --tolerance 5).",
 						Destination: &tolerance,
 					},
+					PrintJSONFlag,
+					PodCacheFlag,
 				},
-				Action: func(_ *cli.Context) error {
+				Action: func(cctx *cli.Context) error {
 					return commands.FindStalePodsCommand(commands.TFindStalePodsCommandArgs{
 						EthNode:    node,
 						BeaconNode: beacon,
 						Verbose:    verbose,
 						Tolerance:  tolerance,
+						PodCache:   cctx.String(PodCacheFlag.Name),
+						UseJSON:    useJSON,
 					})
 				},
 			},
@@ -93,6 +149,101 @@ func main() {
 					})
 				},
 			},
+			{
+				Name:      "watch-slashings",
+				Args:      true,
+				Usage:     "Watches the beacon chain for slashings of your pods' validators, and corrects a pod's stale balance (like `correct-stale-pod`) once its balance deviation exceeds the tolerance.",
+				UsageText: "./cli watch-slashings [FLAGS]",
+				Flags: []cli.Flag{
+					VerboseFlag,
+					ExecNodeFlag,
+					BeaconNodeFlag,
+					SenderPkFlag,
+					BatchBySize(&batchSize, utils.DEFAULT_BATCH_CHECKPOINT),
+					&cli.StringSliceFlag{
+						Name:     "pods",
+						Usage:    "[required] The `addresses` of the pods to watch",
+						Required: true,
+					},
+					&cli.Float64Flag{
+						Name:        "tolerance",
+						Value:       DefaultHealthcheckTolerance, // default: 5
+						Usage:       "The percentage balance deviation to tolerate before correcting a pod (e.g --tolerance 5).",
+						Destination: &tolerance,
+					},
+					&cli.Uint64Flag{
+						Name:  "check-interval",
+						Value: core.DEFAULT_SLASHING_CHECK_INTERVAL_EPOCHS,
+						Usage: "How often, in `epochs`, to re-check pods with slashed validators (their balances keep decreasing after the slashing)",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					return commands.WatchSlashingsCommand(commands.TWatchSlashingsArgs{
+						Pods:                ctx.StringSlice("pods"),
+						Node:                node,
+						BeaconNode:          beacon,
+						Sender:              sender,
+						Tolerance:           tolerance,
+						CheckIntervalEpochs: ctx.Uint64("check-interval"),
+						CheckpointBatchSize: batchSize,
+						DisableColor:        disableColor,
+						Verbose:             verbose,
+					})
+				},
+			},
+			{
+				Name:      "stale-pod-keeper",
+				Args:      true,
+				Usage:     "Periodically finds stale pods across the network and calls `verifyStaleBalance` on them, most deviated first. Anyone may run this.",
+				UsageText: "./cli stale-pod-keeper [FLAGS]",
+				Flags: []cli.Flag{
+					VerboseFlag,
+					ExecNodeFlag,
+					BeaconNodeFlag,
+					SenderPkFlag,
+					PrintJSONFlag,
+					PodCacheFlag,
+					&cli.Float64Flag{
+						Name:        "tolerance",
+						Value:       DefaultHealthcheckTolerance, // default: 5
+						Usage:       "The percentage balance deviation to tolerate before correcting a pod (e.g --tolerance 5).",
+						Destination: &tolerance,
+					},
+					&cli.DurationFlag{
+						Name:  "interval",
+						Value: commands.DEFAULT_KEEPER_INTERVAL,
+						Usage: "How often to scan for stale pods (e.g --interval 30m)",
+					},
+					&cli.Uint64Flag{
+						Name:  "max-spend",
+						Usage: "Stop once this many `gwei` have been spent on gas in total; 0 for no limit",
+					},
+					&cli.Uint64Flag{
+						Name:  "max-base-fee",
+						Usage: "Don't submit anything while the base fee is above this many `gwei`; 0 for no limit",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Scan once and report which pods would be corrected, with gas estimates, without sending anything",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					return commands.StalePodKeeperCommand(commands.TStalePodKeeperArgs{
+						Node:           node,
+						BeaconNode:     beacon,
+						Sender:         sender,
+						Tolerance:      tolerance,
+						Interval:       ctx.Duration("interval"),
+						MaxSpendGwei:   ctx.Uint64("max-spend"),
+						MaxBaseFeeGwei: ctx.Uint64("max-base-fee"),
+						DryRun:         ctx.Bool("dry-run"),
+						PodCache:       ctx.String(PodCacheFlag.Name),
+						UseJSON:        useJSON,
+						DisableColor:   disableColor,
+						Verbose:        verbose,
+					})
+				},
+			},
 			{
 				Name:      "assign-submitter",
 				Args:      true,
@@ -124,6 +275,12 @@ func main() {
 					BeaconNodeFlag,
 					ExecNodeFlag,
 					PrintJSONFlag,
+					&cli.BoolFlag{
+						Name:        "light",
+						Value:       false,
+						Usage:       "Query the pod's verified validators from the beacon node instead of downloading the full beacon state. Much faster, but does not list validators awaiting a credential proof or estimate the beacon chain's queues.",
+						Destination: &lightStatus,
+					},
 				},
 				Action: func(_ *cli.Context) error {
 					return commands.StatusCommand(commands.TStatusArgs{
@@ -133,6 +290,7 @@ func main() {
 						Node:            node,
 						BeaconNode:      beacon,
 						Verbose:         verbose,
+						Light:           lightStatus,
 					})
 				},
 			},
@@ -148,6 +306,7 @@ func main() {
 					SenderPkFlag,
 					EstimateGasFlag,
 					BatchBySize(&batchSize, utils.DEFAULT_BATCH_CHECKPOINT),
+					ProofsFromNodeFlag,
 					&cli.BoolFlag{
 						Name:        "force",
 						Aliases:     []string{"f"},
@@ -168,6 +327,7 @@ func main() {
 						EigenpodAddress:     eigenpodAddress,
 						Verbose:             verbose,
 						Sender:              sender,
+						ProofsFromNode:      proofsFromNode,
 					})
 				},
 			},
@@ -189,6 +349,7 @@ func main() {
 						Usage:       "The `index` of a specific validator to prove (e.g a slashed validator for `verifyStaleBalance()`).",
 						Destination: &specificValidator,
 					},
+					ProofsFromNodeFlag,
 				},
 				Action: func(_ *cli.Context) error {
 					return commands.CredentialsCommand(commands.TCredentialCommandArgs{
@@ -203,9 +364,160 @@ func main() {
 						BatchSize:           batchSize,
 						NoPrompt:            noPrompt,
 						Verbose:             verbose,
+						ProofsFromNode:      proofsFromNode,
 					})
 				},
 			},
+			{
+				Name:  "requests",
+				Usage: "Track the EIP-7002/EIP-7251 requests sent by your eigenpod",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "status",
+						Usage: "Reports whether each withdrawal/consolidation request sent by the pod is pending, processed or dropped by the beacon chain.",
+						Flags: []cli.Flag{
+							VerboseFlag,
+							PodAddressFlag,
+							BeaconNodeFlag,
+							ExecNodeFlag,
+							PrintJSONFlag,
+							&cli.Uint64Flag{
+								Name:        "from-block",
+								Usage:       "The `block` to start searching for requests from. Defaults to roughly 14 days ago.",
+								Destination: &fromBlock,
+							},
+						},
+						Action: func(_ *cli.Context) error {
+							return commands.RequestsStatusCommand(commands.TRequestsStatusCommandArgs{
+								EigenpodAddress: eigenpodAddress,
+								DisableColor:    disableColor,
+								UseJSON:         useJSON,
+								Node:            node,
+								BeaconNode:      beacon,
+								FromBlock:       fromBlock,
+								Verbose:         verbose,
+							})
+						},
+					},
+				},
+			},
+			{
+				Name:  "inflows",
+				Usage: "Explains where the pod's uncheckpointed ETH came from, by summing the beacon chain withdrawals paid to the pod (partial sweeps and full exits) since its last checkpoint. The remainder of the pod's balance change is attributed to non-beacon transfers.",
+				Flags: []cli.Flag{
+					VerboseFlag,
+					PodAddressFlag,
+					BeaconNodeFlag,
+					ExecNodeFlag,
+					PrintJSONFlag,
+					&cli.Uint64Flag{
+						Name:  "from-timestamp",
+						Usage: "Start of the range (exclusive), as a unix `timestamp`. Defaults to the pod's last checkpoint.",
+					},
+					&cli.Uint64Flag{
+						Name:  "to-timestamp",
+						Usage: "End of the range (inclusive), as a unix `timestamp`. Defaults to the pod's active checkpoint, or the latest block.",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					return commands.InflowsCommand(commands.TInflowsCommandArgs{
+						EigenpodAddress: eigenpodAddress,
+						Node:            node,
+						BeaconNode:      beacon,
+						FromTimestamp:   ctx.Uint64("from-timestamp"),
+						ToTimestamp:     ctx.Uint64("to-timestamp"),
+						UseJSON:         useJSON,
+						DisableColor:    disableColor,
+						Verbose:         verbose,
+					})
+				},
+			},
+			{
+				Name:  "report",
+				Usage: "Accounting reports for your eigenpod",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "checkpoints",
+						Usage: "Reconstructs every checkpoint finalized in a block range, broken down by validator balance changes, pod ETH balance change and slashing factor changes.",
+						Flags: []cli.Flag{
+							PodAddressFlag,
+							ExecNodeFlag,
+							&cli.Uint64Flag{
+								Name:        "from-block",
+								Usage:       "The first `block` of the report. Defaults to roughly 30 days ago.",
+								Destination: &fromBlock,
+							},
+							&cli.Uint64Flag{
+								Name:  "to-block",
+								Usage: "The last `block` of the report. Defaults to the latest block.",
+							},
+							&cli.StringFlag{
+								Name:  "format",
+								Usage: "Output `format`: json or csv",
+								Value: "json",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return commands.CheckpointReportCommand(commands.TCheckpointReportArgs{
+								EigenpodAddress: eigenpodAddress,
+								Node:            node,
+								FromBlock:       fromBlock,
+								ToBlock:         ctx.Uint64("to-block"),
+								Format:          ctx.String("format"),
+							})
+						},
+					},
+				},
+			},
+			{
+				Name:      "index",
+				Usage:     "Indexes the EigenPod, EigenPodManager and DelegationManager events of a set of pods into a local SQLite database. Each run picks up where the last one stopped.",
+				UsageText: "./cli index -e <execNodeRPC> --pods <podAddress>,<podAddress> [--from-block <block>]",
+				Flags: []cli.Flag{
+					VerboseFlag,
+					&cli.StringFlag{
+						Name:        "execNode",
+						Aliases:     []string{"e"},
+						Usage:       "[required] `URL` to a functioning execution-layer RPC (https://)",
+						Destination: &node,
+					},
+					&cli.StringFlag{
+						Name:        "db",
+						Value:       commands.DEFAULT_INDEX_DB,
+						Usage:       "`path` to the SQLite database",
+						Destination: &indexDatabase,
+					},
+					&cli.StringSliceFlag{
+						Name:  "pods",
+						Usage: "The `addresses` of the pods to index. Defaults to every pod already in the database.",
+					},
+					&cli.Uint64Flag{
+						Name:        "from-block",
+						Usage:       "The `block` to start indexing newly added pods from (e.g. the block the pod was deployed in)",
+						Destination: &fromBlock,
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					if node == "" {
+						return fmt.Errorf("--execNode is required")
+					}
+					return commands.IndexCommand(commands.TIndexCommandArgs{
+						Database:     indexDatabase,
+						Pods:         ctx.StringSlice("pods"),
+						Node:         node,
+						FromBlock:    fromBlock,
+						DisableColor: disableColor,
+						Verbose:      verbose,
+					})
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:        "query",
+						Usage:       "Answers questions about the indexed pods",
+						Subcommands: indexQueryCommands(&disableColor),
+					},
+				},
+			},
 			{
 				Name:  "consolidate",
 				Usage: "(EIP-7521) Consolidates eligible validators via EigenPod.requestConsolidation()",
@@ -236,12 +548,44 @@ func main() {
 									NoPrompt:              noPrompt,
 									Verbose:               verbose,
 									CheckFee:              checkFee,
+									NoWarn:                noWarn,
 									FeeOverestimateFactor: feeOverestimateFactor,
+									MaxFeePerRequestGwei:  maxFeePerRequest,
 								},
 								Validators: ctx.Uint64Slice("validators"),
 							})
 						},
 					},
+					{
+						Name:  "plan",
+						Usage: "Computes a consolidation plan for all of your pod's validators, packing 0x01 validators into 0x02 targets.",
+						Flags: []cli.Flag{
+							VerboseFlag,
+							PodAddressFlag,
+							BeaconNodeFlag,
+							ExecNodeFlag,
+							PrintJSONFlag,
+							BatchBySize(&batchSize, utils.DEFAULT_BATCH_CONSOLIDATE),
+							&cli.StringFlag{
+								Name:    "output",
+								Aliases: []string{"out", "O"},
+								Value:   "",
+								Usage:   "Output `path` for the planned consolidation requests (in the format read by `LoadConsolidationRequestFromFile`)",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return commands.ConsolidatePlanCommand(commands.TConsolidatePlanCommandArgs{
+								EigenpodAddress: eigenpodAddress,
+								DisableColor:    disableColor,
+								UseJSON:         useJSON,
+								Node:            node,
+								BeaconNode:      beacon,
+								BatchSize:       batchSize,
+								Verbose:         verbose,
+								Output:          ctx.String("output"),
+							})
+						},
+					},
 					{
 						Name:  "source-to-target",
 						Usage: "Specify a target validator inbdex and a list of source validator indices to consolidate into the target.",
@@ -272,7 +616,9 @@ func main() {
 									NoPrompt:              noPrompt,
 									Verbose:               verbose,
 									CheckFee:              checkFee,
+									NoWarn:                noWarn,
 									FeeOverestimateFactor: feeOverestimateFactor,
+									MaxFeePerRequestGwei:  maxFeePerRequest,
 								},
 								TargetValidator:  ctx.Uint64("target"),
 								SourceValidators: ctx.Uint64Slice("sources"),
@@ -311,7 +657,9 @@ func main() {
 									NoPrompt:              noPrompt,
 									Verbose:               verbose,
 									CheckFee:              checkFee,
+									NoWarn:                noWarn,
 									FeeOverestimateFactor: feeOverestimateFactor,
+									MaxFeePerRequestGwei:  maxFeePerRequest,
 								},
 								Validators: ctx.Uint64Slice("validators"),
 							})
@@ -347,7 +695,9 @@ func main() {
 									NoPrompt:              noPrompt,
 									Verbose:               verbose,
 									CheckFee:              checkFee,
+									NoWarn:                noWarn,
 									FeeOverestimateFactor: feeOverestimateFactor,
+									MaxFeePerRequestGwei:  maxFeePerRequest,
 								},
 								Validators: ctx.Uint64Slice("validators"),
 								AmtsGwei:   ctx.Uint64Slice("amounts"),
@@ -359,20 +709,80 @@ func main() {
 			{
 				Name:  "complete-all-withdrawals",
 				Args:  true,
-				Usage: "Completes all withdrawals queued on the podOwner, for which Native ETH is the sole strategy in the withdrawal. Attempts to execute a group of withdrawals whose sum does not exceed Pod.withdrawableRestakedExecutionLayerGwei() in value.",
+				Usage: "Completes withdrawals queued on the podOwner. When receiving native ETH as tokens, completes the group of withdrawals with the largest total value that does not exceed Pod.withdrawableRestakedExecutionLayerGwei().",
 				Flags: []cli.Flag{
 					VerboseFlag,
 					ExecNodeFlag,
 					PodAddressFlag,
 					SenderPkFlag,
 					EstimateGasFlag,
+					PrintJSONFlag,
+					&cli.StringSliceFlag{
+						Name:  "withdrawal-roots",
+						Usage: "Only complete the withdrawals with these `roots` (see `show-withdrawals`). By default, all completable withdrawals are considered.",
+					},
+					&cli.BoolFlag{
+						Name:        "receive-as-shares",
+						Usage:       "Redeposit the withdrawn shares instead of receiving them as tokens. Native ETH withdrawals completed as shares don't need any withdrawable ETH in the pod.",
+						Destination: &receiveAsShares,
+					},
 				},
-				Action: func(_ *cli.Context) error {
+				Action: func(ctx *cli.Context) error {
 					return commands.CompleteAllWithdrawalsCommand(commands.TCompleteWithdrawalArgs{
-						EthNode:     node,
-						EigenPod:    eigenpodAddress,
-						Sender:      sender,
-						EstimateGas: estimateGas,
+						EthNode:         node,
+						EigenPod:        eigenpodAddress,
+						Sender:          sender,
+						EstimateGas:     sender == "" || estimateGas,
+						NoPrompt:        noPrompt,
+						UseJSON:         useJSON,
+						WithdrawalRoots: ctx.StringSlice("withdrawal-roots"),
+						ReceiveAsShares: receiveAsShares,
+					})
+				},
+			},
+			{
+				Name:  "exit-flow",
+				Args:  true,
+				Usage: "Walks validators through exiting the pod and withdrawing their ETH (request exit -> wait for sweep -> checkpoint -> queue withdrawal -> complete withdrawal), performing the next step on each run.",
+				Flags: []cli.Flag{
+					VerboseFlag,
+					PodAddressFlag,
+					BeaconNodeFlag,
+					ExecNodeFlag,
+					SenderPkFlag,
+					BatchBySize(&batchSize, utils.DEFAULT_BATCH_WITHDRAWREQUEST),
+					&cli.Uint64SliceFlag{
+						Name:  "validators",
+						Usage: "The validator indices to exit. Only needed on the first run; later runs read them from the state file.",
+					},
+					&cli.StringFlag{
+						Name:        "state-file",
+						Usage:       "The `path` to persist progress to. Defaults to ./exit-flow-<podAddress>.json",
+						Destination: &exitFlowStateFile,
+					},
+					&cli.Float64Flag{
+						Name:        "fee-overestimate-factor",
+						Aliases:     []string{"overestimate", "over"},
+						Usage:       "Specify how much to overestimate the predeploy request fee by when requesting exits.",
+						Value:       feeOverestimateFactor,
+						Destination: &feeOverestimateFactor,
+					},
+					MaxFeePerRequestFlag,
+				},
+				Action: func(ctx *cli.Context) error {
+					return commands.ExitFlowCommand(commands.TExitFlowCommandArgs{
+						EigenpodAddress:       eigenpodAddress,
+						Node:                  node,
+						BeaconNode:            beacon,
+						Sender:                sender,
+						Validators:            ctx.Uint64Slice("validators"),
+						StateFile:             exitFlowStateFile,
+						BatchSize:             batchSize,
+						NoPrompt:              noPrompt,
+						Verbose:               verbose,
+						DisableColor:          disableColor,
+						FeeOverestimateFactor: feeOverestimateFactor,
+						MaxFeePerRequestGwei:  maxFeePerRequest,
 					})
 				},
 			},
@@ -394,6 +804,7 @@ func main() {
 						EigenPod:    eigenpodAddress,
 						Sender:      sender,
 						EstimateGas: estimateGas,
+						NoPrompt:    noPrompt,
 						AmountWei:   amountWei,
 					})
 				},
@@ -401,16 +812,48 @@ func main() {
 			{
 				Name:  "show-withdrawals",
 				Args:  true,
-				Usage: "Shows all pending withdrawals for the podOwner.",
+				Usage: "Shows all pending withdrawals for the podOwner, with their roots and when they become completable.",
 				Flags: []cli.Flag{
 					VerboseFlag,
 					ExecNodeFlag,
 					PodAddressFlag,
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "Output `format`: json or csv",
+						Value: "json",
+					},
 				},
-				Action: func(_ *cli.Context) error {
+				Action: func(ctx *cli.Context) error {
 					return commands.ShowWithdrawalsCommand(commands.TShowWithdrawalArgs{
 						EthNode:  node,
 						EigenPod: eigenpodAddress,
+						Format:   ctx.String("format"),
+					})
+				},
+			},
+			{
+				Name:      "cancel-nonce",
+				Args:      true,
+				Usage:     "Replaces a stuck transaction from --sender with an empty, higher-fee transaction to itself.",
+				UsageText: "./cli cancel-nonce [FLAGS]",
+				Flags: []cli.Flag{
+					VerboseFlag,
+					ExecNodeFlag,
+					Require(SenderPkFlag),
+					&cli.Uint64Flag{
+						Name:        "nonce",
+						Value:       math.MaxUint64,
+						Usage:       "The `nonce` to cancel. Defaults to the sender's oldest pending transaction.",
+						Destination: &cancelNonce,
+					},
+				},
+				Action: func(_ *cli.Context) error {
+					return commands.CancelNonceCommand(commands.TCancelNonceArgs{
+						EthNode:  node,
+						Sender:   sender,
+						Nonce:    cancelNonce,
+						NoPrompt: noPrompt,
+						Verbose:  verbose,
 					})
 				},
 			},
@@ -428,6 +871,30 @@ func main() {
 				Usage:       "Disables prompts to approve any transactions occurring (e.g in CI).",
 				Destination: &noPrompt,
 			},
+			&cli.StringFlag{
+				Name:        "withdrawal-index",
+				Usage:       "`path` to a file caching which validators withdraw to which address, so pods' validators can be found without scanning every validator. Created if it doesn't exist, and updated after each run.",
+				Destination: &withdrawalIndexFile,
+			},
+			&cli.StringFlag{
+				Name:        "stateCache",
+				Value:       commands.DefaultStateCacheDir(),
+				Usage:       "`dir` to cache beacon states downloaded by slot or root in, so they aren't downloaded again. States unused for a week are removed, as are the least recently used ones once it exceeds 4GiB. Pass an empty dir to disable the cache.",
+				Destination: &stateCacheDir,
+			},
+		},
+		Before: func(_ *cli.Context) error {
+			commands.UseStateCache(stateCacheDir)
+			if withdrawalIndexFile == "" {
+				return nil
+			}
+			return commands.LoadWithdrawalAddressIndex(withdrawalIndexFile)
+		},
+		After: func(_ *cli.Context) error {
+			if withdrawalIndexFile == "" {
+				return nil
+			}
+			return commands.SaveWithdrawalAddressIndex(withdrawalIndexFile)
 		},
 	}
 
@@ -0,0 +1,65 @@
+package common
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// Generalized indices ("gindices") number the nodes of a Merkle tree: the root is 1, and the children
+// of node i are 2i and 2i+1. See https://github.com/ethereum/consensus-specs/blob/dev/ssz/merkle-proofs.md
+//
+// The gindex of a node deep inside an SSZ object is built from a path of steps down its fields, e.g.
+//
+//	ConcatGindices(FieldGindex(stateTreeHeight, VALIDATORS_INDEX), ListElementGindex(VALIDATOR_TREE_HEIGHT, i))
+//
+// for the i-th validator of a beacon state.
+
+// LIST_LENGTH_GINDEX is the gindex of the length of an SSZ list, relative to the list's root.
+const LIST_LENGTH_GINDEX = uint64(3)
+
+// FieldGindex returns the gindex of field (or vector element) `index` of a container whose fields
+// are the leaves of a tree of height `treeHeight`. index must be below 2^treeHeight.
+func FieldGindex(treeHeight uint64, index uint64) uint64 {
+	return 1<<treeHeight + index
+}
+
+// ListElementGindex returns the gindex of element `index` of an SSZ list whose elements (or
+// chunks, for packed basic types) are the leaves of a tree of height `treeHeight`, relative to the
+// list's root (which also mixes in the list's length).
+func ListElementGindex(treeHeight uint64, index uint64) uint64 {
+	return 2<<treeHeight + index
+}
+
+// ConcatGindices returns the gindex of the node reached by following each of `gindices` in turn,
+// each relative to the node reached by the previous ones.
+func ConcatGindices(gindices ...uint64) (uint64, error) {
+	result := uint64(1)
+	for _, gindex := range gindices {
+		if gindex == 0 {
+			return 0, errors.New("invalid gindex 0")
+		}
+		depth := GindexDepth(gindex)
+		if GindexDepth(result)+depth > 63 {
+			return 0, errors.New("gindex path is too deep")
+		}
+		result = result<<depth | (gindex ^ 1<<depth)
+	}
+	return result, nil
+}
+
+// GindexDepth returns the depth of the node `gindex` below the root.
+func GindexDepth(gindex uint64) int {
+	return 63 - bits.LeadingZeros64(gindex)
+}
+
+// IsGindexAncestor returns whether `ancestor` is `gindex` or one of its ancestors.
+func IsGindexAncestor(ancestor uint64, gindex uint64) bool {
+	depth := GindexDepth(gindex) - GindexDepth(ancestor)
+	return depth >= 0 && gindex>>depth == ancestor
+}
+
+// GindexIndex returns the position of `gindex` among the nodes of its depth, which is the index
+// taken by ValidateProof for a proof against the root.
+func GindexIndex(gindex uint64) uint64 {
+	return gindex ^ 1<<GindexDepth(gindex)
+}
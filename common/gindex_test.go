@@ -0,0 +1,54 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcatGindices(t *testing.T) {
+	tests := []struct {
+		name     string
+		gindices []uint64
+		want     uint64
+	}{
+		{name: "no path", gindices: nil, want: 1},
+		{name: "the root", gindices: []uint64{1, 1}, want: 1},
+		{name: "left then right", gindices: []uint64{2, 3}, want: 5},
+		{name: "right, left, right", gindices: []uint64{3, 2, 3}, want: 13},
+		{name: "with the root in between", gindices: []uint64{6, 1, 5}, want: 25},
+		// a deneb state's first validator: field 11 of a 32-field container, then element 0 of a
+		// list of 2^40 validators
+		{name: "a validator", gindices: []uint64{FieldGindex(5, 11), ListElementGindex(40, 0)}, want: 94557999988736},
+		{name: "a list's length", gindices: []uint64{FieldGindex(5, 11), LIST_LENGTH_GINDEX}, want: 87},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ConcatGindices(test.gindices...)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+
+	_, err := ConcatGindices(2, 0)
+	assert.ErrorContains(t, err, "invalid gindex 0")
+	_, err = ConcatGindices(1<<40, 1<<24)
+	assert.ErrorContains(t, err, "too deep")
+	got, err := ConcatGindices(1<<40, 1<<23)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1<<63), got)
+}
+
+func TestGindexHelpers(t *testing.T) {
+	assert.Equal(t, 0, GindexDepth(1))
+	assert.Equal(t, 3, GindexDepth(13))
+	assert.Equal(t, uint64(5), GindexIndex(13))
+	assert.Equal(t, uint64(0), GindexIndex(1))
+
+	assert.True(t, IsGindexAncestor(1, 13))
+	assert.True(t, IsGindexAncestor(3, 13))
+	assert.True(t, IsGindexAncestor(13, 13))
+	assert.False(t, IsGindexAncestor(2, 13))
+	assert.False(t, IsGindexAncestor(26, 13))
+}
@@ -0,0 +1,225 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// CompactMultiproof is a Merkle multiproof in the "compact" encoding served by consensus clients'
+// proof endpoints (e.g. Lodestar's /eth/v0/beacon/proof/state/{state_id}).
+//
+// The descriptor is a bitstring of the proof tree in pre-order, with a 0 for every branch node and a
+// 1 for every leaf, padded with 0s to a whole byte. Leaves holds the value of each leaf, in the same
+// order.
+type CompactMultiproof struct {
+	Leaves     []phase0.Root
+	Descriptor []byte
+}
+
+// ProofNodes holds every node of a multiproof's tree, keyed by generalized index (the root is 1).
+type ProofNodes map[uint64]phase0.Root
+
+// ProveMultiproof returns the compact multiproof of the nodes `gindices` of `tree`.
+func ProveMultiproof(tree Tree, gindices []uint64) (*CompactMultiproof, error) {
+	descriptor, err := ComputeDescriptor(gindices)
+	if err != nil {
+		return nil, err
+	}
+	leafGindices, err := proofLeafGindices(gindices)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &CompactMultiproof{
+		Leaves:     make([]phase0.Root, len(leafGindices)),
+		Descriptor: descriptor,
+	}
+	for i, gindex := range leafGindices {
+		proof.Leaves[i], err = tree.Get(gindex)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return proof, nil
+}
+
+// ComputeDescriptor returns the descriptor of the compact multiproof for `gindices`.
+func ComputeDescriptor(gindices []uint64) ([]byte, error) {
+	leaves, err := proofLeafGindices(gindices)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptor := []byte{}
+	numBits := 0
+	appendBit := func(set bool) {
+		if numBits%8 == 0 {
+			descriptor = append(descriptor, 0)
+		}
+		if set {
+			descriptor[numBits/8] |= 1 << (7 - numBits%8)
+		}
+		numBits++
+	}
+
+	// in pre-order, a leaf comes right after every branch it is the leftmost leaf of, i.e. one for
+	// each trailing 0 in its gindex.
+	for _, gindex := range leaves {
+		for i := 0; i < bits.TrailingZeros64(gindex); i++ {
+			appendBit(false)
+		}
+		appendBit(true)
+	}
+	return descriptor, nil
+}
+
+// proofLeafGindices returns the gindices of the leaves of the multiproof for `gindices` (the
+// requested nodes, and the siblings of their paths to the root), in pre-order.
+func proofLeafGindices(gindices []uint64) ([]uint64, error) {
+	if len(gindices) == 0 {
+		return nil, errors.New("no gindices to prove")
+	}
+
+	leaves := map[uint64]bool{}
+	paths := map[uint64]bool{}
+	for _, gindex := range gindices {
+		if gindex == 0 {
+			return nil, errors.New("invalid gindex 0")
+		}
+		leaves[gindex] = true
+		for node := gindex; node > 1; node /= 2 {
+			paths[node/2] = true
+			leaves[node^1] = true
+		}
+	}
+
+	// requested nodes (or siblings) that are on another node's path are computed from its proof
+	out := []uint64{}
+	for gindex := range leaves {
+		if !paths[gindex] {
+			out = append(out, gindex)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return preorderLess(out[i], out[j])
+	})
+	return out, nil
+}
+
+// preorderLess orders gindices, none of which is an ancestor of another, from left to right.
+func preorderLess(a, b uint64) bool {
+	depthA, depthB := GindexDepth(a), GindexDepth(b)
+	if depthA < depthB {
+		return a<<(depthB-depthA) < b
+	}
+	return a < b<<(depthA-depthB)
+}
+
+// DescriptorGindices returns the gindices of the leaves of the multiproof described by
+// `descriptor`, in order.
+func DescriptorGindices(descriptor []byte) ([]uint64, error) {
+	gindices := []uint64{}
+	bit := 0
+
+	var walk func(gindex uint64) error
+	walk = func(gindex uint64) error {
+		if GindexDepth(gindex) == 63 {
+			return errors.New("invalid descriptor: tree is too deep")
+		}
+		if bit >= len(descriptor)*8 {
+			return errors.New("invalid descriptor: not enough leaves")
+		}
+		isLeaf := descriptor[bit/8]&(1<<(7-bit%8)) != 0
+		bit++
+
+		if isLeaf {
+			gindices = append(gindices, gindex)
+			return nil
+		}
+		if err := walk(gindex * 2); err != nil {
+			return err
+		}
+		return walk(gindex*2 + 1)
+	}
+
+	if err := walk(1); err != nil {
+		return nil, err
+	}
+
+	// only padding is allowed after the tree
+	if bit+7 < len(descriptor)*8 {
+		return nil, errors.New("invalid descriptor: too many bytes")
+	}
+	for ; bit < len(descriptor)*8; bit++ {
+		if descriptor[bit/8]&(1<<(7-bit%8)) != 0 {
+			return nil, errors.New("invalid descriptor: non-zero padding")
+		}
+	}
+	return gindices, nil
+}
+
+// Nodes rebuilds the tree of the multiproof, returning every node it covers.
+func (p *CompactMultiproof) Nodes() (ProofNodes, error) {
+	gindices, err := DescriptorGindices(p.Descriptor)
+	if err != nil {
+		return nil, err
+	}
+	if len(gindices) != len(p.Leaves) {
+		return nil, fmt.Errorf("invalid multiproof: %d leaves but descriptor has %d", len(p.Leaves), len(gindices))
+	}
+
+	nodes := ProofNodes{}
+	for i, gindex := range gindices {
+		nodes[gindex] = p.Leaves[i]
+	}
+
+	// every branch of the descriptor's tree has both children, so this bottoms out at the leaves
+	var compute func(gindex uint64) phase0.Root
+	compute = func(gindex uint64) phase0.Root {
+		if node, ok := nodes[gindex]; ok {
+			return node
+		}
+		nodes[gindex] = hashNodes(compute(gindex*2), compute(gindex*2+1))
+		return nodes[gindex]
+	}
+	compute(1)
+
+	return nodes, nil
+}
+
+// Verify checks the multiproof against `root`, and that it covers each of `gindices`. The returned
+// nodes can be turned into the per-leaf proofs taken by the contracts with Branch.
+func (p *CompactMultiproof) Verify(root phase0.Root, gindices []uint64) (ProofNodes, error) {
+	nodes, err := p.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	if nodes.Root() != root {
+		return nil, fmt.Errorf("multiproof has root %s, expected %s", nodes.Root(), root)
+	}
+	for _, gindex := range gindices {
+		if _, err := nodes.Get(gindex); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+// Root returns the root of the multiproof's tree.
+func (n ProofNodes) Root() phase0.Root {
+	return n[1]
+}
+
+// Get returns the node at `gindex`, if the multiproof covers it.
+func (n ProofNodes) Get(gindex uint64) (phase0.Root, error) {
+	node, ok := n[gindex]
+	if !ok {
+		return phase0.Root{}, fmt.Errorf("multiproof does not cover gindex %d", gindex)
+	}
+	return node, nil
+}
@@ -0,0 +1,177 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTree returns a tree of height 4, with 14 leaves and zero hashes on the right.
+func newTestTree(t *testing.T) LayeredTree {
+	leaves := make([]phase0.Root, 14)
+	for i := range leaves {
+		leaves[i] = phase0.Root{byte(i + 1)}
+	}
+	tree, err := ComputeMerkleTreeFromLeaves(leaves, 4)
+	require.NoError(t, err)
+	return LayeredTree(tree)
+}
+
+func TestComputeDescriptor(t *testing.T) {
+	tests := []struct {
+		name       string
+		gindices   []uint64
+		descriptor []byte
+		leaves     []uint64
+	}{
+		{name: "the root", gindices: []uint64{1}, descriptor: []byte{0b1000_0000}, leaves: []uint64{1}},
+		{name: "a child", gindices: []uint64{2}, descriptor: []byte{0b0110_0000}, leaves: []uint64{2, 3}},
+		{name: "a grandchild", gindices: []uint64{4}, descriptor: []byte{0b0011_1000}, leaves: []uint64{4, 5, 3}},
+		{name: "siblings", gindices: []uint64{5, 4}, descriptor: []byte{0b0011_1000}, leaves: []uint64{4, 5, 3}},
+		// the ancestor is computed from the node's proof
+		{name: "a node and its ancestor", gindices: []uint64{9, 2}, descriptor: []byte{0b0001_1110}, leaves: []uint64{8, 9, 5, 3}},
+		{name: "duplicates", gindices: []uint64{7, 7}, descriptor: []byte{0b0101_1000}, leaves: []uint64{2, 6, 7}},
+		{
+			name:       "across the tree",
+			gindices:   []uint64{17, 14},
+			descriptor: []byte{0b0000_1111, 0b0101_1000},
+			leaves:     []uint64{16, 17, 9, 5, 6, 14, 15},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			descriptor, err := ComputeDescriptor(test.gindices)
+			require.NoError(t, err)
+			assert.Equal(t, test.descriptor, descriptor)
+
+			leaves, err := DescriptorGindices(descriptor)
+			require.NoError(t, err)
+			assert.Equal(t, test.leaves, leaves)
+		})
+	}
+
+	_, err := ComputeDescriptor(nil)
+	assert.Error(t, err)
+	_, err = ComputeDescriptor([]uint64{2, 0})
+	assert.ErrorContains(t, err, "invalid gindex 0")
+}
+
+func TestDescriptorGindicesRejectsMalformedDescriptors(t *testing.T) {
+	tests := []struct {
+		name       string
+		descriptor []byte
+		err        string
+	}{
+		{name: "empty", descriptor: []byte{}, err: "not enough leaves"},
+		{name: "only branches", descriptor: []byte{0x00}, err: "not enough leaves"},
+		{name: "a missing right subtree", descriptor: []byte{0b0100_0000}, err: "not enough leaves"},
+		{name: "trailing byte", descriptor: []byte{0b1000_0000, 0x00}, err: "too many bytes"},
+		{name: "non-zero padding", descriptor: []byte{0b1001_0000}, err: "non-zero padding"},
+		{name: "a leaf after the tree", descriptor: []byte{0b0111_0000}, err: "non-zero padding"},
+		{name: "too deep", descriptor: append(bytes.Repeat([]byte{0x00}, 8), 0xff), err: "too deep"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DescriptorGindices(test.descriptor)
+			assert.ErrorContains(t, err, test.err)
+
+			_, err = (&CompactMultiproof{Descriptor: test.descriptor}).Nodes()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMultiproofRoundTrip(t *testing.T) {
+	tree := newTestTree(t)
+	root, err := tree.Get(1)
+	require.NoError(t, err)
+
+	for _, gindices := range [][]uint64{
+		{1},
+		{16},
+		{31},
+		// zero hashes on the right of the tree
+		{30, 31},
+		{16, 17, 31},
+		{5, 16, 29},
+		{2, 27},
+		{8, 9, 10, 11, 12, 13, 14, 15},
+	} {
+		proof, err := ProveMultiproof(tree, gindices)
+		require.NoError(t, err)
+		nodes, err := proof.Verify(root, gindices)
+		require.NoError(t, err, "gindices %v", gindices)
+		assert.Equal(t, root, nodes.Root())
+
+		for _, gindex := range gindices {
+			leaf, err := nodes.Get(gindex)
+			require.NoError(t, err)
+			want, err := tree.Get(gindex)
+			require.NoError(t, err)
+			assert.Equal(t, want, leaf)
+
+			// the branch rebuilt from the multiproof is the one from the full tree
+			branch, err := Branch(nodes, gindex, 1)
+			require.NoError(t, err)
+			fromTree, err := Branch(tree, gindex, 1)
+			require.NoError(t, err)
+			assert.Equal(t, fromTree, branch)
+			assert.True(t, ValidateProof(root, branch, leaf, GindexIndex(gindex)), "gindex %d", gindex)
+		}
+	}
+}
+
+func TestMultiproofVerifyRejects(t *testing.T) {
+	tree := newTestTree(t)
+	root, err := tree.Get(1)
+	require.NoError(t, err)
+
+	proof, err := ProveMultiproof(tree, []uint64{20})
+	require.NoError(t, err)
+
+	_, err = proof.Verify(phase0.Root{0x1}, []uint64{20})
+	assert.ErrorContains(t, err, "expected")
+
+	// covered by the proof's tree, but not proven
+	_, err = proof.Verify(root, []uint64{21})
+	assert.NoError(t, err)
+	_, err = proof.Verify(root, []uint64{22})
+	assert.ErrorContains(t, err, "does not cover gindex 22")
+
+	tampered := &CompactMultiproof{Descriptor: proof.Descriptor, Leaves: append([]phase0.Root{}, proof.Leaves...)}
+	tampered.Leaves[0][0] ^= 1
+	_, err = tampered.Verify(root, []uint64{20})
+	assert.Error(t, err)
+
+	truncated := &CompactMultiproof{Descriptor: proof.Descriptor, Leaves: proof.Leaves[1:]}
+	_, err = truncated.Verify(root, []uint64{20})
+	assert.ErrorContains(t, err, "leaves but descriptor has")
+}
+
+func TestBranch(t *testing.T) {
+	tree := newTestTree(t)
+
+	// against an ancestor, the branch stops below it
+	branch, err := Branch(tree, 20, 5)
+	require.NoError(t, err)
+	assert.Len(t, branch, 2)
+	ancestor, err := tree.Get(5)
+	require.NoError(t, err)
+	leaf, err := tree.Get(20)
+	require.NoError(t, err)
+	assert.True(t, ValidateProof(ancestor, branch, leaf, GindexIndex(20)&3))
+
+	branch, err = Branch(tree, 7, 7)
+	require.NoError(t, err)
+	assert.Empty(t, branch)
+
+	_, err = Branch(tree, 20, 3)
+	assert.ErrorContains(t, err, "is not below")
+	_, err = Branch(tree, 20, 0)
+	assert.Error(t, err)
+	_, err = Branch(tree, 64, 1)
+	assert.ErrorContains(t, err, "is not in a tree of height 4")
+}
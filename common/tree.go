@@ -0,0 +1,96 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Tree is a Merkle tree whose nodes can be looked up by gindex, which proofs can be made from.
+type Tree interface {
+	// Get returns the node at `gindex` (relative to the tree's root).
+	Get(gindex uint64) (phase0.Root, error)
+}
+
+// LayeredTree is a tree as computed by ComputeMerkleTreeFromLeaves: its layers from the leaves
+// up, where nodes missing on the right of a layer are zero hashes.
+type LayeredTree [][]phase0.Root
+
+func (t LayeredTree) Get(gindex uint64) (phase0.Root, error) {
+	height := len(t) - 1
+	depth := GindexDepth(gindex)
+	if gindex == 0 || depth > height {
+		return phase0.Root{}, fmt.Errorf("gindex %d is not in a tree of height %d", gindex, height)
+	}
+
+	layer := t[height-depth]
+	index := GindexIndex(gindex)
+	if index >= uint64(len(layer)) {
+		return zeroHashes[height-depth], nil
+	}
+	return layer[index], nil
+}
+
+// Leaf is a tree made of a single node, e.g. the length of a list.
+type Leaf phase0.Root
+
+func (l Leaf) Get(gindex uint64) (phase0.Root, error) {
+	if gindex != 1 {
+		return phase0.Root{}, fmt.Errorf("gindex %d is not in a single node tree", gindex)
+	}
+	return phase0.Root(l), nil
+}
+
+// GraftedTree is a tree made of subtrees, each rooted at its key's gindex. The subtree at 1 is the
+// top of the tree. A node is looked up in the deepest subtree it belongs to, so the leaves of a
+// subtree can be the roots of deeper ones (e.g. a beacon state's fields, with the validator list
+// grafted below its field).
+type GraftedTree map[uint64]Tree
+
+func (t GraftedTree) Get(gindex uint64) (phase0.Root, error) {
+	if gindex == 0 {
+		return phase0.Root{}, errors.New("invalid gindex 0")
+	}
+	for root := gindex; root > 0; root /= 2 {
+		if subtree, ok := t[root]; ok {
+			depth := GindexDepth(gindex) - GindexDepth(root)
+			return subtree.Get(1<<depth | gindex&(1<<depth-1))
+		}
+	}
+	return phase0.Root{}, fmt.Errorf("gindex %d is not in any subtree", gindex)
+}
+
+// GraftList grafts the tree of an SSZ list's elements, and its length, below the list's root at
+// `listGindex`.
+func (t GraftedTree) GraftList(listGindex uint64, elements Tree, length uint64) error {
+	dataGindex, err := ConcatGindices(listGindex, 2)
+	if err != nil {
+		return err
+	}
+	lengthGindex, err := ConcatGindices(listGindex, LIST_LENGTH_GINDEX)
+	if err != nil {
+		return err
+	}
+	t[dataGindex] = elements
+	t[lengthGindex] = Leaf(ConvertUint64ToRoot(length))
+	return nil
+}
+
+// Branch returns the proof of the node at `gindex` against its ancestor `ancestor` (1 for the
+// tree's root), from the bottom to the top: the format taken by ValidateProof and the contracts.
+func Branch(tree Tree, gindex uint64, ancestor uint64) (Proof, error) {
+	if ancestor == 0 || !IsGindexAncestor(ancestor, gindex) {
+		return nil, fmt.Errorf("gindex %d is not below gindex %d", gindex, ancestor)
+	}
+
+	proof := Proof{}
+	for node := gindex; node != ancestor; node /= 2 {
+		sibling, err := tree.Get(node ^ 1)
+		if err != nil {
+			return nil, err
+		}
+		proof = append(proof, sibling)
+	}
+	return proof, nil
+}
@@ -0,0 +1,149 @@
+package eigenpodproofs
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+
+	beacon "github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/common"
+)
+
+// StateProofProvider serves Merkle proofs of beacon states, so they can be proven without being
+// downloaded (e.g. a beacon node's /eth/v0/beacon/proof/state/{state_id} endpoint).
+type StateProofProvider interface {
+	// GetStateProof returns a multiproof of the nodes `gindices` of the beacon state `stateId`.
+	GetStateProof(ctx context.Context, stateId string, gindices []uint64) (*common.CompactMultiproof, error)
+}
+
+// ProveValidatorContainersFromNode generates the same proofs as ProveValidatorContainers, from a
+// multiproof of the oracle state served by `node` rather than from the state itself.
+// version is the fork of the oracle state.
+func (epp *EigenPodProofs) ProveValidatorContainersFromNode(ctx context.Context, node StateProofProvider, oracleBlockHeader *phase0.BeaconBlockHeader, version spec.DataVersion, validatorIndices []uint64) (*VerifyValidatorFieldsCallParams, error) {
+	validatorsLengthGindex, err := beacon.ValidatorsLengthGindex(version)
+	if err != nil {
+		return nil, err
+	}
+
+	// every field of every validator, so the validator fields can be read from the proof
+	gindices := []uint64{validatorsLengthGindex}
+	for _, validatorIndex := range validatorIndices {
+		for field := uint64(0); field < beacon.VALIDATOR_FIELDS_LENGTH; field++ {
+			gindex, err := beacon.ValidatorFieldGindex(version, validatorIndex, field)
+			if err != nil {
+				return nil, err
+			}
+			gindices = append(gindices, gindex)
+		}
+	}
+
+	stateNodes, err := getStateProofNodes(ctx, node, oracleBlockHeader, gindices)
+	if err != nil {
+		return nil, err
+	}
+
+	numValidators, err := getListLength(stateNodes, validatorsLengthGindex)
+	if err != nil {
+		return nil, err
+	}
+
+	validatorFields := make([][]Bytes32, len(validatorIndices))
+	for i, validatorIndex := range validatorIndices {
+		if validatorIndex >= numValidators {
+			return nil, fmt.Errorf("validator %d does not exist (the state has %d validators)", validatorIndex, numValidators)
+		}
+
+		validatorFields[i] = make([]Bytes32, beacon.VALIDATOR_FIELDS_LENGTH)
+		for field := uint64(0); field < beacon.VALIDATOR_FIELDS_LENGTH; field++ {
+			gindex, err := beacon.ValidatorFieldGindex(version, validatorIndex, field)
+			if err != nil {
+				return nil, err
+			}
+			fieldRoot, err := stateNodes.Get(gindex)
+			if err != nil {
+				return nil, err
+			}
+			validatorFields[i][field] = Bytes32(fieldRoot)
+		}
+	}
+
+	tree, err := beacon.BlockHeaderTree(oracleBlockHeader, stateNodes)
+	if err != nil {
+		return nil, err
+	}
+	return proveValidatorContainers(tree, version, validatorIndices, validatorFields)
+}
+
+// ProveCheckpointProofsFromNode generates the same proofs as ProveCheckpointProofs, from a
+// multiproof of the oracle state served by `node` rather than from the state itself.
+// version is the fork of the oracle state.
+func (epp *EigenPodProofs) ProveCheckpointProofsFromNode(ctx context.Context, node StateProofProvider, oracleBlockHeader *phase0.BeaconBlockHeader, version spec.DataVersion, validatorIndices []uint64) (*VerifyCheckpointProofsCallParams, error) {
+	validatorsLengthGindex, err := beacon.ValidatorsLengthGindex(version)
+	if err != nil {
+		return nil, err
+	}
+
+	// each validator's balance, and its pubkey (whose field root is the pod's pubkey hash)
+	gindices := []uint64{validatorsLengthGindex}
+	pubkeyGindices := make([]uint64, len(validatorIndices))
+	for i, validatorIndex := range validatorIndices {
+		balanceGindex, err := beacon.BalanceGindex(version, validatorIndex)
+		if err != nil {
+			return nil, err
+		}
+		pubkeyGindices[i], err = beacon.ValidatorFieldGindex(version, validatorIndex, beacon.VALIDATOR_PUBKEY_INDEX)
+		if err != nil {
+			return nil, err
+		}
+		gindices = append(gindices, balanceGindex, pubkeyGindices[i])
+	}
+
+	stateNodes, err := getStateProofNodes(ctx, node, oracleBlockHeader, gindices)
+	if err != nil {
+		return nil, err
+	}
+
+	numValidators, err := getListLength(stateNodes, validatorsLengthGindex)
+	if err != nil {
+		return nil, err
+	}
+
+	pubkeyHashes := make([][32]byte, len(validatorIndices))
+	for i, validatorIndex := range validatorIndices {
+		if validatorIndex >= numValidators {
+			return nil, fmt.Errorf("validator %d does not exist (the state has %d validators)", validatorIndex, numValidators)
+		}
+		pubkeyHashes[i], err = stateNodes.Get(pubkeyGindices[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tree, err := beacon.BlockHeaderTree(oracleBlockHeader, stateNodes)
+	if err != nil {
+		return nil, err
+	}
+	return proveCheckpointProofs(tree, version, validatorIndices, pubkeyHashes)
+}
+
+// getStateProofNodes fetches a multiproof of `gindices` in the state of `oracleBlockHeader`, and
+// checks it against the header's state root.
+func getStateProofNodes(ctx context.Context, node StateProofProvider, oracleBlockHeader *phase0.BeaconBlockHeader, gindices []uint64) (common.ProofNodes, error) {
+	multiproof, err := node.GetStateProof(ctx, oracleBlockHeader.StateRoot.String(), gindices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch state proof: %w", err)
+	}
+	return multiproof.Verify(oracleBlockHeader.StateRoot, gindices)
+}
+
+// getListLength reads the length of an SSZ list from its (little endian) length node.
+func getListLength(nodes common.ProofNodes, lengthGindex uint64) (uint64, error) {
+	length, err := nodes.Get(lengthGindex)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(length[:8]), nil
+}
@@ -2,7 +2,7 @@ package eigenpodproofs
 
 import (
 	"crypto/sha256"
-	"math/big"
+	"fmt"
 
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
@@ -53,37 +53,37 @@ func (epp *EigenPodProofs) ProveValidatorContainers(oracleBlockHeader *phase0.Be
 		return nil, err
 	}
 
-	verifyValidatorFieldsCallParams := &VerifyValidatorFieldsCallParams{}
-
-	// Get the state root proof
-	verifyValidatorFieldsCallParams.StateRootProof = &StateRootProof{}
-	verifyValidatorFieldsCallParams.StateRootProof.BeaconStateRoot = oracleBlockHeader.StateRoot
-	verifyValidatorFieldsCallParams.StateRootProof.Proof, err = beacon.ProveStateRootAgainstBlockHeader(oracleBlockHeader)
+	stateTree, err := epp.oracleStateTree(oracleBeaconState)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get beacon state top level roots
-	beaconStateTopLevelRoots, err := epp.ComputeBeaconStateTopLevelRoots(oracleBeaconState)
+	// graft the validator list below its field
+	validatorTree, err := epp.ComputeValidatorTree(oracleBeaconStateSlot, oracleBeaconStateValidators)
+	if err != nil {
+		return nil, err
+	}
+	validatorsGindex, err := beacon.BeaconStateFieldGindex(oracleBeaconState.Version, beacon.VALIDATORS_INDEX)
 	if err != nil {
 		return nil, err
 	}
+	if err := stateTree.GraftList(validatorsGindex, common.LayeredTree(validatorTree), uint64(len(oracleBeaconStateValidators))); err != nil {
+		return nil, err
+	}
 
-	verifyValidatorFieldsCallParams.ValidatorIndices = make([]uint64, len(validatorIndices))
-	verifyValidatorFieldsCallParams.ValidatorFieldsProofs = make([]common.Proof, len(validatorIndices))
-	verifyValidatorFieldsCallParams.ValidatorFields = make([][]Bytes32, len(validatorIndices))
+	validatorFields := make([][]Bytes32, len(validatorIndices))
 	for i, validatorIndex := range validatorIndices {
-		verifyValidatorFieldsCallParams.ValidatorIndices[i] = validatorIndex
-		// prove the validator fields against the beacon state
-		verifyValidatorFieldsCallParams.ValidatorFieldsProofs[i], err = epp.proveValidatorAgainstBeaconState(beaconStateTopLevelRoots, oracleBeaconStateSlot, oracleBeaconStateValidators, validatorIndex)
-		if err != nil {
-			return nil, err
+		if validatorIndex >= uint64(len(oracleBeaconStateValidators)) {
+			return nil, fmt.Errorf("validator %d does not exist (the state has %d validators)", validatorIndex, len(oracleBeaconStateValidators))
 		}
-
-		verifyValidatorFieldsCallParams.ValidatorFields[i] = ConvertValidatorToValidatorFields(oracleBeaconStateValidators[validatorIndex])
+		validatorFields[i] = ConvertValidatorToValidatorFields(oracleBeaconStateValidators[validatorIndex])
 	}
 
-	return verifyValidatorFieldsCallParams, nil
+	tree, err := beacon.BlockHeaderTree(oracleBlockHeader, stateTree)
+	if err != nil {
+		return nil, err
+	}
+	return proveValidatorContainers(tree, oracleBeaconState.Version, validatorIndices, validatorFields)
 }
 
 func (epp *EigenPodProofs) ProveCheckpointProofs(oracleBlockHeader *phase0.BeaconBlockHeader, oracleBeaconState *spec.VersionedBeaconState, validatorIndices []uint64) (*VerifyCheckpointProofsCallParams, error) {
@@ -102,53 +102,37 @@ func (epp *EigenPodProofs) ProveCheckpointProofs(oracleBlockHeader *phase0.Beaco
 		return nil, err
 	}
 
-	verifyCheckpointProofsCallParams := &VerifyCheckpointProofsCallParams{}
-
-	// Get beacon state top level roots
-	beaconStateTopLevelRoots, err := epp.ComputeBeaconStateTopLevelRoots(oracleBeaconState)
+	stateTree, err := epp.oracleStateTree(oracleBeaconState)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get state root proof
-	verifyCheckpointProofsCallParams.ValidatorBalancesRootProof = &ValidatorBalancesRootProof{}
-	stateRootProof, err := beacon.ProveStateRootAgainstBlockHeader(oracleBlockHeader)
+	// graft the balance list below its field
+	validatorBalancesTree, err := epp.ComputeValidatorBalancesTree(oracleBeaconStateSlot, oracleBeaconStateValidatorBalances)
 	if err != nil {
 		return nil, err
 	}
-
-	// prove the validator balances root against the beacon state root
-	balancesRootProof, err := beacon.ProveBeaconTopLevelRootAgainstBeaconState(beaconStateTopLevelRoots, beacon.BALANCES_INDEX)
+	balancesGindex, err := beacon.BeaconStateFieldGindex(oracleBeaconState.Version, beacon.BALANCES_INDEX)
 	if err != nil {
 		return nil, err
 	}
-
-	root, err := beaconStateTopLevelRoots.GetBalancesRoot()
-	if err != nil {
+	if err := stateTree.GraftList(balancesGindex, common.LayeredTree(validatorBalancesTree), uint64(len(oracleBeaconStateValidatorBalances))); err != nil {
 		return nil, err
 	}
-	verifyCheckpointProofsCallParams.ValidatorBalancesRootProof.ValidatorBalancesRoot = *root
-	verifyCheckpointProofsCallParams.ValidatorBalancesRootProof.Proof = append(balancesRootProof, stateRootProof...)
 
-	verifyCheckpointProofsCallParams.BalanceProofs = make([]*BalanceProof, len(validatorIndices))
+	pubkeyHashes := make([][32]byte, len(validatorIndices))
 	for i, validatorIndex := range validatorIndices {
-		balanceRoot, balanceProof, err := epp.proveValidatorBalanceAgainstBeaconState(beaconStateTopLevelRoots, oracleBeaconStateSlot, oracleBeaconStateValidatorBalances, validatorIndex)
-		if err != nil {
-			return nil, err
-		}
-
-		var pubkeyHash [32]byte
-		pubkeyHashVariable := computePubkeyHash(oracleBeaconStateValidators[validatorIndex].PublicKey[:])
-		copy(pubkeyHash[:], pubkeyHashVariable[:])
-
-		verifyCheckpointProofsCallParams.BalanceProofs[i] = &BalanceProof{
-			PubkeyHash:  pubkeyHash,
-			BalanceRoot: balanceRoot,
-			Proof:       balanceProof,
+		if validatorIndex >= uint64(len(oracleBeaconStateValidators)) {
+			return nil, fmt.Errorf("validator %d does not exist (the state has %d validators)", validatorIndex, len(oracleBeaconStateValidators))
 		}
+		pubkeyHashes[i] = computePubkeyHash(oracleBeaconStateValidators[validatorIndex].PublicKey[:])
 	}
 
-	return verifyCheckpointProofsCallParams, nil
+	tree, err := beacon.BlockHeaderTree(oracleBlockHeader, stateTree)
+	if err != nil {
+		return nil, err
+	}
+	return proveCheckpointProofs(tree, oracleBeaconState.Version, validatorIndices, pubkeyHashes)
 }
 
 func computePubkeyHash(publicKey []byte) [32]byte {
@@ -163,68 +147,109 @@ func computePubkeyHash(publicKey []byte) [32]byte {
 	)
 }
 
-func (epp *EigenPodProofs) proveValidatorAgainstBeaconState(beaconStateTopLevelRoots *beacon.VersionedBeaconStateTopLevelRoots, oracleBeaconStateSlot phase0.Slot, oracleBeaconStateValidators []*phase0.Validator, validatorIndex uint64) (common.Proof, error) {
-	// prove the validator list against the beacon state
-	validatorListProof, err := beacon.ProveBeaconTopLevelRootAgainstBeaconState(beaconStateTopLevelRoots, beacon.VALIDATORS_INDEX)
+// oracleStateTree returns the tree of the oracle state's top-level fields. The lists being proven
+// are grafted below their fields by the caller.
+func (epp *EigenPodProofs) oracleStateTree(oracleBeaconState *spec.VersionedBeaconState) (common.GraftedTree, error) {
+	beaconStateTopLevelRoots, err := epp.ComputeBeaconStateTopLevelRoots(oracleBeaconState)
 	if err != nil {
 		return nil, err
 	}
+	return beaconStateTopLevelRoots.Tree()
+}
 
-	// prove the validator root against the validator list root
-	validatorProof, err := epp.proveValidatorAgainstValidatorList(oracleBeaconStateSlot, oracleBeaconStateValidators, validatorIndex)
+// oracleStateGindex turns a gindex relative to the oracle state root into one relative to the
+// oracle block header root, which the contracts' proofs are rooted at.
+func oracleStateGindex(gindex uint64, err error) (uint64, error) {
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-
-	proof := append(validatorProof, validatorListProof...)
-
-	return proof, nil
+	return common.ConcatGindices(beacon.STATE_ROOT_GINDEX, gindex)
 }
 
-func (epp *EigenPodProofs) proveValidatorAgainstValidatorList(slot phase0.Slot, validators []*phase0.Validator, validatorIndex uint64) (common.Proof, error) {
-	validatorTree, err := epp.ComputeValidatorTree(slot, validators)
+// proveValidatorContainers lays out the proofs of the validators `validatorIndices` (whose fields
+// are `validatorFields`) from `tree`, the tree of the oracle block header covering them.
+func proveValidatorContainers(tree common.Tree, version spec.DataVersion, validatorIndices []uint64, validatorFields [][]Bytes32) (*VerifyValidatorFieldsCallParams, error) {
+	var err error
+	verifyValidatorFieldsCallParams := &VerifyValidatorFieldsCallParams{}
+
+	// Get the state root proof
+	verifyValidatorFieldsCallParams.StateRootProof = &StateRootProof{}
+	verifyValidatorFieldsCallParams.StateRootProof.BeaconStateRoot, err = tree.Get(beacon.STATE_ROOT_GINDEX)
 	if err != nil {
 		return nil, err
 	}
-
-	proof, err := common.ComputeMerkleProofFromTree(validatorTree, validatorIndex, beacon.VALIDATOR_TREE_HEIGHT)
+	verifyValidatorFieldsCallParams.StateRootProof.Proof, err = common.Branch(tree, beacon.STATE_ROOT_GINDEX, 1)
 	if err != nil {
 		return nil, err
 	}
-	// append the length of the validator array to the proof
-	// convert big endian to little endian
-	validatorListLenLE := BigToLittleEndian(big.NewInt(int64(len(validators))))
 
-	proof = append(proof, validatorListLenLE)
-	return proof, nil
-}
+	verifyValidatorFieldsCallParams.ValidatorIndices = make([]uint64, len(validatorIndices))
+	verifyValidatorFieldsCallParams.ValidatorFieldsProofs = make([]common.Proof, len(validatorIndices))
+	verifyValidatorFieldsCallParams.ValidatorFields = make([][]Bytes32, len(validatorIndices))
+	for i, validatorIndex := range validatorIndices {
+		verifyValidatorFieldsCallParams.ValidatorIndices[i] = validatorIndex
 
-func (epp *EigenPodProofs) proveValidatorBalanceAgainstBeaconState(beaconStateTopLevelRoots *beacon.VersionedBeaconStateTopLevelRoots, oracleBeaconStateSlot phase0.Slot, oracleBeaconStateValidatorBalances []phase0.Gwei, validatorIndex uint64) (phase0.Root, common.Proof, error) {
-	// prove the validator root against the validator list root
-	balanceRoot, balanceProof, err := epp.proveValidatorBalanceAgainstValidatorBalancesList(oracleBeaconStateSlot, oracleBeaconStateValidatorBalances, validatorIndex)
-	if err != nil {
-		return phase0.Root{}, nil, err
+		// prove the validator fields against the beacon state. The proof passes the length of the
+		// validator list, as the sibling of the list's elements.
+		validatorGindex, err := oracleStateGindex(beacon.ValidatorGindex(version, validatorIndex))
+		if err != nil {
+			return nil, err
+		}
+		verifyValidatorFieldsCallParams.ValidatorFieldsProofs[i], err = common.Branch(tree, validatorGindex, beacon.STATE_ROOT_GINDEX)
+		if err != nil {
+			return nil, err
+		}
+
+		verifyValidatorFieldsCallParams.ValidatorFields[i] = validatorFields[i]
 	}
 
-	return balanceRoot, balanceProof, nil
+	return verifyValidatorFieldsCallParams, nil
 }
 
-func (epp *EigenPodProofs) proveValidatorBalanceAgainstValidatorBalancesList(slot phase0.Slot, balances []phase0.Gwei, validatorIndex uint64) (phase0.Root, common.Proof, error) {
-	validatorBalancesTree, err := epp.ComputeValidatorBalancesTree(slot, balances)
+// proveCheckpointProofs lays out the balance proofs of the validators `validatorIndices` (whose
+// pubkey hashes are `pubkeyHashes`) from `tree`, the tree of the oracle block header covering them.
+func proveCheckpointProofs(tree common.Tree, version spec.DataVersion, validatorIndices []uint64, pubkeyHashes [][32]byte) (*VerifyCheckpointProofsCallParams, error) {
+	var err error
+	verifyCheckpointProofsCallParams := &VerifyCheckpointProofsCallParams{}
+
+	// prove the validator balances root against the block header, through the beacon state root
+	balancesGindex, err := oracleStateGindex(beacon.BeaconStateFieldGindex(version, beacon.BALANCES_INDEX))
+	if err != nil {
+		return nil, err
+	}
+	verifyCheckpointProofsCallParams.ValidatorBalancesRootProof = &ValidatorBalancesRootProof{}
+	verifyCheckpointProofsCallParams.ValidatorBalancesRootProof.ValidatorBalancesRoot, err = tree.Get(balancesGindex)
+	if err != nil {
+		return nil, err
+	}
+	verifyCheckpointProofsCallParams.ValidatorBalancesRootProof.Proof, err = common.Branch(tree, balancesGindex, 1)
 	if err != nil {
-		return phase0.Root{}, nil, err
+		return nil, err
 	}
 
-	// 4 balances per leaf
-	validatorBalancesIndex := validatorIndex / 4
+	verifyCheckpointProofsCallParams.BalanceProofs = make([]*BalanceProof, len(validatorIndices))
+	for i, validatorIndex := range validatorIndices {
+		// prove the balance against the balances root. The proof passes the length of the balance
+		// list, as the sibling of the list's elements.
+		balanceGindex, err := oracleStateGindex(beacon.BalanceGindex(version, validatorIndex))
+		if err != nil {
+			return nil, err
+		}
+		balanceRoot, err := tree.Get(balanceGindex)
+		if err != nil {
+			return nil, err
+		}
+		balanceProof, err := common.Branch(tree, balanceGindex, balancesGindex)
+		if err != nil {
+			return nil, err
+		}
 
-	proof, err := common.ComputeMerkleProofFromTree(validatorBalancesTree, validatorBalancesIndex, beacon.GetValidatorBalancesProofDepth(len(balances)))
-	if err != nil {
-		return phase0.Root{}, nil, err
+		verifyCheckpointProofsCallParams.BalanceProofs[i] = &BalanceProof{
+			PubkeyHash:  pubkeyHashes[i],
+			BalanceRoot: balanceRoot,
+			Proof:       balanceProof,
+		}
 	}
-	// append the little endian length of the balances array to the proof
-	validatorBalancesListLenLE := BigToLittleEndian(big.NewInt(int64(len(balances))))
 
-	proof = append(proof, validatorBalancesListLenLE)
-	return validatorBalancesTree[0][validatorBalancesIndex], proof, nil
+	return verifyCheckpointProofsCallParams, nil
 }
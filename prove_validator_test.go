@@ -1,6 +1,7 @@
 package eigenpodproofs_test
 
 import (
+	"context"
 	"testing"
 
 	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
@@ -57,6 +58,104 @@ func TestProveValidatorBalances(t *testing.T) {
 	}
 }
 
+// stateTreeProvider serves multiproofs of a beacon state, like a beacon node's proof endpoint.
+type stateTreeProvider struct {
+	tree common.Tree
+}
+
+func (p *stateTreeProvider) GetStateProof(ctx context.Context, stateId string, gindices []uint64) (*common.CompactMultiproof, error) {
+	return common.ProveMultiproof(p.tree, gindices)
+}
+
+func TestProveFromNode(t *testing.T) {
+	slot, err := beaconState.Slot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	validators, err := beaconState.Validators()
+	if err != nil {
+		t.Fatal(err)
+	}
+	balances, err := beaconState.ValidatorBalances()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validatorIndices := []uint64{}
+	for i := int(0); i < len(validators); i += 1000 {
+		validatorIndices = append(validatorIndices, uint64(i))
+	}
+
+	// the state's tree, down to the fields of the validators being proven
+	topLevelRoots, err := epp.ComputeBeaconStateTopLevelRoots(beaconState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateTree, err := topLevelRoots.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	validatorTree, err := epp.ComputeValidatorTree(slot, validators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validatorsGindex, err := beacon.BeaconStateFieldGindex(beaconState.Version, beacon.VALIDATORS_INDEX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stateTree.GraftList(validatorsGindex, common.LayeredTree(validatorTree), uint64(len(validators))); err != nil {
+		t.Fatal(err)
+	}
+	balancesTree, err := epp.ComputeValidatorBalancesTree(slot, balances)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balancesGindex, err := beacon.BeaconStateFieldGindex(beaconState.Version, beacon.BALANCES_INDEX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stateTree.GraftList(balancesGindex, common.LayeredTree(balancesTree), uint64(len(balances))); err != nil {
+		t.Fatal(err)
+	}
+	for _, validatorIndex := range validatorIndices {
+		fields := eigenpodproofs.ConvertValidatorToValidatorFields(validators[validatorIndex])
+		leaves := make([]phase0.Root, len(fields))
+		for i, field := range fields {
+			leaves[i] = phase0.Root(field)
+		}
+		fieldsTree, err := common.ComputeMerkleTreeFromLeaves(leaves, beacon.VALIDATOR_FIELDS_TREE_HEIGHT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		validatorGindex, err := beacon.ValidatorGindex(beaconState.Version, validatorIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stateTree[validatorGindex] = common.LayeredTree(fieldsTree)
+	}
+	node := &stateTreeProvider{tree: stateTree}
+
+	verifyValidatorFieldsCallParams, err := epp.ProveValidatorContainers(beaconHeader, beaconState, validatorIndices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyValidatorFieldsCallParamsFromNode, err := epp.ProveValidatorContainersFromNode(context.Background(), node, beaconHeader, beaconState.Version, validatorIndices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, verifyValidatorFieldsCallParams, verifyValidatorFieldsCallParamsFromNode)
+
+	verifyCheckpointProofsCallParams, err := epp.ProveCheckpointProofs(beaconHeader, beaconState, validatorIndices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyCheckpointProofsCallParamsFromNode, err := epp.ProveCheckpointProofsFromNode(context.Background(), node, beaconHeader, beaconState.Version, validatorIndices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, verifyCheckpointProofsCallParams, verifyCheckpointProofsCallParamsFromNode)
+}
+
 func verifyStateRootAgainstBlockHeader(t *testing.T, epp *eigenpodproofs.EigenPodProofs, oracleBlockHeader *phase0.BeaconBlockHeader, oracleState *spec.VersionedBeaconState, proof common.Proof) bool {
 	root, err := oracleBlockHeader.HashTreeRoot()
 	if err != nil {